@@ -1,26 +1,142 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
+	"github.com/lumiforge/docfactory-backend/internal/apikeys"
+	"github.com/lumiforge/docfactory-backend/internal/assets"
+	"github.com/lumiforge/docfactory-backend/internal/auth"
+	"github.com/lumiforge/docfactory-backend/internal/certificates"
+	"github.com/lumiforge/docfactory-backend/internal/documents"
 	"github.com/lumiforge/docfactory-backend/internal/httpapi"
+	"github.com/lumiforge/docfactory-backend/internal/idempotency"
+	"github.com/lumiforge/docfactory-backend/internal/quotas"
+	"github.com/lumiforge/docfactory-backend/internal/ratelimit"
 	"github.com/lumiforge/docfactory-backend/internal/templates"
+	"github.com/lumiforge/docfactory-backend/internal/tenants"
+	"github.com/lumiforge/docfactory-backend/internal/users"
 )
 
 func main() {
+	tenantService := tenants.NewTenantService(tenants.NewInMemoryRepository())
+	quotaService := quotas.NewQuotaService(quotas.NewInMemoryRepository(), tenantService, quotas.DefaultPlans())
 	repo := templates.NewInMemoryRepository()
-	service := templates.NewTemplateService(repo)
-	handler := httpapi.NewTemplateHandler(service)
+	service := templates.NewTemplateService(repo).WithQuota(quotaService).
+		WithMetadataVersioning(boolFromEnv("TEMPLATE_VERSION_METADATA", false))
+	quotaService.SetTemplateCounter(service)
+	assetService := assets.NewService(assets.NewInMemoryRepository()).WithQuota(quotaService)
+	certificateService := certificates.NewService(certificates.NewInMemoryRepository(), secretFromEnv("CERTIFICATE_ENCRYPTION_KEY"))
+	documentService := documents.NewService(documents.NewInMemoryRepository(), documents.NewInMemoryFileStore(), service, assetService).
+		WithQuota(quotaService).
+		WithVerification(documents.NewSigner(secretFromEnv("DOCUMENT_SIGNING_SECRET")), publicURL()).
+		WithSigning(certificateService)
+	service.WithVersionReferences(documentService)
+	userService := users.NewUserService(users.NewInMemoryRepository(), tenantService, secretFromEnv("INVITATION_SECRET"))
+	authOptions := auth.DefaultOptions()
+	keyRing := auth.NewKeyRing(auth.NewInMemoryKeyRepository(), secretFromEnv("JWT_SECRET"), authOptions.AccessTTL)
+	authService := auth.NewAuthService(auth.NewInMemoryRepository(), userService, keyRing, authOptions)
 
-	addr := ":8080"
-	if v := os.Getenv("PORT"); v != "" {
-		addr = ":" + v
-	}
+	addr := ":" + port()
+
+	router := httpapi.Router(httpapi.Handlers{
+		Templates:    httpapi.NewTemplateHandler(service, userService),
+		Assets:       httpapi.NewAssetHandler(assetService),
+		Documents:    httpapi.NewDocumentHandler(documentService),
+		Certificates: httpapi.NewCertificateHandler(certificateService),
+		Tenants:      httpapi.NewTenantHandler(tenantService),
+		Users:        httpapi.NewUserHandler(userService),
+		Auth:         httpapi.NewAuthHandler(authService),
+		APIKeys:      httpapi.NewAPIKeyHandler(apikeys.NewKeyService(apikeys.NewInMemoryRepository())),
+		Quotas:       httpapi.NewQuotaHandler(quotaService),
+		RateLimits:   httpapi.NewRateLimitHandler(ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.DefaultConfig())),
+		PublicLimits: ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.PublicConfig()),
+		Idempotency:  idempotency.NewService(idempotency.NewInMemoryRepository(), durationFromEnv("IDEMPOTENCY_TTL", idempotency.DefaultTTL)),
+		AdminToken:   os.Getenv("ADMIN_TOKEN"),
+	})
+
+	go pruneVersions(service, durationFromEnv("VERSION_PRUNE_INTERVAL", time.Hour))
 
 	log.Printf("starting API server on %s", addr)
-	if err := http.ListenAndServe(addr, httpapi.Router(handler)); err != nil {
+	if err := http.ListenAndServe(addr, router); err != nil {
 		log.Fatalf("server error: %v", err)
 	}
 }
+
+// pruneVersions applies template version retention policies periodically.
+func pruneVersions(service *templates.TemplateService, interval time.Duration) {
+	for range time.Tick(interval) {
+		results, err := service.PruneAll(context.Background())
+		if err != nil {
+			log.Printf("prune template versions: %v", err)
+		}
+		for _, res := range results {
+			if res.VersionsPruned > 0 {
+				log.Printf("pruned %d versions and %d schemas of tenant %s", res.VersionsPruned, len(res.SchemasDeleted), res.TenantID)
+			}
+		}
+	}
+}
+
+// port returns the port to listen on, 8080 unless PORT is set.
+func port() string {
+	if v := os.Getenv("PORT"); v != "" {
+		return v
+	}
+	return "8080"
+}
+
+// publicURL returns the address clients reach the API at, which
+// verification QR codes of documents link to. PUBLIC_URL should be set in
+// production; the default suits local runs only.
+func publicURL() string {
+	if v := os.Getenv("PUBLIC_URL"); v != "" {
+		return v
+	}
+	return "http://localhost:" + port()
+}
+
+// secretFromEnv reads signing secret from environment, falling back to a random
+// per-process secret that invalidates issued tokens on restart.
+func secretFromEnv(name string) []byte {
+	if v := os.Getenv(name); v != "" {
+		return []byte(v)
+	}
+	log.Printf("%s is not set, using ephemeral secret", name)
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatalf("generate %s: %v", name, err)
+	}
+	return secret
+}
+
+// durationFromEnv parses Go duration such as "12h" from environment.
+func durationFromEnv(name string, fallback time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Fatalf("%s must be a positive duration: %q", name, v)
+	}
+	return d
+}
+
+// boolFromEnv parses boolean such as "true" or "0" from environment.
+func boolFromEnv(name string, fallback bool) bool {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		log.Fatalf("%s must be a boolean: %q", name, v)
+	}
+	return b
+}
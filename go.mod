@@ -1,3 +1,7 @@
 module github.com/lumiforge/docfactory-backend
 
 go 1.24.2
+
+require golang.org/x/crypto v0.45.0
+
+require golang.org/x/sys v0.38.0 // indirect
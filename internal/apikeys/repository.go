@@ -0,0 +1,22 @@
+package apikeys
+
+import (
+	"context"
+	"time"
+)
+
+// ListOptions configure listing behaviour.
+type ListOptions struct {
+	TenantID       string
+	IncludeRevoked bool
+}
+
+// Repository defines persistence layer for API keys.
+type Repository interface {
+	ListKeys(ctx context.Context, opt ListOptions) ([]APIKey, error)
+	GetKey(ctx context.Context, tenantID, keyID string) (*APIKey, error)
+	GetKeyByPrefix(ctx context.Context, prefix string) (*APIKey, error)
+	CreateKey(ctx context.Context, key APIKey) (*APIKey, error)
+	RevokeKey(ctx context.Context, tenantID, keyID string, at time.Time) (*APIKey, error)
+	TouchKey(ctx context.Context, keyID string, at time.Time) error
+}
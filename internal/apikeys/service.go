@@ -0,0 +1,241 @@
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/ids"
+	"github.com/lumiforge/docfactory-backend/internal/users"
+)
+
+// lastUsedResolution limits how often LastUsedAt is persisted for busy keys.
+const lastUsedResolution = time.Minute
+
+// CreateRequest describes a new API key.
+type CreateRequest struct {
+	Name      string
+	Role      users.Role
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+// NewInMemoryRepository creates thread-safe repository for prototyping.
+func NewInMemoryRepository() Repository {
+	return &inMemoryRepository{keys: make(map[string]APIKey)}
+}
+
+// KeyService manages API keys and authenticates their secrets.
+type KeyService struct {
+	repo Repository
+}
+
+// NewKeyService creates service instance.
+func NewKeyService(repo Repository) *KeyService {
+	return &KeyService{repo: repo}
+}
+
+// CreateKey issues new key on behalf of actor and returns the secret, which
+// is never stored and cannot be retrieved again. Keys cannot exceed the role
+// of the admin who creates them.
+func (s *KeyService) CreateKey(ctx context.Context, actor users.User, req CreateRequest) (*APIKey, string, error) {
+	if !actor.Role.AtLeast(users.RoleAdmin) {
+		return nil, "", fmt.Errorf("only admins can manage api keys: %w", ErrForbidden)
+	}
+	if req.Role == "" {
+		req.Role = users.RoleEditor
+	}
+	if !actor.Role.AtLeast(req.Role) {
+		return nil, "", fmt.Errorf("key role cannot exceed own role: %w", ErrForbidden)
+	}
+	prefix, secret, err := generateSecret()
+	if err != nil {
+		return nil, "", err
+	}
+	key := APIKey{
+		KeyID:     ids.New(),
+		TenantID:  actor.TenantID,
+		Name:      strings.TrimSpace(req.Name),
+		Role:      req.Role,
+		Scopes:    req.Scopes,
+		Prefix:    prefix,
+		KeyHash:   hashSecret(secret),
+		CreatedBy: actor.UserID,
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: req.ExpiresAt,
+	}
+	if err := key.Validate(); err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	created, err := s.repo.CreateKey(ctx, key)
+	if err != nil {
+		return nil, "", err
+	}
+	return created, secret, nil
+}
+
+// ListKeys returns keys of tenant without secrets.
+func (s *KeyService) ListKeys(ctx context.Context, opt ListOptions) ([]APIKey, error) {
+	return s.repo.ListKeys(ctx, opt)
+}
+
+// GetKey fetches key metadata.
+func (s *KeyService) GetKey(ctx context.Context, tenantID, keyID string) (*APIKey, error) {
+	return s.repo.GetKey(ctx, tenantID, keyID)
+}
+
+// RevokeKey permanently disables key.
+func (s *KeyService) RevokeKey(ctx context.Context, actor users.User, keyID string) (*APIKey, error) {
+	if !actor.Role.AtLeast(users.RoleAdmin) {
+		return nil, fmt.Errorf("only admins can manage api keys: %w", ErrForbidden)
+	}
+	return s.repo.RevokeKey(ctx, actor.TenantID, keyID, time.Now().UTC())
+}
+
+// Authenticate resolves presented secret into usable key and records usage.
+func (s *KeyService) Authenticate(ctx context.Context, secret string) (*APIKey, error) {
+	prefix, ok := parsePrefix(secret)
+	if !ok {
+		return nil, ErrInvalidKey
+	}
+	key, err := s.repo.GetKeyByPrefix(ctx, prefix)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrInvalidKey
+		}
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(key.KeyHash), []byte(hashSecret(secret))) != 1 {
+		return nil, ErrInvalidKey
+	}
+	now := time.Now().UTC()
+	if !key.Usable(now) {
+		return nil, ErrInvalidKey
+	}
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= lastUsedResolution {
+		if err := s.repo.TouchKey(ctx, key.KeyID, now); err != nil {
+			return nil, err
+		}
+		key.LastUsedAt = &now
+	}
+	return key, nil
+}
+
+// generateSecret returns lookup prefix and full secret dfk_<prefix>_<random>.
+func generateSecret() (string, string, error) {
+	buf := make([]byte, 38)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("generate api key: %w", err)
+	}
+	prefix := hex.EncodeToString(buf[:6])
+	return prefix, TokenPrefix + prefix + "_" + base64.RawURLEncoding.EncodeToString(buf[6:]), nil
+}
+
+func parsePrefix(secret string) (string, bool) {
+	rest, ok := strings.CutPrefix(secret, TokenPrefix)
+	if !ok {
+		return "", false
+	}
+	prefix, tail, ok := strings.Cut(rest, "_")
+	if !ok || prefix == "" || tail == "" {
+		return "", false
+	}
+	return prefix, true
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// inMemoryRepository is prototyping repository with maps.
+type inMemoryRepository struct {
+	keys map[string]APIKey
+	mu   sync.RWMutex
+}
+
+func (r *inMemoryRepository) ListKeys(ctx context.Context, opt ListOptions) ([]APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := []APIKey{}
+	for _, key := range r.keys {
+		if key.TenantID != opt.TenantID {
+			continue
+		}
+		if !opt.IncludeRevoked && key.RevokedAt != nil {
+			continue
+		}
+		result = append(result, key)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.After(result[j].CreatedAt)
+	})
+	return result, nil
+}
+
+func (r *inMemoryRepository) GetKey(ctx context.Context, tenantID, keyID string) (*APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	key, ok := r.keys[keyID]
+	if !ok || key.TenantID != tenantID {
+		return nil, ErrNotFound
+	}
+	clone := key
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) GetKeyByPrefix(ctx context.Context, prefix string) (*APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, key := range r.keys {
+		if key.Prefix == prefix {
+			clone := key
+			return &clone, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *inMemoryRepository) CreateKey(ctx context.Context, key APIKey) (*APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.keys[key.KeyID] = key
+	clone := key
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) RevokeKey(ctx context.Context, tenantID, keyID string, at time.Time) (*APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, ok := r.keys[keyID]
+	if !ok || key.TenantID != tenantID {
+		return nil, ErrNotFound
+	}
+	if key.RevokedAt == nil {
+		key.RevokedAt = &at
+		r.keys[keyID] = key
+	}
+	clone := key
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) TouchKey(ctx context.Context, keyID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, ok := r.keys[keyID]
+	if !ok {
+		return ErrNotFound
+	}
+	key.LastUsedAt = &at
+	r.keys[keyID] = key
+	return nil
+}
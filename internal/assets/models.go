@@ -0,0 +1,70 @@
+package assets
+
+import (
+	"errors"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/validation"
+)
+
+// MaxSize caps size of uploaded assets in bytes.
+const MaxSize = 1 << 20
+
+// Type enumerates asset kinds.
+type Type string
+
+const (
+	TypeLogo      Type = "logo"
+	TypeImage     Type = "image"
+	TypeWatermark Type = "watermark"
+)
+
+// Content types accepted for uploads.
+const (
+	ContentTypePNG  = "image/png"
+	ContentTypeJPEG = "image/jpeg"
+)
+
+// Asset represents the assets table structure. Content is stored separately
+// and fetched with Service.Content.
+type Asset struct {
+	AssetID    string    `json:"asset_id"`
+	TenantID   string    `json:"tenant_id"`
+	TemplateID string    `json:"template_id,omitempty"`
+	Type       Type      `json:"type"`
+	FileName   string    `json:"file_name"`
+	StorageURL string    `json:"storage_url"`
+	MimeType   string    `json:"mime_type"`
+	Size       int64     `json:"size"`
+	UploadedBy string    `json:"uploaded_by"`
+	UploadedAt time.Time `json:"uploaded_at"`
+}
+
+var (
+	// ErrNotFound is returned when asset does not exist in tenant.
+	ErrNotFound = errors.New("assets: asset not found")
+	// ErrInvalidInput indicates validation error.
+	ErrInvalidInput = errors.New("assets: invalid input")
+)
+
+// Validate ensures asset business rules.
+func (a Asset) Validate() error {
+	var errs validation.Errors
+	errs.Required("tenant_id", a.TenantID)
+	switch a.Type {
+	case TypeLogo, TypeImage, TypeWatermark:
+	default:
+		errs.Add("type", validation.CodeInvalidValue, "is invalid")
+	}
+	errs.Length("file_name", a.FileName, 1, 255)
+	switch a.MimeType {
+	case ContentTypePNG, ContentTypeJPEG:
+	default:
+		errs.Add("content", validation.CodeInvalidValue, "must be a PNG or JPEG image")
+	}
+	if a.Size <= 0 || a.Size > MaxSize {
+		errs.Add("content", validation.CodeLength, "must be between 1 byte and 1 MiB")
+	}
+	errs.Required("uploaded_by", a.UploadedBy)
+	return errs.Err()
+}
@@ -0,0 +1,20 @@
+package assets
+
+import (
+	"context"
+)
+
+// ListOptions configure listing behaviour.
+type ListOptions struct {
+	TenantID string
+	Type     Type
+}
+
+// Repository defines persistence layer for asset metadata and content.
+type Repository interface {
+	ListAssets(ctx context.Context, opt ListOptions) ([]Asset, error)
+	GetAsset(ctx context.Context, tenantID, assetID string) (*Asset, error)
+	GetContent(ctx context.Context, tenantID, assetID string) ([]byte, error)
+	CreateAsset(ctx context.Context, asset Asset, content []byte) (*Asset, error)
+	DeleteAsset(ctx context.Context, tenantID, assetID string) error
+}
@@ -0,0 +1,193 @@
+package assets
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/jpeg" // register decoders used to check uploads
+	_ "image/png"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/ids"
+)
+
+// NewInMemoryRepository creates thread-safe repository for prototyping.
+func NewInMemoryRepository() Repository {
+	return &inMemoryRepository{assets: make(map[string]Asset), contents: make(map[string][]byte)}
+}
+
+// StorageQuota accounts stored bytes against tenant plan.
+type StorageQuota interface {
+	AddStorage(ctx context.Context, tenantID string, delta int64) error
+}
+
+type unlimitedStorage struct{}
+
+func (unlimitedStorage) AddStorage(context.Context, string, int64) error { return nil }
+
+// UploadRequest describes a new asset.
+type UploadRequest struct {
+	TenantID   string
+	TemplateID string
+	Type       Type
+	FileName   string
+	UploadedBy string
+	Content    []byte
+}
+
+// Service stores tenant logos and images used by templates.
+type Service struct {
+	repo  Repository
+	quota StorageQuota
+}
+
+// NewService creates service instance.
+func NewService(repo Repository) *Service {
+	return &Service{repo: repo, quota: unlimitedStorage{}}
+}
+
+// WithQuota enables storage limit enforcement.
+func (s *Service) WithQuota(quota StorageQuota) *Service {
+	s.quota = quota
+	return s
+}
+
+// Upload validates that content is a PNG or JPEG image and stores it.
+func (s *Service) Upload(ctx context.Context, req UploadRequest) (*Asset, error) {
+	assetID := ids.New()
+	asset := Asset{
+		AssetID:    assetID,
+		TenantID:   req.TenantID,
+		TemplateID: req.TemplateID,
+		Type:       req.Type,
+		FileName:   strings.TrimSpace(req.FileName),
+		StorageURL: fmt.Sprintf("tenants/%s/assets/%s", req.TenantID, assetID),
+		MimeType:   http.DetectContentType(req.Content),
+		Size:       int64(len(req.Content)),
+		UploadedBy: req.UploadedBy,
+		UploadedAt: time.Now().UTC(),
+	}
+	if err := asset.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	if _, _, err := image.DecodeConfig(bytes.NewReader(req.Content)); err != nil {
+		return nil, fmt.Errorf("%w: content is not a valid image: %v", ErrInvalidInput, err)
+	}
+	if err := s.quota.AddStorage(ctx, req.TenantID, asset.Size); err != nil {
+		return nil, err
+	}
+	created, err := s.repo.CreateAsset(ctx, asset, req.Content)
+	if err != nil {
+		_ = s.quota.AddStorage(ctx, req.TenantID, -asset.Size)
+		return nil, err
+	}
+	return created, nil
+}
+
+// ListAssets returns assets of tenant, newest first.
+func (s *Service) ListAssets(ctx context.Context, opt ListOptions) ([]Asset, error) {
+	return s.repo.ListAssets(ctx, opt)
+}
+
+// GetAsset fetches asset metadata.
+func (s *Service) GetAsset(ctx context.Context, tenantID, assetID string) (*Asset, error) {
+	return s.repo.GetAsset(ctx, tenantID, assetID)
+}
+
+// Content returns asset metadata and stored bytes.
+func (s *Service) Content(ctx context.Context, tenantID, assetID string) (*Asset, []byte, error) {
+	asset, err := s.repo.GetAsset(ctx, tenantID, assetID)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := s.repo.GetContent(ctx, tenantID, assetID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return asset, content, nil
+}
+
+// DeleteAsset removes asset and releases its storage.
+func (s *Service) DeleteAsset(ctx context.Context, tenantID, assetID string) error {
+	asset, err := s.repo.GetAsset(ctx, tenantID, assetID)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.DeleteAsset(ctx, tenantID, assetID); err != nil {
+		return err
+	}
+	return s.quota.AddStorage(ctx, tenantID, -asset.Size)
+}
+
+// inMemoryRepository is prototyping repository with maps.
+type inMemoryRepository struct {
+	assets   map[string]Asset
+	contents map[string][]byte
+	mu       sync.RWMutex
+}
+
+func (r *inMemoryRepository) ListAssets(ctx context.Context, opt ListOptions) ([]Asset, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := []Asset{}
+	for _, asset := range r.assets {
+		if asset.TenantID != opt.TenantID {
+			continue
+		}
+		if opt.Type != "" && asset.Type != opt.Type {
+			continue
+		}
+		result = append(result, asset)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].UploadedAt.After(result[j].UploadedAt)
+	})
+	return result, nil
+}
+
+func (r *inMemoryRepository) GetAsset(ctx context.Context, tenantID, assetID string) (*Asset, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	asset, ok := r.assets[assetID]
+	if !ok || asset.TenantID != tenantID {
+		return nil, ErrNotFound
+	}
+	clone := asset
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) GetContent(ctx context.Context, tenantID, assetID string) ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	asset, ok := r.assets[assetID]
+	if !ok || asset.TenantID != tenantID {
+		return nil, ErrNotFound
+	}
+	return bytes.Clone(r.contents[assetID]), nil
+}
+
+func (r *inMemoryRepository) CreateAsset(ctx context.Context, asset Asset, content []byte) (*Asset, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.assets[asset.AssetID] = asset
+	r.contents[asset.AssetID] = bytes.Clone(content)
+	clone := asset
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) DeleteAsset(ctx context.Context, tenantID, assetID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	asset, ok := r.assets[assetID]
+	if !ok || asset.TenantID != tenantID {
+		return ErrNotFound
+	}
+	delete(r.assets, assetID)
+	delete(r.contents, assetID)
+	return nil
+}
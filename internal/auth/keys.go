@@ -0,0 +1,214 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/ids"
+)
+
+const issuer = "docfactory"
+
+// SigningKey is an HMAC key used to sign access tokens.
+type SigningKey struct {
+	KeyID     string     `json:"kid"`
+	Secret    []byte     `json:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at"`
+}
+
+// KeyRing signs and verifies access tokens with the keys of its repository,
+// so that every instance shares them. The active key is the one not retired.
+// Rotation makes a new key active immediately while retired keys keep
+// verifying tokens until the grace period, normally the access token
+// lifetime, elapses.
+type KeyRing struct {
+	repo    KeyRepository
+	initial SigningKey
+	grace   time.Duration
+}
+
+// NewKeyRing creates key ring storing keys in repo. Secret is the initial key,
+// stored unless the repository has keys already; its ID is derived from the
+// secret, so that instances started together agree on it. Grace controls how
+// long retired keys keep verifying tokens.
+func NewKeyRing(repo KeyRepository, secret []byte, grace time.Duration) *KeyRing {
+	sum := sha256.Sum256(secret)
+	initial := SigningKey{KeyID: hex.EncodeToString(sum[:8]), Secret: secret, CreatedAt: time.Now().UTC()}
+	return &KeyRing{repo: repo, initial: initial, grace: grace}
+}
+
+// Rotate generates a new random active key and retires the current one.
+func (k *KeyRing) Rotate(ctx context.Context) (SigningKey, error) {
+	if _, err := k.keys(ctx); err != nil {
+		return SigningKey{}, err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return SigningKey{}, fmt.Errorf("generate signing key: %w", err)
+	}
+	key := SigningKey{KeyID: ids.New(), Secret: secret, CreatedAt: time.Now().UTC()}
+	if err := k.repo.RotateSigningKey(ctx, key, k.grace); err != nil {
+		return SigningKey{}, err
+	}
+	return key, nil
+}
+
+// Keys returns metadata of keys currently accepted for verification.
+func (k *KeyRing) Keys(ctx context.Context) ([]SigningKey, error) {
+	keys, err := k.keys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now().UTC()
+	return slices.DeleteFunc(keys, func(key SigningKey) bool { return k.expired(key, now) }), nil
+}
+
+// keys returns stored keys, storing the initial key first if there are none.
+func (k *KeyRing) keys(ctx context.Context) ([]SigningKey, error) {
+	keys, err := k.repo.ListSigningKeys(ctx)
+	if err != nil || len(keys) > 0 {
+		return keys, err
+	}
+	if err := k.repo.InitSigningKey(ctx, k.initial); err != nil {
+		return nil, err
+	}
+	return k.repo.ListSigningKeys(ctx)
+}
+
+func (k *KeyRing) expired(key SigningKey, now time.Time) bool {
+	return key.RetiredAt != nil && now.Sub(*key.RetiredAt) > k.grace
+}
+
+type tokenHeader struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ"`
+	KeyID     string `json:"kid"`
+}
+
+// Sign encodes claims as HS256 JWT signed by the active key.
+func (k *KeyRing) Sign(ctx context.Context, claims Claims) (string, error) {
+	keys, err := k.keys(ctx)
+	if err != nil {
+		return "", err
+	}
+	i := slices.IndexFunc(keys, func(key SigningKey) bool { return key.RetiredAt == nil })
+	if i < 0 {
+		return "", fmt.Errorf("active signing key is missing")
+	}
+	key := keys[i]
+	header, err := json.Marshal(tokenHeader{Algorithm: "HS256", Type: "JWT", KeyID: key.KeyID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(payload)
+	return unsigned + "." + enc.EncodeToString(signHS256(key.Secret, unsigned)), nil
+}
+
+// Verify checks token signature, issuer and expiry and returns its claims.
+func (k *KeyRing) Verify(ctx context.Context, token string, now time.Time) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidToken
+	}
+	enc := base64.RawURLEncoding
+	rawHeader, err := enc.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var header tokenHeader
+	if err := json.Unmarshal(rawHeader, &header); err != nil || header.Algorithm != "HS256" {
+		return nil, ErrInvalidToken
+	}
+	keys, err := k.keys(ctx)
+	if err != nil {
+		return nil, err
+	}
+	i := slices.IndexFunc(keys, func(key SigningKey) bool { return key.KeyID == header.KeyID })
+	if i < 0 || k.expired(keys[i], now) {
+		return nil, ErrInvalidToken
+	}
+	key := keys[i]
+	signature, err := enc.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, signHS256(key.Secret, parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidToken
+	}
+	rawClaims, err := enc.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	var claims Claims
+	if err := json.Unmarshal(rawClaims, &claims); err != nil {
+		return nil, ErrInvalidToken
+	}
+	if claims.Issuer != issuer || now.Unix() >= claims.ExpiresAt {
+		return nil, ErrInvalidToken
+	}
+	return &claims, nil
+}
+
+func signHS256(secret []byte, data string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// inMemoryKeyRepository is prototyping key repository.
+type inMemoryKeyRepository struct {
+	keys []SigningKey
+	mu   sync.RWMutex
+}
+
+// NewInMemoryKeyRepository creates thread-safe key repository for
+// prototyping.
+func NewInMemoryKeyRepository() KeyRepository {
+	return &inMemoryKeyRepository{}
+}
+
+func (r *inMemoryKeyRepository) ListSigningKeys(ctx context.Context) ([]SigningKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return slices.Clone(r.keys), nil
+}
+
+func (r *inMemoryKeyRepository) InitSigningKey(ctx context.Context, key SigningKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.keys) == 0 {
+		r.keys = append(r.keys, key)
+	}
+	return nil
+}
+
+func (r *inMemoryKeyRepository) RotateSigningKey(ctx context.Context, key SigningKey, grace time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := key.CreatedAt
+	kept := r.keys[:0]
+	for _, k := range r.keys {
+		if k.RetiredAt == nil {
+			k.RetiredAt = &now
+		}
+		if now.Sub(*k.RetiredAt) > grace {
+			continue
+		}
+		kept = append(kept, k)
+	}
+	r.keys = append(kept, key)
+	return nil
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/users"
+)
+
+// Claims are the access token claims understood by the API.
+type Claims struct {
+	Issuer    string     `json:"iss"`
+	Subject   string     `json:"sub"`
+	TenantID  string     `json:"tid"`
+	Role      users.Role `json:"role"`
+	IssuedAt  int64      `json:"iat"`
+	ExpiresAt int64      `json:"exp"`
+	ID        string     `json:"jti"`
+}
+
+// RefreshToken represents a stored refresh token. Tokens issued by rotation
+// share FamilyID with the token they replaced so that reuse of any consumed
+// token revokes the whole chain.
+type RefreshToken struct {
+	TokenID    string     `json:"token_id"`
+	FamilyID   string     `json:"family_id"`
+	TenantID   string     `json:"tenant_id"`
+	UserID     string     `json:"user_id"`
+	TokenHash  string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	UsedAt     *time.Time `json:"used_at"`
+	RevokedAt  *time.Time `json:"revoked_at"`
+	ReplacedBy string     `json:"replaced_by"`
+}
+
+// LoginAttempts tracks consecutive failed logins for an account.
+type LoginAttempts struct {
+	Email       string     `json:"email"`
+	Failures    int        `json:"failures"`
+	LastFailure time.Time  `json:"last_failure"`
+	LockedUntil *time.Time `json:"locked_until"`
+}
+
+// Session is the result of successful login or refresh.
+type Session struct {
+	AccessToken      string      `json:"access_token"`
+	TokenType        string      `json:"token_type"`
+	ExpiresAt        time.Time   `json:"expires_at"`
+	RefreshToken     string      `json:"-"`
+	RefreshExpiresAt time.Time   `json:"-"`
+	User             *users.User `json:"user"`
+}
+
+var (
+	// ErrInvalidCredentials is returned for unknown email or wrong password.
+	ErrInvalidCredentials = errors.New("auth: invalid credentials")
+	// ErrLocked is returned while account is locked after failed logins.
+	ErrLocked = errors.New("auth: account temporarily locked")
+	// ErrInvalidToken is returned for malformed, expired or revoked tokens.
+	ErrInvalidToken = errors.New("auth: invalid or expired token")
+	// ErrTokenReuse is returned when already rotated refresh token is presented.
+	ErrTokenReuse = errors.New("auth: refresh token reuse detected")
+	// ErrNotFound is returned when token or key does not exist.
+	ErrNotFound = errors.New("auth: resource not found")
+)
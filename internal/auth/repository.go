@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"context"
+	"time"
+)
+
+// Repository defines persistence layer for refresh tokens and login attempts.
+type Repository interface {
+	CreateRefreshToken(ctx context.Context, token RefreshToken) (*RefreshToken, error)
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	// ConsumeRefreshToken atomically marks token used and replaced by the
+	// given token ID. It returns ErrTokenReuse when token was already used.
+	ConsumeRefreshToken(ctx context.Context, tokenID, replacedBy string, at time.Time) error
+	RevokeFamily(ctx context.Context, familyID string, at time.Time) error
+
+	GetLoginAttempts(ctx context.Context, email string) (*LoginAttempts, error)
+	// RecordLoginFailure atomically counts a failed login of email at time
+	// at, restarting the count when the previous failure is older than
+	// window, and returns the updated attempts.
+	RecordLoginFailure(ctx context.Context, email string, at time.Time, window time.Duration) (*LoginAttempts, error)
+	// LockLogin locks email until time until and clears its failures.
+	LockLogin(ctx context.Context, email string, until time.Time) error
+	// ResetLoginAttempts clears failures and lock of email.
+	ResetLoginAttempts(ctx context.Context, email string) error
+}
+
+// KeyRepository defines persistence layer for access token signing keys.
+type KeyRepository interface {
+	// ListSigningKeys returns stored keys, oldest first.
+	ListSigningKeys(ctx context.Context) ([]SigningKey, error)
+	// InitSigningKey stores key unless keys are stored already.
+	InitSigningKey(ctx context.Context, key SigningKey) error
+	// RotateSigningKey atomically retires the active key as of the creation
+	// of key, drops keys retired longer than grace ago and stores key as the
+	// active one.
+	RotateSigningKey(ctx context.Context, key SigningKey, grace time.Duration) error
+}
@@ -0,0 +1,327 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/ids"
+	"github.com/lumiforge/docfactory-backend/internal/users"
+)
+
+// Options configure token lifetimes and brute-force protection.
+type Options struct {
+	AccessTTL       time.Duration
+	RefreshTTL      time.Duration
+	MaxFailures     int
+	FailureWindow   time.Duration
+	LockoutDuration time.Duration
+}
+
+// DefaultOptions returns production defaults.
+func DefaultOptions() Options {
+	return Options{
+		AccessTTL:       15 * time.Minute,
+		RefreshTTL:      30 * 24 * time.Hour,
+		MaxFailures:     5,
+		FailureWindow:   15 * time.Minute,
+		LockoutDuration: 15 * time.Minute,
+	}
+}
+
+// NewInMemoryRepository creates thread-safe repository for prototyping.
+func NewInMemoryRepository() Repository {
+	return &inMemoryRepository{
+		tokens:   make(map[string]RefreshToken),
+		attempts: make(map[string]LoginAttempts),
+	}
+}
+
+// AuthService issues and validates access and refresh tokens.
+type AuthService struct {
+	repo  Repository
+	users *users.UserService
+	keys  *KeyRing
+	opt   Options
+
+	dummyHash     string
+	dummyHashOnce sync.Once
+}
+
+// NewAuthService creates service instance.
+func NewAuthService(repo Repository, userService *users.UserService, keys *KeyRing, opt Options) *AuthService {
+	return &AuthService{repo: repo, users: userService, keys: keys, opt: opt}
+}
+
+// Keys exposes key ring for rotation.
+func (s *AuthService) Keys() *KeyRing {
+	return s.keys
+}
+
+// Login verifies credentials and starts a new refresh token family.
+func (s *AuthService) Login(ctx context.Context, email, password string) (*Session, error) {
+	email = users.NormalizeEmail(email)
+	now := time.Now().UTC()
+	attempts, err := s.repo.GetLoginAttempts(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	if attempts.LockedUntil != nil && now.Before(*attempts.LockedUntil) {
+		return nil, ErrLocked
+	}
+	user, err := s.users.UserByEmail(ctx, email)
+	if err != nil && !errors.Is(err, users.ErrNotFound) {
+		return nil, err
+	}
+	if user == nil || !user.IsActive {
+		// Spend comparable time on unknown accounts to avoid user enumeration.
+		_, _ = users.VerifyPassword(s.fakeHash(), password)
+		return nil, s.recordFailure(ctx, email, now)
+	}
+	ok, err := users.VerifyPassword(user.PasswordHash, password)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, s.recordFailure(ctx, email, now)
+	}
+	if err := s.repo.ResetLoginAttempts(ctx, email); err != nil {
+		return nil, err
+	}
+	user, err = s.users.RecordLogin(ctx, *user, now)
+	if err != nil {
+		return nil, err
+	}
+	return s.issue(ctx, user, ids.New(), ids.New(), now)
+}
+
+// Refresh rotates refresh token. Presenting an already rotated token is
+// treated as theft and revokes every token of its family.
+func (s *AuthService) Refresh(ctx context.Context, refreshToken string) (*Session, error) {
+	now := time.Now().UTC()
+	stored, err := s.repo.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	if stored.RevokedAt != nil || !now.Before(stored.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+	if stored.UsedAt != nil {
+		if err := s.repo.RevokeFamily(ctx, stored.FamilyID, now); err != nil {
+			return nil, err
+		}
+		return nil, ErrTokenReuse
+	}
+	user, err := s.users.ActiveUser(ctx, stored.TenantID, stored.UserID)
+	if err != nil {
+		if errors.Is(err, users.ErrNotFound) || errors.Is(err, users.ErrInactive) {
+			_ = s.repo.RevokeFamily(ctx, stored.FamilyID, now)
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	nextID := ids.New()
+	if err := s.repo.ConsumeRefreshToken(ctx, stored.TokenID, nextID, now); err != nil {
+		if errors.Is(err, ErrTokenReuse) {
+			_ = s.repo.RevokeFamily(ctx, stored.FamilyID, now)
+		}
+		return nil, err
+	}
+	return s.issue(ctx, user, stored.FamilyID, nextID, now)
+}
+
+// Logout revokes the refresh token family of the presented token.
+func (s *AuthService) Logout(ctx context.Context, refreshToken string) error {
+	stored, err := s.repo.GetRefreshTokenByHash(ctx, hashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	return s.repo.RevokeFamily(ctx, stored.FamilyID, time.Now().UTC())
+}
+
+// Authenticate validates access token and returns its claims.
+func (s *AuthService) Authenticate(ctx context.Context, accessToken string) (*Claims, error) {
+	return s.keys.Verify(ctx, accessToken, time.Now().UTC())
+}
+
+func (s *AuthService) issue(ctx context.Context, user *users.User, familyID, tokenID string, now time.Time) (*Session, error) {
+	expiresAt := now.Add(s.opt.AccessTTL)
+	access, err := s.keys.Sign(ctx, Claims{
+		Issuer:    issuer,
+		Subject:   user.UserID,
+		TenantID:  user.TenantID,
+		Role:      user.Role,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+		ID:        ids.New(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("generate refresh token: %w", err)
+	}
+	refresh := base64.RawURLEncoding.EncodeToString(secret)
+	stored := RefreshToken{
+		TokenID:   tokenID,
+		FamilyID:  familyID,
+		TenantID:  user.TenantID,
+		UserID:    user.UserID,
+		TokenHash: hashToken(refresh),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.opt.RefreshTTL),
+	}
+	if _, err := s.repo.CreateRefreshToken(ctx, stored); err != nil {
+		return nil, err
+	}
+	return &Session{
+		AccessToken:      access,
+		TokenType:        "Bearer",
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refresh,
+		RefreshExpiresAt: stored.ExpiresAt,
+		User:             user,
+	}, nil
+}
+
+// recordFailure counts failed login of email and locks it once failures
+// within the window reach the limit. The count is kept by the repository,
+// so that concurrent guesses are all counted.
+func (s *AuthService) recordFailure(ctx context.Context, email string, now time.Time) error {
+	attempts, err := s.repo.RecordLoginFailure(ctx, email, now, s.opt.FailureWindow)
+	if err != nil {
+		return err
+	}
+	if attempts.Failures >= s.opt.MaxFailures {
+		if err := s.repo.LockLogin(ctx, email, now.Add(s.opt.LockoutDuration)); err != nil {
+			return err
+		}
+	}
+	return ErrInvalidCredentials
+}
+
+func (s *AuthService) fakeHash() string {
+	s.dummyHashOnce.Do(func() {
+		s.dummyHash, _ = users.HashPassword(ids.New())
+	})
+	return s.dummyHash
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// inMemoryRepository is prototyping repository with maps.
+type inMemoryRepository struct {
+	tokens   map[string]RefreshToken
+	attempts map[string]LoginAttempts
+	mu       sync.RWMutex
+}
+
+func (r *inMemoryRepository) CreateRefreshToken(ctx context.Context, token RefreshToken) (*RefreshToken, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tokens[token.TokenID] = token
+	clone := token
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			clone := token
+			return &clone, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *inMemoryRepository) ConsumeRefreshToken(ctx context.Context, tokenID, replacedBy string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	token, ok := r.tokens[tokenID]
+	if !ok {
+		return ErrNotFound
+	}
+	if token.UsedAt != nil {
+		return ErrTokenReuse
+	}
+	token.UsedAt = &at
+	token.ReplacedBy = replacedBy
+	r.tokens[tokenID] = token
+	return nil
+}
+
+func (r *inMemoryRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, token := range r.tokens {
+		if token.FamilyID == familyID && token.RevokedAt == nil {
+			token.RevokedAt = &at
+			r.tokens[id] = token
+		}
+	}
+	return nil
+}
+
+func (r *inMemoryRepository) GetLoginAttempts(ctx context.Context, email string) (*LoginAttempts, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	attempts, ok := r.attempts[email]
+	if !ok {
+		attempts = LoginAttempts{Email: email}
+	}
+	return &attempts, nil
+}
+
+func (r *inMemoryRepository) RecordLoginFailure(ctx context.Context, email string, at time.Time, window time.Duration) (*LoginAttempts, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	attempts, ok := r.attempts[email]
+	if !ok {
+		attempts = LoginAttempts{Email: email}
+	}
+	if at.Sub(attempts.LastFailure) > window {
+		attempts.Failures = 0
+	}
+	attempts.Failures++
+	attempts.LastFailure = at
+	r.attempts[email] = attempts
+	return &attempts, nil
+}
+
+func (r *inMemoryRepository) LockLogin(ctx context.Context, email string, until time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	attempts, ok := r.attempts[email]
+	if !ok {
+		attempts = LoginAttempts{Email: email}
+	}
+	attempts.Failures = 0
+	attempts.LockedUntil = &until
+	r.attempts[email] = attempts
+	return nil
+}
+
+func (r *inMemoryRepository) ResetLoginAttempts(ctx context.Context, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.attempts, email)
+	return nil
+}
@@ -0,0 +1,117 @@
+// Package barcode encodes QR Code, Data Matrix, EAN-13 and Code 128 symbols
+// into module grids without external deps. Drawing the modules is left to
+// renderers.
+package barcode
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Symbology enumerates supported barcode types.
+type Symbology string
+
+const (
+	QR         Symbology = "qr"
+	DataMatrix Symbology = "datamatrix"
+	EAN13      Symbology = "ean13"
+	Code128    Symbology = "code128"
+)
+
+// Symbologies lists supported symbologies.
+func Symbologies() []Symbology {
+	return []Symbology{QR, DataMatrix, EAN13, Code128}
+}
+
+// Level is the QR Code error correction level, recovering roughly 7%, 15%,
+// 25% and 30% of damaged symbol respectively.
+type Level string
+
+const (
+	LevelL Level = "L"
+	LevelM Level = "M"
+	LevelQ Level = "Q"
+	LevelH Level = "H"
+)
+
+// Levels lists error correction levels from lowest to highest.
+func Levels() []Level {
+	return []Level{LevelL, LevelM, LevelQ, LevelH}
+}
+
+// DefaultLevel is used when no error correction level is given.
+const DefaultLevel = LevelM
+
+// ErrUnencodable is returned for content the symbology cannot represent.
+var ErrUnencodable = errors.New("barcode: content cannot be encoded")
+
+// Code is an encoded symbol. Linear symbologies have a single row of modules
+// that renderers stretch to the element height.
+type Code struct {
+	Symbology Symbology
+	// Modules holds rows of modules; true modules are dark.
+	Modules [][]bool
+	// QuietZone is the number of light modules required on each side.
+	QuietZone int
+}
+
+// Width returns number of modules per row.
+func (c *Code) Width() int {
+	return len(c.Modules[0])
+}
+
+// Height returns number of rows.
+func (c *Code) Height() int {
+	return len(c.Modules)
+}
+
+// Linear reports whether the code is a one-dimensional barcode.
+func (c *Code) Linear() bool {
+	return c.Symbology == EAN13 || c.Symbology == Code128
+}
+
+// Encode encodes content. Level applies to QR codes only; empty uses
+// DefaultLevel.
+func Encode(symbology Symbology, content string, level Level) (*Code, error) {
+	if content == "" {
+		return nil, fmt.Errorf("%w: content is empty", ErrUnencodable)
+	}
+	switch symbology {
+	case QR:
+		if level == "" {
+			level = DefaultLevel
+		}
+		return encodeQR(content, level)
+	case DataMatrix:
+		return encodeDataMatrix(content)
+	case EAN13:
+		return encodeEAN13(content)
+	case Code128:
+		return encodeCode128(content)
+	default:
+		return nil, fmt.Errorf("barcode: unknown symbology %q", symbology)
+	}
+}
+
+// Validate reports whether content can be encoded.
+func Validate(symbology Symbology, content string, level Level) error {
+	_, err := Encode(symbology, content, level)
+	return err
+}
+
+func newGrid(width, height int) [][]bool {
+	grid := make([][]bool, height)
+	for y := range grid {
+		grid[y] = make([]bool, width)
+	}
+	return grid
+}
+
+// linear turns a pattern of '1' and '0' modules into a single-row code.
+func linear(symbology Symbology, pattern string, quiet int) *Code {
+	row := make([]bool, len(pattern))
+	for i := range pattern {
+		row[i] = pattern[i] == '1'
+	}
+	return &Code{Symbology: symbology, Modules: [][]bool{row}, QuietZone: quiet}
+}
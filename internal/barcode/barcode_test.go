@@ -0,0 +1,242 @@
+package barcode
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// grid draws modules of code as rows of '#' for dark and '.' for light
+// modules.
+func grid(code *Code) string {
+	rows := make([]string, len(code.Modules))
+	for y, row := range code.Modules {
+		var b strings.Builder
+		for _, dark := range row {
+			if dark {
+				b.WriteByte('#')
+			} else {
+				b.WriteByte('.')
+			}
+		}
+		rows[y] = b.String()
+	}
+	return strings.Join(rows, "\n")
+}
+
+// Golden grids were cross-checked module for module against
+// github.com/boombuler/barcode.
+func TestEncodeGolden(t *testing.T) {
+	for _, tc := range []struct {
+		symbology Symbology
+		content   string
+		level     Level
+		quietZone int
+		golden    string
+	}{
+		{QR, "HELLO WORLD", LevelL, 4, `
+#######...#.#.#######
+#.....#.#.#.#.#.....#
+#.###.#.#.##..#.###.#
+#.###.#.....#.#.###.#
+#.###.#.#####.#.###.#
+#.....#.###...#.....#
+#######.#.#.#.#######
+........#............
+##.#..##..###.###.##.
+###.##..#.##....#...#
+#.#...#..#.#.##..#.#.
+#.####.###..####..###
+...#####.###..###.#.#
+........#....##.#.###
+#######.#..##.##..#.#
+#.....#...#...##.#...
+#.###.#..##.####.##.#
+#.###.#.#.#..###.#.##
+#.###.#...##.###.#..#
+#.....#.#.###...##..#
+#######.#.#..#.#.#...`},
+		{QR, "HELLO WORLD", LevelM, 4, `
+#######...#.#.#######
+#.....#.###...#.....#
+#.###.#...#.#.#.###.#
+#.###.#...#.#.#.###.#
+#.###.#.#.###.#.###.#
+#.....#..###..#.....#
+#######.#.#.#.#######
+.....................
+#.#.#.#..#..#...#..#.
+.####...#..#....#...#
+...#######.#..#.##...
+####.#.##..###.#.###.
+.#..####.#.#..###.#.#
+........#.#...#...#.#
+#######.....#..#.##..
+#.....#..##...##.#...
+#.###.#.##..#.#######
+#.###.#...##.#.#...#.
+#.###.#.####.###.#..#
+#.....#....###...#.##
+#######.##.#.###....#`},
+		{QR, "HELLO WORLD", LevelQ, 4, `
+#######....#..#######
+#.....#.##..#.#.....#
+#.###.#..#.##.#.###.#
+#.###.#.#####.#.###.#
+#.###.#.##.#..#.###.#
+#.....#..#..#.#.....#
+#######.#.#.#.#######
+........##.##........
+.#.####.##..###.##.#.
+#.####.#....####.###.
+..#.#.##...#..##.....
+#.##.#...#.##...##...
+##.########.###.#####
+........#...#..#.#...
+#######..##..##..####
+#.....#.#.#..#..#.###
+#.###.#.##.#..#...###
+#.###.#.#.###...#.#..
+#.###.#..#....#....##
+#.....#.###..###..##.
+#######..#.#.......#.`},
+		{QR, "HELLO WORLD", LevelH, 4, `
+#######.###.##.##.#######
+#.....#...#.##.##.#.....#
+#.###.#.#.#.....#.#.###.#
+#.###.#..##..####.#.###.#
+#.###.#.##..#.##..#.###.#
+#.....#..#.....#..#.....#
+#######.#.#.#.#.#.#######
+........#.#.##..#........
+.....##...####.#..#.#.#.#
+##.##..####...#.#.##.#..#
+....#.#....#...#..#.#....
+#.###..#.#.#...#.####..#.
+#.#.###..###.....####.#.#
+###.##..#....#...##..#.#.
+#...#.##....#.####....#..
+#..##....#.##.#.#..##.#.#
+#.#.#.#..#...#.########.#
+........##..#.#.#...####.
+#######...##.####.#.#.##.
+#.....#.#..#.####...#####
+#.###.#......##.######.##
+#.###.#...###....#.#.##.#
+#.###.#..#.#..#.#.#..#..#
+#.....#.....####..#..##..
+#######...###..##.#.#.###`},
+		{QR, "https://docfactory.example/verify/0a78e5e5", LevelQ, 4, `
+#######.#....#...#####.#..#######
+#.....#...#..###.#....#...#.....#
+#.###.#...#....####..##.#.#.###.#
+#.###.#....#..#.#..##.....#.###.#
+#.###.#.##.###.#..#..####.#.###.#
+#.....#.##..#..#..#.#.....#.....#
+#######.#.#.#.#.#.#.#.#.#.#######
+..........####..#....##.#........
+.#######.##.#.####..#.#.#..##...#
+.#.###.#..#.###...###.##..##.##.#
+....###.##..##.##.#.##..#.#.#.##.
+..####.#....#..##...##.#.#..###..
+.#..#.###..#.####.#...#.##.###.##
+..##...#.#.#..#.##..##.#..#..####
+....#.#..####.###..#.##.########.
+######.#######.##.####..####..#..
+####..##.#....##.#.#..#.##.##...#
+####.#..##..#.#...####.#..##.##.#
+..#.####..######..#..##..#.##.##.
+..#..#..####..###.#######.#####..
+####.####.##...#.###.....#..##...
+####.#.########.##....#####..#..#
+#..#.####..#.##.....#.#..#...#.#.
+#..##...#.#.#..##...####.....##.#
+#.#####.###.###..##...########..#
+........##...#.#.#.#.##.#...#.###
+#######.##..#.##....#####.#.#.##.
+#.....#.#.###...##...####...####.
+#.###.#.#.#.#.##..###.#.######.##
+#.###.#.#.#.####...#.#.#.#..###.#
+#.###.#.#..##.##.#..#..#..##.....
+#.....#.###..####.#.##....#.###..
+#######.....#.#.....#.#.##.#...#.`},
+		{DataMatrix, "123456", "", 1, `
+#.#.#.#.#.
+##..#.##.#
+##.....#..
+##...###.#
+##....#...
+#.....####
+###.##....
+####.##..#
+#..###.#..
+##########`},
+		{DataMatrix, "DocFactory", "", 1, `
+#.#.#.#.#.#.#.#.
+#.##.....#.#...#
+###..#########..
+#...#..#.####..#
+#..#.#..#....#..
+###..#..###.#.##
+###.........#...
+##..#..#..##.#.#
+#.####..##.#....
+#.#.#....#.#####
+###..#.##..####.
+#.###.###....#.#
+#.....#.#.......
+##....##.##.##.#
+##.#.#...####.#.
+################`},
+		{EAN13, "4006381333931", "", 11, `#.#...##.#.#..###.#.####.####.#...#..#.##..##.#.#.#....#.#....#.#....#.###.#..#....#.##..##.#.#`},
+		{EAN13, "590123412345", "", 11, `#.#...#.##.#..###.##..##..#..##.####.#..###.#.#.#.##..##.##.##..#....#.#.###..#..###.#...#..#.#`},
+		{Code128, "DOC-42", "", 10, `##.#..#....#.##...#...#...###.##.#...#...##.#..##.###..##..#..###.##..###..#.#..####.#..##...###.#.##`},
+		{Code128, "1234567890", "", 10, `##.#..###..#.##..###..#...#.##...###...#.##.##....#.#..##.####.##.#..####..#.##...###.#.##`},
+	} {
+		code, err := Encode(tc.symbology, tc.content, tc.level)
+		if err != nil {
+			t.Errorf("%s %s %q: %v", tc.symbology, tc.level, tc.content, err)
+			continue
+		}
+		if got, want := grid(code), strings.TrimPrefix(tc.golden, "\n"); got != want {
+			t.Errorf("%s %s %q:\n%s\nwant\n%s", tc.symbology, tc.level, tc.content, got, want)
+		}
+		if code.QuietZone != tc.quietZone {
+			t.Errorf("%s %q: quiet zone %d, want %d", tc.symbology, tc.content, code.QuietZone, tc.quietZone)
+		}
+	}
+}
+
+func TestReedSolomon(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		field *field
+		data  []byte
+		want  []byte
+	}{
+		// "HELLO WORLD" as version 1-M QR Code.
+		{"qr", qrField, []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}, []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}},
+		// "123456" as 10x10 Data Matrix, ISO/IEC 16022 annex example.
+		{"datamatrix", dmField, []byte{142, 164, 186}, []byte{114, 25, 5, 88, 102}},
+	} {
+		if got := tc.field.ecc(tc.data, len(tc.want)); !slices.Equal(got, tc.want) {
+			t.Errorf("%s: ecc %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestEANCheckDigit(t *testing.T) {
+	if got := EANCheckDigit("400638133393"); got != 1 {
+		t.Errorf("check digit %d, want 1", got)
+	}
+	for _, content := range []string{"4006381333932", "40063813339", "40063813339a"} {
+		if _, err := Encode(EAN13, content, ""); !errors.Is(err, ErrUnencodable) {
+			t.Errorf("%q: error %v, want %v", content, err, ErrUnencodable)
+		}
+	}
+	_, err := Encode(EAN13, "4006381333932", "")
+	if err == nil || !strings.Contains(err.Error(), "check digit must be 1") {
+		t.Errorf("wrong check digit: error %v", err)
+	}
+}
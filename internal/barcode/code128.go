@@ -0,0 +1,119 @@
+package barcode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// code128Widths holds bar and space widths of symbol values 0-106; 106 is the
+// stop pattern including its final bar.
+var code128Widths = [107]string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+// Code 128 code sets and their start and switch symbols.
+const (
+	code128A = iota
+	code128B
+	code128C
+)
+
+var (
+	code128Start  = [3]int{103, 104, 105}
+	code128Switch = [3]int{101, 100, 99}
+)
+
+const (
+	code128Stop = 106
+	// code128MaxLength keeps symbols short enough to scan.
+	code128MaxLength = 80
+)
+
+// encodeCode128 encodes ASCII content, using code set C for runs of digits
+// and A or B for other characters.
+func encodeCode128(content string) (*Code, error) {
+	if len(content) > code128MaxLength {
+		return nil, fmt.Errorf("%w: Code 128 content exceeds %d characters", ErrUnencodable, code128MaxLength)
+	}
+	for _, r := range content {
+		if r > 127 {
+			return nil, fmt.Errorf("%w: Code 128 supports ASCII characters only", ErrUnencodable)
+		}
+	}
+	var values []int
+	set := -1
+	use := func(next int) {
+		switch {
+		case set < 0:
+			values = append(values, code128Start[next])
+		case set != next:
+			values = append(values, code128Switch[next])
+		}
+		set = next
+	}
+	for i := 0; i < len(content); {
+		run := digitRun(content[i:])
+		if run >= 4 || run >= 2 && run == len(content) {
+			use(code128C)
+			for ; run >= 2; run -= 2 {
+				values = append(values, int(content[i]-'0')*10+int(content[i+1]-'0'))
+				i += 2
+			}
+			continue
+		}
+		c := content[i]
+		switch {
+		case c < 32:
+			if set != code128A {
+				use(code128A)
+			}
+			values = append(values, int(c)+64)
+		case c >= 96:
+			if set != code128B {
+				use(code128B)
+			}
+			values = append(values, int(c)-32)
+		default:
+			if set != code128A && set != code128B {
+				use(code128B)
+			}
+			values = append(values, int(c)-32)
+		}
+		i++
+	}
+	checksum := values[0]
+	for i, v := range values[1:] {
+		checksum += (i + 1) * v
+	}
+	values = append(values, checksum%103, code128Stop)
+
+	var b strings.Builder
+	for _, v := range values {
+		for i, w := range code128Widths[v] {
+			module := "1"
+			if i%2 == 1 {
+				module = "0"
+			}
+			b.WriteString(strings.Repeat(module, int(w-'0')))
+		}
+	}
+	return linear(Code128, b.String(), 10), nil
+}
+
+func digitRun(s string) int {
+	n := 0
+	for n < len(s) && s[n] >= '0' && s[n] <= '9' {
+		n++
+	}
+	return n
+}
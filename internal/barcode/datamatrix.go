@@ -0,0 +1,180 @@
+package barcode
+
+import "fmt"
+
+// dmSize is a square ECC 200 symbol size. Symbols larger than 26 modules are
+// split into regions, each framed by its own finder and clock pattern.
+type dmSize struct {
+	size, regions, data, ecc, blocks int
+}
+
+// dmSizes lists supported sizes, smallest first. The 144x144 symbol uses an
+// irregular block layout and is not supported.
+var dmSizes = []dmSize{
+	{10, 1, 3, 5, 1}, {12, 1, 5, 7, 1}, {14, 1, 8, 10, 1}, {16, 1, 12, 12, 1},
+	{18, 1, 18, 14, 1}, {20, 1, 22, 18, 1}, {22, 1, 30, 20, 1}, {24, 1, 36, 24, 1},
+	{26, 1, 44, 28, 1}, {32, 2, 62, 36, 1}, {36, 2, 86, 42, 1}, {40, 2, 114, 48, 1},
+	{44, 2, 144, 56, 1}, {48, 2, 174, 68, 1}, {52, 2, 204, 84, 2}, {64, 4, 280, 112, 2},
+	{72, 4, 368, 144, 4}, {80, 4, 456, 192, 4}, {88, 4, 576, 224, 4}, {96, 4, 696, 272, 4},
+	{104, 4, 816, 336, 6}, {120, 6, 1050, 408, 6}, {132, 6, 1304, 496, 8},
+}
+
+// encodeDataMatrix encodes content with ASCII encodation: digit pairs take
+// one codeword and bytes above 127 take two.
+func encodeDataMatrix(content string) (*Code, error) {
+	var data []byte
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case isDigit(c) && i+1 < len(content) && isDigit(content[i+1]):
+			data = append(data, 130+(c-'0')*10+content[i+1]-'0')
+			i++
+		case c < 128:
+			data = append(data, c+1)
+		default:
+			data = append(data, 235, c-127)
+		}
+	}
+	var sz dmSize
+	for _, s := range dmSizes {
+		if len(data) <= s.data {
+			sz = s
+			break
+		}
+	}
+	if sz.size == 0 {
+		return nil, fmt.Errorf("%w: content is too long for a Data Matrix", ErrUnencodable)
+	}
+	if len(data) < sz.data {
+		data = append(data, 129)
+	}
+	for len(data) < sz.data {
+		v := 129 + (149*(len(data)+1))%253 + 1
+		if v > 254 {
+			v -= 254
+		}
+		data = append(data, byte(v))
+	}
+
+	codewords := make([]byte, sz.data+sz.ecc)
+	copy(codewords, data)
+	for b := 0; b < sz.blocks; b++ {
+		var block []byte
+		for i := b; i < sz.data; i += sz.blocks {
+			block = append(block, data[i])
+		}
+		for i, cw := range dmField.ecc(block, sz.ecc/sz.blocks) {
+			codewords[sz.data+i*sz.blocks+b] = cw
+		}
+	}
+
+	region := sz.size/sz.regions - 2
+	mapped := dmPlacement(region*sz.regions, codewords)
+	modules := newGrid(sz.size, sz.size)
+	for ry := 0; ry < sz.regions; ry++ {
+		for rx := 0; rx < sz.regions; rx++ {
+			top, left := ry*(region+2), rx*(region+2)
+			for i := 0; i < region+2; i++ {
+				modules[top+region+1][left+i] = true
+				modules[top+i][left] = true
+				modules[top][left+i] = i%2 == 0
+				modules[top+i][left+region+1] = i%2 == 1
+			}
+			for y := 0; y < region; y++ {
+				for x := 0; x < region; x++ {
+					modules[top+1+y][left+1+x] = mapped[ry*region+y][rx*region+x]
+				}
+			}
+		}
+	}
+	return &Code{Symbology: DataMatrix, Modules: modules, QuietZone: 1}, nil
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// dmPlacement arranges codewords in the n x n mapping matrix following the
+// ECC 200 placement algorithm: 8-module "utah" shapes along diagonals with
+// special shapes at the corners.
+func dmPlacement(n int, codewords []byte) [][]bool {
+	grid := newGrid(n, n)
+	placed := newGrid(n, n)
+	module := func(row, col int, cw byte, bit int) {
+		if row < 0 {
+			row += n
+			col += 4 - (n+4)%8
+		}
+		if col < 0 {
+			col += n
+			row += 4 - (n+4)%8
+		}
+		grid[row][col] = cw>>(8-bit)&1 == 1
+		placed[row][col] = true
+	}
+	utah := func(row, col int, cw byte) {
+		module(row-2, col-2, cw, 1)
+		module(row-2, col-1, cw, 2)
+		module(row-1, col-2, cw, 3)
+		module(row-1, col-1, cw, 4)
+		module(row-1, col, cw, 5)
+		module(row, col-2, cw, 6)
+		module(row, col-1, cw, 7)
+		module(row, col, cw, 8)
+	}
+	corner := func(cw byte, positions [8][2]int) {
+		for i, p := range positions {
+			module(p[0], p[1], cw, i+1)
+		}
+	}
+	next := 0
+	take := func() byte {
+		cw := byte(0)
+		if next < len(codewords) {
+			cw = codewords[next]
+		}
+		next++
+		return cw
+	}
+	row, col := 4, 0
+	for {
+		switch {
+		case row == n && col == 0:
+			corner(take(), [8][2]int{{n - 1, 0}, {n - 1, 1}, {n - 1, 2}, {0, n - 2}, {0, n - 1}, {1, n - 1}, {2, n - 1}, {3, n - 1}})
+		case row == n-2 && col == 0 && n%4 != 0:
+			corner(take(), [8][2]int{{n - 3, 0}, {n - 2, 0}, {n - 1, 0}, {0, n - 4}, {0, n - 3}, {0, n - 2}, {0, n - 1}, {1, n - 1}})
+		case row == n-2 && col == 0 && n%8 == 4:
+			corner(take(), [8][2]int{{n - 3, 0}, {n - 2, 0}, {n - 1, 0}, {0, n - 2}, {0, n - 1}, {1, n - 1}, {2, n - 1}, {3, n - 1}})
+		case row == n+4 && col == 2 && n%8 == 0:
+			corner(take(), [8][2]int{{n - 1, 0}, {n - 1, n - 1}, {0, n - 3}, {0, n - 2}, {0, n - 1}, {1, n - 3}, {1, n - 2}, {1, n - 1}})
+		}
+		for {
+			if row < n && col >= 0 && !placed[row][col] {
+				utah(row, col, take())
+			}
+			row, col = row-2, col+2
+			if row < 0 || col >= n {
+				break
+			}
+		}
+		row, col = row+1, col+3
+		for {
+			if row >= 0 && col < n && !placed[row][col] {
+				utah(row, col, take())
+			}
+			row, col = row+2, col-2
+			if row >= n || col < 0 {
+				break
+			}
+		}
+		row, col = row+3, col+1
+		if row >= n && col >= n {
+			break
+		}
+	}
+	if !placed[n-1][n-1] {
+		grid[n-1][n-1] = true
+		grid[n-2][n-2] = true
+	}
+	return grid
+}
@@ -0,0 +1,57 @@
+package barcode
+
+import (
+	"fmt"
+	"strings"
+)
+
+var (
+	eanL = [10]string{"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011"}
+	eanG = [10]string{"0100111", "0110011", "0011011", "0100001", "0011101", "0111001", "0000101", "0010001", "0001001", "0010111"}
+	eanR = [10]string{"1110010", "1100110", "1101100", "1000010", "1011100", "1001110", "1010000", "1000100", "1001000", "1110100"}
+	// eanParity selects L or G codes of the left half by the first digit.
+	eanParity = [10]string{"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL"}
+)
+
+// EANCheckDigit returns check digit of the first 12 digits of an EAN-13.
+func EANCheckDigit(digits string) int {
+	sum := 0
+	for i := 0; i < 12; i++ {
+		d := int(digits[i] - '0')
+		if i%2 == 1 {
+			d *= 3
+		}
+		sum += d
+	}
+	return (10 - sum%10) % 10
+}
+
+// encodeEAN13 accepts 12 digits, to which the check digit is appended, or 13
+// digits with a valid check digit.
+func encodeEAN13(content string) (*Code, error) {
+	if len(content) != 12 && len(content) != 13 || strings.Trim(content, "0123456789") != "" {
+		return nil, fmt.Errorf("%w: EAN-13 needs 12 or 13 digits", ErrUnencodable)
+	}
+	check := EANCheckDigit(content)
+	if len(content) == 13 && int(content[12]-'0') != check {
+		return nil, fmt.Errorf("%w: EAN-13 check digit must be %d", ErrUnencodable, check)
+	}
+	digits := content[:12] + fmt.Sprint(check)
+	var b strings.Builder
+	b.WriteString("101")
+	parity := eanParity[digits[0]-'0']
+	for i := 1; i <= 6; i++ {
+		d := digits[i] - '0'
+		if parity[i-1] == 'L' {
+			b.WriteString(eanL[d])
+		} else {
+			b.WriteString(eanG[d])
+		}
+	}
+	b.WriteString("01010")
+	for i := 7; i <= 12; i++ {
+		b.WriteString(eanR[digits[i]-'0'])
+	}
+	b.WriteString("101")
+	return linear(EAN13, b.String(), 11), nil
+}
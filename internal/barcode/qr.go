@@ -0,0 +1,424 @@
+package barcode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// qrECCPerBlock and qrBlocks hold error correction codewords per block and
+// number of blocks for versions 1-40, indexed by level then version.
+var (
+	qrECCPerBlock = [4][41]int{
+		{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+		{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+		{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+		{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	}
+	qrBlocks = [4][41]int{
+		{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+		{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+		{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+		{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+	}
+)
+
+// qrLevels maps levels to table index and the two bits of format information.
+var qrLevels = map[Level]struct{ index, format int }{
+	LevelL: {0, 1},
+	LevelM: {1, 0},
+	LevelQ: {2, 3},
+	LevelH: {3, 2},
+}
+
+const qrAlphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// qrMode is a segment encoding with its indicator and character count widths
+// for versions 1-9, 10-26 and 27-40.
+type qrMode struct {
+	indicator  int
+	countWidth [3]int
+}
+
+var (
+	qrNumeric = qrMode{0x1, [3]int{10, 12, 14}}
+	qrAlnum   = qrMode{0x2, [3]int{9, 11, 13}}
+	qrByte    = qrMode{0x4, [3]int{8, 16, 16}}
+)
+
+func (m qrMode) count(version int) int {
+	switch {
+	case version <= 9:
+		return m.countWidth[0]
+	case version <= 26:
+		return m.countWidth[1]
+	default:
+		return m.countWidth[2]
+	}
+}
+
+// bits is a big-endian bit buffer.
+type bits []bool
+
+func (b *bits) append(value, n int) {
+	for i := n - 1; i >= 0; i-- {
+		*b = append(*b, value>>i&1 == 1)
+	}
+}
+
+// encodeQR encodes content as a single numeric, alphanumeric or byte segment
+// in the smallest version that fits.
+func encodeQR(content string, level Level) (*Code, error) {
+	lv, ok := qrLevels[level]
+	if !ok {
+		return nil, fmt.Errorf("barcode: unknown error correction level %q", level)
+	}
+	mode := qrByte
+	switch {
+	case strings.Trim(content, "0123456789") == "":
+		mode = qrNumeric
+	case strings.Trim(content, qrAlphanumeric) == "":
+		mode = qrAlnum
+	}
+	var payload bits
+	switch mode {
+	case qrNumeric:
+		for i := 0; i < len(content); i += 3 {
+			group := content[i:min(i+3, len(content))]
+			n := 0
+			for _, c := range group {
+				n = n*10 + int(c-'0')
+			}
+			payload.append(n, len(group)*3+1)
+		}
+	case qrAlnum:
+		for i := 0; i < len(content); i += 2 {
+			if i+1 < len(content) {
+				payload.append(strings.IndexByte(qrAlphanumeric, content[i])*45+strings.IndexByte(qrAlphanumeric, content[i+1]), 11)
+			} else {
+				payload.append(strings.IndexByte(qrAlphanumeric, content[i]), 6)
+			}
+		}
+	default:
+		for i := 0; i < len(content); i++ {
+			payload.append(int(content[i]), 8)
+		}
+	}
+
+	for version := 1; version <= 40; version++ {
+		capacity := qrDataCodewords(version, lv.index) * 8
+		if 4+mode.count(version)+len(payload) > capacity || len(content) >= 1<<mode.count(version) {
+			continue
+		}
+		var data bits
+		data.append(mode.indicator, 4)
+		data.append(len(content), mode.count(version))
+		data = append(data, payload...)
+		data.append(0, min(4, capacity-len(data)))
+		data.append(0, (8-len(data)%8)%8)
+		for pad := 0xec; len(data) < capacity; pad ^= 0xec ^ 0x11 {
+			data.append(pad, 8)
+		}
+		codewords := make([]byte, len(data)/8)
+		for i, bit := range data {
+			if bit {
+				codewords[i/8] |= 1 << (7 - i%8)
+			}
+		}
+		q := newQRSymbol(version)
+		q.place(qrInterleave(codewords, version, lv.index))
+		q.applyBestMask(lv.format)
+		return &Code{Symbology: QR, Modules: q.modules, QuietZone: 4}, nil
+	}
+	return nil, fmt.Errorf("%w: content is too long for a QR code at level %s", ErrUnencodable, level)
+}
+
+// qrRawModules returns number of modules available for codewords.
+func qrRawModules(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		align := version/7 + 2
+		n -= (25*align-10)*align - 55
+		if version >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+func qrDataCodewords(version, level int) int {
+	return qrRawModules(version)/8 - qrECCPerBlock[level][version]*qrBlocks[level][version]
+}
+
+// qrInterleave splits data into blocks, appends error correction to each and
+// interleaves the blocks codeword by codeword.
+func qrInterleave(data []byte, version, level int) []byte {
+	numBlocks := qrBlocks[level][version]
+	eccLen := qrECCPerBlock[level][version]
+	raw := qrRawModules(version) / 8
+	numShort := numBlocks - raw%numBlocks
+	shortLen := raw / numBlocks
+	blocks := make([][]byte, numBlocks)
+	for i, k := 0, 0; i < numBlocks; i++ {
+		n := shortLen - eccLen
+		if i >= numShort {
+			n++
+		}
+		block := append([]byte{}, data[k:k+n]...)
+		k += n
+		ecc := qrField.ecc(block, eccLen)
+		// Short blocks get a placeholder so all blocks align when interleaved.
+		if i < numShort {
+			block = append(block, 0)
+		}
+		blocks[i] = append(block, ecc...)
+	}
+	result := make([]byte, 0, raw)
+	for i := 0; i <= shortLen; i++ {
+		for j, block := range blocks {
+			if i != shortLen-eccLen || j >= numShort {
+				result = append(result, block[i])
+			}
+		}
+	}
+	return result
+}
+
+// qrSymbol is a QR Code matrix under construction.
+type qrSymbol struct {
+	size     int
+	version  int
+	modules  [][]bool
+	function [][]bool
+}
+
+func newQRSymbol(version int) *qrSymbol {
+	size := version*4 + 17
+	q := &qrSymbol{size: size, version: version, modules: newGrid(size, size), function: newGrid(size, size)}
+	for i := 0; i < size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+	q.finder(3, 3)
+	q.finder(size-4, 3)
+	q.finder(3, size-4)
+	positions := q.alignmentPositions()
+	last := len(positions) - 1
+	for i, x := range positions {
+		for j, y := range positions {
+			if i == 0 && j == 0 || i == 0 && j == last || i == last && j == 0 {
+				continue
+			}
+			q.alignment(x, y)
+		}
+	}
+	q.format(0, 0)
+	q.versionInfo()
+	return q
+}
+
+func (q *qrSymbol) set(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.function[y][x] = true
+}
+
+func (q *qrSymbol) finder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || y < 0 || x >= q.size || y >= q.size {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			q.set(x, y, d != 2 && d != 4)
+		}
+	}
+}
+
+func (q *qrSymbol) alignment(cx, cy int) {
+	for dy := -2; dy <= 2; dy++ {
+		for dx := -2; dx <= 2; dx++ {
+			q.set(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+func (q *qrSymbol) alignmentPositions() []int {
+	if q.version == 1 {
+		return nil
+	}
+	n := q.version/7 + 2
+	step := 26
+	if q.version != 32 {
+		step = (q.version*4 + n*2 + 1) / (n*2 - 2) * 2
+	}
+	positions := make([]int, n)
+	positions[0] = 6
+	for i, pos := n-1, q.size-7; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+// format draws both copies of the 15-bit format information.
+func (q *qrSymbol) format(levelBits, mask int) {
+	data := levelBits<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	b := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return b>>i&1 == 1 }
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		q.set(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, q.size-15+i, bit(i))
+	}
+	q.set(8, q.size-8, true)
+}
+
+// versionInfo draws version information of versions 7 and above.
+func (q *qrSymbol) versionInfo() {
+	if q.version < 7 {
+		return
+	}
+	rem := q.version
+	for i := 0; i < 12; i++ {
+		rem = rem<<1 ^ (rem>>11)*0x1f25
+	}
+	b := q.version<<12 | rem
+	for i := 0; i < 18; i++ {
+		dark := b>>i&1 == 1
+		a, c := q.size-11+i%3, i/3
+		q.set(a, c, dark)
+		q.set(c, a, dark)
+	}
+}
+
+// place fills non-function modules with codewords in the zigzag order.
+func (q *qrSymbol) place(codewords []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.size - 1 - vert
+				}
+				if !q.function[y][x] && i < len(codewords)*8 {
+					q.modules[y][x] = codewords[i>>3]>>(7-i&7)&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+var qrMasks = [8]func(x, y int) bool{
+	func(x, y int) bool { return (x+y)%2 == 0 },
+	func(x, y int) bool { return y%2 == 0 },
+	func(x, y int) bool { return x%3 == 0 },
+	func(x, y int) bool { return (x+y)%3 == 0 },
+	func(x, y int) bool { return (x/3+y/2)%2 == 0 },
+	func(x, y int) bool { return x*y%2+x*y%3 == 0 },
+	func(x, y int) bool { return (x*y%2+x*y%3)%2 == 0 },
+	func(x, y int) bool { return ((x+y)%2+x*y%3)%2 == 0 },
+}
+
+func (q *qrSymbol) mask(m int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if !q.function[y][x] && qrMasks[m](x, y) {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// applyBestMask applies the mask with the lowest penalty score.
+func (q *qrSymbol) applyBestMask(levelBits int) {
+	best, bestPenalty := 0, -1
+	for m := range qrMasks {
+		q.mask(m)
+		q.format(levelBits, m)
+		if p := q.penalty(); bestPenalty < 0 || p < bestPenalty {
+			best, bestPenalty = m, p
+		}
+		q.mask(m)
+	}
+	q.mask(best)
+	q.format(levelBits, best)
+}
+
+// penalty scores runs, 2x2 blocks, finder-like patterns and dark balance.
+func (q *qrSymbol) penalty() int {
+	n := q.size
+	at := func(x, y int, transposed bool) bool {
+		if transposed {
+			return q.modules[x][y]
+		}
+		return q.modules[y][x]
+	}
+	score := 0
+	for _, transposed := range []bool{false, true} {
+		for y := 0; y < n; y++ {
+			var line strings.Builder
+			run := 0
+			for x := 0; x < n; x++ {
+				dark := at(x, y, transposed)
+				if x > 0 && dark == at(x-1, y, transposed) {
+					run++
+				} else {
+					run = 1
+				}
+				if run == 5 {
+					score += 3
+				} else if run > 5 {
+					score++
+				}
+				if dark {
+					line.WriteByte('1')
+				} else {
+					line.WriteByte('0')
+				}
+			}
+			s := line.String()
+			score += 40 * (strings.Count(s, "10111010000") + strings.Count(s, "00001011101"))
+		}
+	}
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			c := q.modules[y][x]
+			if c {
+				dark++
+			}
+			if x+1 < n && y+1 < n && c == q.modules[y][x+1] && c == q.modules[y+1][x] && c == q.modules[y+1][x+1] {
+				score += 3
+			}
+		}
+	}
+	total := n * n
+	k := (abs(dark*20-total*10)+total-1)/total - 1
+	return score + k*10
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
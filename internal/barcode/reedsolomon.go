@@ -0,0 +1,71 @@
+package barcode
+
+// field is GF(256) defined by a primitive polynomial. QR Code and Data
+// Matrix use different polynomials and generator roots.
+type field struct {
+	exp [512]byte
+	log [256]int
+	// base is the exponent of the first generator root.
+	base int
+}
+
+var (
+	qrField = newField(0x11d, 0)
+	dmField = newField(0x12d, 1)
+)
+
+func newField(poly, base int) *field {
+	f := &field{base: base}
+	x := 1
+	for i := 0; i < 255; i++ {
+		f.exp[i] = byte(x)
+		f.log[x] = i
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= poly
+		}
+	}
+	for i := 255; i < 512; i++ {
+		f.exp[i] = f.exp[i-255]
+	}
+	return f
+}
+
+func (f *field) mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return f.exp[f.log[a]+f.log[b]]
+}
+
+// generator returns coefficients of the generator polynomial of degree n,
+// highest order first with the leading 1 omitted.
+func (f *field) generator(n int) []byte {
+	g := make([]byte, n)
+	g[n-1] = 1
+	for i := 0; i < n; i++ {
+		root := f.exp[(f.base+i)%255]
+		for j := 0; j < n; j++ {
+			g[j] = f.mul(g[j], root)
+			if j+1 < n {
+				g[j] ^= g[j+1]
+			}
+		}
+	}
+	return g
+}
+
+// ecc returns n error correction codewords of data.
+func (f *field) ecc(data []byte, n int) []byte {
+	g := f.generator(n)
+	rem := make([]byte, n)
+	for _, b := range data {
+		factor := b ^ rem[0]
+		copy(rem, rem[1:])
+		rem[n-1] = 0
+		for i := range rem {
+			rem[i] ^= f.mul(g[i], factor)
+		}
+	}
+	return rem
+}
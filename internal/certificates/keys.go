@@ -0,0 +1,177 @@
+package certificates
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lumiforge/docfactory-backend/internal/validation"
+)
+
+// minRSABits is the smallest RSA key accepted for signing.
+const minRSABits = 2048
+
+// parseChain decodes PEM certificates, the signing certificate first.
+func parseChain(data string, errs *validation.Errors) []*x509.Certificate {
+	var chain []*x509.Certificate
+	rest := []byte(data)
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			errs.Add("certificate", validation.CodeInvalidValue, fmt.Sprintf("must contain only certificates, found %s", block.Type))
+			return nil
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			errs.Add("certificate", validation.CodeInvalidValue, fmt.Sprintf("is not a valid X.509 certificate: %v", err))
+			return nil
+		}
+		chain = append(chain, cert)
+	}
+	if len(chain) == 0 {
+		errs.Add("certificate", validation.CodeInvalidValue, "must be a PEM encoded X.509 certificate")
+	}
+	return chain
+}
+
+// parseKey decodes PEM private key in PKCS #8, PKCS #1 or SEC 1 form.
+func parseKey(data string, errs *validation.Errors) crypto.Signer {
+	block, _ := pem.Decode([]byte(data))
+	if block == nil {
+		errs.Add("private_key", validation.CodeInvalidValue, "must be a PEM encoded private key")
+		return nil
+	}
+	if block.Type == "ENCRYPTED PRIVATE KEY" || strings.Contains(block.Headers["Proc-Type"], "ENCRYPTED") {
+		errs.Add("private_key", validation.CodeInvalidValue, "must not be encrypted")
+		return nil
+	}
+	var key any
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		err = fmt.Errorf("unknown PEM type %s", block.Type)
+	}
+	if err != nil {
+		errs.Add("private_key", validation.CodeInvalidValue, fmt.Sprintf("is not a valid private key: %v", err))
+		return nil
+	}
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		if k.N.BitLen() < minRSABits {
+			errs.Add("private_key", validation.CodeInvalidValue, fmt.Sprintf("RSA keys must have at least %d bits", minRSABits))
+			return nil
+		}
+		return k
+	case *ecdsa.PrivateKey:
+		return k
+	}
+	errs.Add("private_key", validation.CodeInvalidValue, "must be an RSA or ECDSA key")
+	return nil
+}
+
+// keyAlgorithm describes key of cert, e.g. "RSA-2048" or "ECDSA-P-256".
+func keyAlgorithm(cert *x509.Certificate) string {
+	switch k := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return fmt.Sprintf("RSA-%d", k.N.BitLen())
+	case *ecdsa.PublicKey:
+		return "ECDSA-" + k.Curve.Params().Name
+	}
+	return cert.PublicKeyAlgorithm.String()
+}
+
+// matches reports whether key is the private key of cert.
+func matches(key crypto.Signer, cert *x509.Certificate) bool {
+	pub, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	return ok && pub.Equal(cert.PublicKey)
+}
+
+// selfSigned reports whether cert is a trust anchor issued by itself.
+func selfSigned(cert *x509.Certificate) bool {
+	return bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignatureFrom(cert) == nil
+}
+
+// encodeChain returns chain as PEM.
+func encodeChain(chain []*x509.Certificate) string {
+	var b strings.Builder
+	for _, cert := range chain {
+		_ = pem.Encode(&b, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+	return b.String()
+}
+
+// sealer encrypts private keys at rest with AES-256-GCM. Ciphertexts are
+// bound to tenant and certificate, so that they cannot be moved between
+// records.
+type sealer struct {
+	aead cipher.AEAD
+}
+
+func newSealer(secret []byte) *sealer {
+	key := sha256.Sum256(secret)
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		panic(fmt.Sprintf("certificates: create cipher: %v", err))
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		panic(fmt.Sprintf("certificates: create cipher: %v", err))
+	}
+	return &sealer{aead: aead}
+}
+
+func additionalData(tenantID, certificateID string) []byte {
+	return []byte(tenantID + "/" + certificateID)
+}
+
+func (s *sealer) seal(key crypto.Signer, tenantID, certificateID string) ([]byte, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("certificates: marshal key: %w", err)
+	}
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("certificates: generate nonce: %w", err)
+	}
+	return s.aead.Seal(nonce, nonce, der, additionalData(tenantID, certificateID)), nil
+}
+
+func (s *sealer) open(sealed []byte, tenantID, certificateID string) (crypto.Signer, error) {
+	n := s.aead.NonceSize()
+	if len(sealed) < n {
+		return nil, errors.New("certificates: sealed key is truncated")
+	}
+	der, err := s.aead.Open(nil, sealed[:n], sealed[n:], additionalData(tenantID, certificateID))
+	if err != nil {
+		return nil, fmt.Errorf("certificates: decrypt key: %w", err)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("certificates: parse key: %w", err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("certificates: %T cannot sign", key)
+	}
+	return signer, nil
+}
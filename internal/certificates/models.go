@@ -0,0 +1,68 @@
+package certificates
+
+import (
+	"errors"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/validation"
+)
+
+// MaxSize caps size of uploaded certificate chains and keys in bytes.
+const MaxSize = 64 << 10
+
+// Certificate represents the signing_certificates table structure: an X.509
+// certificate of tenant that documents are signed with. Chain holds the PEM
+// encoded certificate followed by its intermediates; the private key is
+// stored encrypted and never returned.
+type Certificate struct {
+	CertificateID     string    `json:"certificate_id"`
+	TenantID          string    `json:"tenant_id"`
+	Name              string    `json:"name"`
+	Subject           string    `json:"subject"`
+	Issuer            string    `json:"issuer"`
+	SerialNumber      string    `json:"serial_number"`
+	FingerprintSHA256 string    `json:"fingerprint_sha256"`
+	KeyAlgorithm      string    `json:"key_algorithm"`
+	NotBefore         time.Time `json:"not_before"`
+	NotAfter          time.Time `json:"not_after"`
+	Chain             string    `json:"chain"`
+	EncryptedKey      []byte    `json:"-"`
+	UploadedBy        string    `json:"uploaded_by"`
+	UploadedAt        time.Time `json:"uploaded_at"`
+}
+
+// UploadRequest describes a new certificate. Certificate is the PEM encoded
+// certificate, optionally followed by its issuers, each certificate issued
+// by the next, and PrivateKey its unencrypted PEM encoded key in PKCS #8,
+// PKCS #1 or SEC 1 form.
+type UploadRequest struct {
+	TenantID    string
+	Name        string
+	Certificate string
+	PrivateKey  string
+	UploadedBy  string
+}
+
+var (
+	// ErrNotFound is returned when certificate does not exist in tenant.
+	ErrNotFound = errors.New("certificates: certificate not found")
+	// ErrConflict is returned when tenant uploaded the certificate already.
+	ErrConflict = errors.New("certificates: certificate already uploaded")
+	// ErrExpired is returned when signing with a certificate that is not
+	// valid at the time.
+	ErrExpired = errors.New("certificates: certificate is not valid at this time")
+	// ErrInvalidInput indicates validation error.
+	ErrInvalidInput = errors.New("certificates: invalid input")
+)
+
+// Validate ensures upload business rules not depending on the content of
+// certificate and key.
+func (r UploadRequest) Validate() error {
+	var errs validation.Errors
+	errs.Required("tenant_id", r.TenantID)
+	errs.Length("name", r.Name, 3, 100)
+	errs.Required("certificate", r.Certificate)
+	errs.Required("private_key", r.PrivateKey)
+	errs.Required("uploaded_by", r.UploadedBy)
+	return errs.Err()
+}
@@ -0,0 +1,13 @@
+package certificates
+
+import "context"
+
+// Repository defines persistence layer for signing certificates.
+type Repository interface {
+	ListCertificates(ctx context.Context, tenantID string) ([]Certificate, error)
+	GetCertificate(ctx context.Context, tenantID, certificateID string) (*Certificate, error)
+	// CreateCertificate stores certificate unless tenant has one with the
+	// same fingerprint.
+	CreateCertificate(ctx context.Context, cert Certificate) (*Certificate, error)
+	DeleteCertificate(ctx context.Context, tenantID, certificateID string) error
+}
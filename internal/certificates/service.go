@@ -0,0 +1,218 @@
+package certificates
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/ids"
+	"github.com/lumiforge/docfactory-backend/internal/pdfsign"
+	"github.com/lumiforge/docfactory-backend/internal/validation"
+)
+
+// NewInMemoryRepository creates thread-safe repository for prototyping.
+func NewInMemoryRepository() Repository {
+	return &inMemoryRepository{certificates: make(map[string]Certificate)}
+}
+
+// Service stores tenant certificates and signs and validates PDF documents
+// with them.
+type Service struct {
+	repo   Repository
+	sealer *sealer
+}
+
+// NewService creates service instance. Private keys are encrypted with a key
+// derived from secret.
+func NewService(repo Repository, secret []byte) *Service {
+	return &Service{repo: repo, sealer: newSealer(secret)}
+}
+
+// Upload checks that the certificate can sign documents with the given key
+// and stores both, the key encrypted.
+func (s *Service) Upload(ctx context.Context, req UploadRequest) (*Certificate, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	if len(req.Certificate) > MaxSize || len(req.PrivateKey) > MaxSize {
+		return nil, fmt.Errorf("%w: certificate and key must be at most 64 KiB each", ErrInvalidInput)
+	}
+	var errs validation.Errors
+	chain := parseChain(req.Certificate, &errs)
+	key := parseKey(req.PrivateKey, &errs)
+	now := time.Now().UTC()
+	if len(chain) > 0 {
+		cert := chain[0]
+		if key != nil && !matches(key, cert) {
+			errs.Add("private_key", validation.CodeInvalidValue, "does not match the certificate")
+		}
+		if now.After(cert.NotAfter) {
+			errs.Add("certificate", validation.CodeInvalidValue, "has expired")
+		}
+		for i := 1; i < len(chain); i++ {
+			if chain[i-1].CheckSignatureFrom(chain[i]) != nil {
+				errs.Add("certificate", validation.CodeInvalidValue, fmt.Sprintf("certificate %d of the chain is not issued by the next one", i))
+			}
+		}
+		if cert.KeyUsage != 0 && cert.KeyUsage&(x509.KeyUsageDigitalSignature|x509.KeyUsageContentCommitment) == 0 {
+			errs.Add("certificate", validation.CodeInvalidValue, "key usage does not allow digital signatures")
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	leaf := chain[0]
+	fingerprint := sha256.Sum256(leaf.Raw)
+	cert := Certificate{
+		CertificateID:     ids.New(),
+		TenantID:          req.TenantID,
+		Name:              strings.TrimSpace(req.Name),
+		Subject:           leaf.Subject.String(),
+		Issuer:            leaf.Issuer.String(),
+		SerialNumber:      strings.ToUpper(leaf.SerialNumber.Text(16)),
+		FingerprintSHA256: hex.EncodeToString(fingerprint[:]),
+		KeyAlgorithm:      keyAlgorithm(leaf),
+		NotBefore:         leaf.NotBefore.UTC(),
+		NotAfter:          leaf.NotAfter.UTC(),
+		Chain:             encodeChain(chain),
+		UploadedBy:        req.UploadedBy,
+		UploadedAt:        now,
+	}
+	sealed, err := s.sealer.seal(key, cert.TenantID, cert.CertificateID)
+	if err != nil {
+		return nil, err
+	}
+	cert.EncryptedKey = sealed
+	return s.repo.CreateCertificate(ctx, cert)
+}
+
+// ListCertificates returns certificates of tenant, newest first.
+func (s *Service) ListCertificates(ctx context.Context, tenantID string) ([]Certificate, error) {
+	return s.repo.ListCertificates(ctx, tenantID)
+}
+
+// GetCertificate fetches certificate.
+func (s *Service) GetCertificate(ctx context.Context, tenantID, certificateID string) (*Certificate, error) {
+	return s.repo.GetCertificate(ctx, tenantID, certificateID)
+}
+
+// DeleteCertificate removes certificate and its key. Templates referring to
+// it fail to generate documents until they are given another certificate.
+func (s *Service) DeleteCertificate(ctx context.Context, tenantID, certificateID string) error {
+	return s.repo.DeleteCertificate(ctx, tenantID, certificateID)
+}
+
+// Signer decrypts the key of certificate for signing at time at, when the
+// certificate must be valid.
+func (s *Service) Signer(ctx context.Context, tenantID, certificateID string, at time.Time) (*pdfsign.Signer, error) {
+	cert, err := s.repo.GetCertificate(ctx, tenantID, certificateID)
+	if err != nil {
+		return nil, err
+	}
+	if at.Before(cert.NotBefore) || at.After(cert.NotAfter) {
+		return nil, ErrExpired
+	}
+	var errs validation.Errors
+	chain := parseChain(cert.Chain, &errs)
+	if err := errs.Err(); err != nil {
+		return nil, fmt.Errorf("certificates: stored chain of %s: %w", certificateID, err)
+	}
+	key, err := s.sealer.open(cert.EncryptedKey, cert.TenantID, cert.CertificateID)
+	if err != nil {
+		return nil, err
+	}
+	return &pdfsign.Signer{Key: key, Certificate: chain[0], Chain: chain[1:]}, nil
+}
+
+// Validate checks the signatures of a PDF document. Self-signed certificates
+// of tenant chains are trusted besides the system roots, so that documents
+// the tenant signed with self-signed or private CA certificates validate.
+func (s *Service) Validate(ctx context.Context, tenantID string, pdf []byte) ([]pdfsign.Signature, error) {
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	certs, err := s.repo.ListCertificates(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	for _, cert := range certs {
+		var errs validation.Errors
+		for _, c := range parseChain(cert.Chain, &errs) {
+			if selfSigned(c) {
+				roots.AddCert(c)
+			}
+		}
+	}
+	signatures, err := pdfsign.Verify(pdf, pdfsign.VerifyOptions{Roots: roots})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	return signatures, nil
+}
+
+// inMemoryRepository is prototyping repository with maps.
+type inMemoryRepository struct {
+	certificates map[string]Certificate
+	mu           sync.RWMutex
+}
+
+func (r *inMemoryRepository) ListCertificates(ctx context.Context, tenantID string) ([]Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := []Certificate{}
+	for _, cert := range r.certificates {
+		if cert.TenantID == tenantID {
+			cert.EncryptedKey = bytes.Clone(cert.EncryptedKey)
+			result = append(result, cert)
+		}
+	}
+	slices.SortFunc(result, func(a, b Certificate) int {
+		return b.UploadedAt.Compare(a.UploadedAt)
+	})
+	return result, nil
+}
+
+func (r *inMemoryRepository) GetCertificate(ctx context.Context, tenantID, certificateID string) (*Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cert, ok := r.certificates[certificateID]
+	if !ok || cert.TenantID != tenantID {
+		return nil, ErrNotFound
+	}
+	cert.EncryptedKey = bytes.Clone(cert.EncryptedKey)
+	return &cert, nil
+}
+
+func (r *inMemoryRepository) CreateCertificate(ctx context.Context, cert Certificate) (*Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.certificates {
+		if existing.TenantID == cert.TenantID && existing.FingerprintSHA256 == cert.FingerprintSHA256 {
+			return nil, ErrConflict
+		}
+	}
+	cert.EncryptedKey = bytes.Clone(cert.EncryptedKey)
+	r.certificates[cert.CertificateID] = cert
+	clone := cert
+	clone.EncryptedKey = bytes.Clone(cert.EncryptedKey)
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) DeleteCertificate(ctx context.Context, tenantID, certificateID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cert, ok := r.certificates[certificateID]
+	if !ok || cert.TenantID != tenantID {
+		return ErrNotFound
+	}
+	delete(r.certificates, certificateID)
+	return nil
+}
@@ -0,0 +1,203 @@
+package certificates
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/pdfsign"
+	"github.com/lumiforge/docfactory-backend/internal/validation"
+)
+
+// issued is a test certificate with its key.
+type issued struct {
+	cert *x509.Certificate
+	key  crypto.Signer
+}
+
+func (c issued) pem() string {
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw}))
+}
+
+// issue creates certificate named name valid from notBefore until notAfter,
+// issued by parent or self-signed when parent is nil. CA certificates may
+// issue others.
+func issue(t *testing.T, name string, parent *issued, ca bool, notBefore, notAfter time.Time) issued {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(7),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+	}
+	if ca {
+		tmpl.IsCA = true
+		tmpl.KeyUsage |= x509.KeyUsageCertSign
+	}
+	issuer, issuerKey := tmpl, crypto.Signer(key)
+	if parent != nil {
+		issuer, issuerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, issuer, key.Public(), issuerKey)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	return issued{cert: cert, key: key}
+}
+
+// current issues certificate valid now.
+func current(t *testing.T, name string, parent *issued, ca bool) issued {
+	t.Helper()
+	now := time.Now()
+	return issue(t, name, parent, ca, now.Add(-time.Hour), now.Add(time.Hour))
+}
+
+func keyPEM(t *testing.T, key crypto.Signer) string {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+}
+
+func newTestService() *Service {
+	return NewService(NewInMemoryRepository(), []byte("test secret"))
+}
+
+func upload(s *Service, certificate, privateKey string) (*Certificate, error) {
+	return s.Upload(context.Background(), UploadRequest{
+		TenantID:    "tenant",
+		Name:        "Signing",
+		Certificate: certificate,
+		PrivateKey:  privateKey,
+		UploadedBy:  "user",
+	})
+}
+
+// assertFieldError checks that err is a validation error of field with
+// message.
+func assertFieldError(t *testing.T, err error, field, message string) {
+	t.Helper()
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("error %v, want %v", err, ErrInvalidInput)
+	}
+	var errs validation.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("error %v carries no field errors", err)
+	}
+	for _, f := range errs {
+		if f.Field == field && f.Message == message {
+			return
+		}
+	}
+	t.Errorf("field errors %+v, want %s %q", errs, field, message)
+}
+
+func TestUploadStoresCertificate(t *testing.T) {
+	leaf := current(t, "Signer", nil, false)
+	cert, err := upload(newTestService(), leaf.pem(), keyPEM(t, leaf.key))
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if cert.KeyAlgorithm != "ECDSA-P-256" || cert.SerialNumber != "7" || len(cert.EncryptedKey) == 0 {
+		t.Errorf("uploaded certificate %+v", cert)
+	}
+}
+
+func TestUploadRejectsMismatchedKey(t *testing.T) {
+	leaf := current(t, "Signer", nil, false)
+	other := current(t, "Other", nil, false)
+	_, err := upload(newTestService(), leaf.pem(), keyPEM(t, other.key))
+	assertFieldError(t, err, "private_key", "does not match the certificate")
+}
+
+func TestUploadRejectsExpiredCertificate(t *testing.T) {
+	now := time.Now()
+	leaf := issue(t, "Signer", nil, false, now.Add(-48*time.Hour), now.Add(-24*time.Hour))
+	_, err := upload(newTestService(), leaf.pem(), keyPEM(t, leaf.key))
+	assertFieldError(t, err, "certificate", "has expired")
+}
+
+func TestUploadRejectsBrokenChain(t *testing.T) {
+	root := current(t, "Root", nil, true)
+	leaf := current(t, "Signer", &root, false)
+	other := current(t, "Other", nil, true)
+	_, err := upload(newTestService(), leaf.pem()+other.pem(), keyPEM(t, leaf.key))
+	assertFieldError(t, err, "certificate", "certificate 1 of the chain is not issued by the next one")
+}
+
+// testPDF returns a minimal one page document that can be signed.
+func testPDF() []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595.28 841.89] >>",
+	}
+	pdf := []byte("%PDF-1.7\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = len(pdf)
+		pdf = fmt.Appendf(pdf, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := len(pdf)
+	pdf = fmt.Appendf(pdf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		pdf = fmt.Appendf(pdf, "%010d 00000 n \n", offset)
+	}
+	return fmt.Appendf(pdf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+}
+
+func TestValidateTrustsOnlySelfSignedAnchors(t *testing.T) {
+	root := current(t, "Root", nil, true)
+	intermediate := current(t, "Intermediate", &root, true)
+	leaf := current(t, "Signer", &intermediate, false)
+	for _, tc := range []struct {
+		name    string
+		chain   string
+		trusted bool
+	}{
+		{"without root", leaf.pem() + intermediate.pem(), false},
+		{"with root", leaf.pem() + intermediate.pem() + root.pem(), true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestService()
+			if _, err := upload(s, tc.chain, keyPEM(t, leaf.key)); err != nil {
+				t.Fatalf("Upload: %v", err)
+			}
+			pdf, err := pdfsign.Sign(testPDF(), pdfsign.Signer{
+				Key: leaf.key, Certificate: leaf.cert, Chain: []*x509.Certificate{intermediate.cert},
+			}, pdfsign.Options{})
+			if err != nil {
+				t.Fatalf("Sign: %v", err)
+			}
+			signatures, err := s.Validate(context.Background(), "tenant", pdf)
+			if err != nil {
+				t.Fatalf("Validate: %v", err)
+			}
+			if len(signatures) != 1 || signatures[0].Trusted != tc.trusted {
+				t.Errorf("signatures %+v, want trusted %t", signatures, tc.trusted)
+			}
+		})
+	}
+}
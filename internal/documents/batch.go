@@ -0,0 +1,506 @@
+package documents
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/ids"
+	"github.com/lumiforge/docfactory-backend/internal/render"
+	"github.com/lumiforge/docfactory-backend/internal/templates"
+	"github.com/lumiforge/docfactory-backend/internal/validation"
+)
+
+// BatchStatus enumerates states of batch generation.
+type BatchStatus string
+
+const (
+	BatchQueued  BatchStatus = "queued"
+	BatchRunning BatchStatus = "running"
+	// BatchCompleted batches processed every row, possibly with row errors.
+	BatchCompleted BatchStatus = "completed"
+	// BatchFailed batches could not produce their result archive.
+	BatchFailed BatchStatus = "failed"
+)
+
+// BatchOutput enumerates results of batches: a ZIP archive of the files of
+// every document or a single PDF with all documents. The merged PDF is
+// rendered anew, so it is not offered for templates whose PDF files are
+// signed.
+type BatchOutput string
+
+const (
+	BatchOutputZIP BatchOutput = "zip"
+	BatchOutputPDF BatchOutput = "pdf"
+)
+
+// SourceFormat enumerates formats of batch uploads.
+type SourceFormat string
+
+const (
+	SourceCSV   SourceFormat = "csv"
+	SourceJSONL SourceFormat = "jsonl"
+)
+
+// MaxBatchRows caps rows of a batch upload.
+const MaxBatchRows = 10000
+
+// MaxMergedRows caps rows of batches with PDF output, whose merged document
+// is built in memory.
+const MaxMergedRows = 1000
+
+// batchProgressEvery is the number of rows between progress updates.
+const batchProgressEvery = 25
+
+// Batch is a generation of one document per row of an uploaded file.
+// Documents of the batch refer to it by BatchID.
+type Batch struct {
+	BatchID         string          `json:"batch_id"`
+	TenantID        string          `json:"tenant_id"`
+	TemplateID      string          `json:"template_id"`
+	TemplateVersion int             `json:"template_version"`
+	Locale          string          `json:"locale,omitempty"`
+	Formats         []render.Format `json:"formats"`
+	Output          BatchOutput     `json:"output"`
+	Status          BatchStatus     `json:"status"`
+	Total           int             `json:"total"`
+	Processed       int             `json:"processed"`
+	Succeeded       int             `json:"succeeded"`
+	Failed          int             `json:"failed"`
+	Errors          []RowError      `json:"errors"`
+	// Error explains why a failed batch has no result.
+	Error       string     `json:"error,omitempty"`
+	ResultKey   string     `json:"result_key,omitempty"`
+	CreatedBy   string     `json:"created_by"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+// RowError reports why the document of a row was not generated. Row is the
+// line of JSONL uploads and the record number of CSV uploads, header
+// excluded. Fields holds validation errors of row data.
+type RowError struct {
+	Row     int               `json:"row"`
+	Fields  validation.Errors `json:"fields,omitempty"`
+	Message string            `json:"message,omitempty"`
+}
+
+// BatchRequest describes a batch upload. Mapping maps template fields to
+// CSV columns; without it CSV columns are named after fields. Formats
+// default to PDF.
+type BatchRequest struct {
+	TenantID   string
+	TemplateID string
+	Version    string
+	Locale     string
+	Formats    []render.Format
+	Output     BatchOutput
+	Source     SourceFormat
+	Content    []byte
+	Mapping    map[string]string
+	CreatedBy  string
+}
+
+var (
+	// ErrBatchNotFound is returned when batch does not exist in tenant.
+	ErrBatchNotFound = errors.New("documents: batch not found")
+	// ErrBatchPending is returned for results of unfinished batches.
+	ErrBatchPending = errors.New("documents: batch has not finished")
+)
+
+// Validate ensures batch request business rules.
+func (r BatchRequest) Validate() error {
+	var errs validation.Errors
+	if err := (GenerateRequest{TenantID: r.TenantID, TemplateID: r.TemplateID, Locale: r.Locale, Formats: r.Formats, CreatedBy: r.CreatedBy}).Validate(); err != nil {
+		errs = append(errs, err.(validation.Errors)...)
+	}
+	switch r.Output {
+	case BatchOutputZIP:
+	case BatchOutputPDF:
+		if !slices.Contains(r.Formats, render.FormatPDF) {
+			errs.Add("formats", validation.CodeInvalidValue, "must include pdf for PDF output")
+		}
+	default:
+		errs.Add("output", validation.CodeInvalidValue, "must be zip or pdf")
+	}
+	switch r.Source {
+	case SourceCSV:
+	case SourceJSONL:
+		if len(r.Mapping) > 0 {
+			errs.Add("mapping", validation.CodeInvalidValue, "is only supported for CSV")
+		}
+	default:
+		errs.Add("source", validation.CodeInvalidValue, "must be csv or jsonl")
+	}
+	return errs.Err()
+}
+
+// batchRow is data of a row; rows with errs failed to decode.
+type batchRow struct {
+	number int
+	data   map[string]any
+	errs   validation.Errors
+	err    string
+}
+
+// StartBatch validates the upload and generates a document per row in the
+// background. Errors of the upload as a whole are returned; errors of rows
+// are reported by the batch.
+func (s *Service) StartBatch(ctx context.Context, req BatchRequest) (*Batch, error) {
+	if len(req.Formats) == 0 {
+		req.Formats = []render.Format{render.FormatPDF}
+	}
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	g, err := s.prepare(ctx, req.TenantID, req.TemplateID, req.Version, req.Locale)
+	if err != nil {
+		return nil, err
+	}
+	if req.Output == BatchOutputPDF && g.signer != nil {
+		var errs validation.Errors
+		errs.Add("output", validation.CodeInvalidValue, "must be zip for templates with signing, as a merged PDF would not carry the signatures")
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, errs.Err())
+	}
+	var rows []batchRow
+	if req.Source == SourceCSV {
+		rows, err = csvRows(req.Content, req.Mapping, g.template.Layout)
+	} else {
+		rows, err = jsonlRows(req.Content)
+	}
+	if err == nil {
+		var errs validation.Errors
+		switch {
+		case len(rows) == 0:
+			errs.Add("content", validation.CodeRequired, "must contain at least one row")
+		case len(rows) > MaxBatchRows:
+			errs.Add("content", validation.CodeInvalidValue, fmt.Sprintf("must contain at most %d rows", MaxBatchRows))
+		case req.Output == BatchOutputPDF && len(rows) > MaxMergedRows:
+			errs.Add("content", validation.CodeInvalidValue, fmt.Sprintf("must contain at most %d rows for PDF output", MaxMergedRows))
+		}
+		err = errs.Err()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	if err := s.quota.CheckBatch(ctx, req.TenantID, len(rows)); err != nil {
+		return nil, err
+	}
+	batch, err := s.repo.CreateBatch(ctx, Batch{
+		BatchID:         ids.New(),
+		TenantID:        req.TenantID,
+		TemplateID:      req.TemplateID,
+		TemplateVersion: g.template.Version,
+		Locale:          g.locale,
+		Formats:         req.Formats,
+		Output:          req.Output,
+		Status:          BatchQueued,
+		Total:           len(rows),
+		Errors:          []RowError{},
+		CreatedBy:       req.CreatedBy,
+		CreatedAt:       time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	go s.runBatch(context.WithoutCancel(ctx), *batch, g, rows)
+	return batch, nil
+}
+
+// runBatch generates documents of rows and stores the result archive.
+func (s *Service) runBatch(ctx context.Context, batch Batch, g *generation, rows []batchRow) {
+	batch.Status = BatchRunning
+	s.saveBatch(ctx, batch)
+	var files []batchFile
+	var merged render.PDFMerger
+	var mergeErr error
+	for _, row := range rows {
+		rowErr := RowError{Row: row.number, Fields: row.errs, Message: row.err}
+		if len(row.errs) == 0 && row.err == "" {
+			doc, in, err := s.generate(ctx, g, GenerateRequest{
+				TenantID:   batch.TenantID,
+				TemplateID: batch.TemplateID,
+				Formats:    batch.Formats,
+				Data:       row.data,
+				CreatedBy:  batch.CreatedBy,
+			}, links{batchID: batch.BatchID})
+			var fields validation.Errors
+			switch {
+			case errors.As(err, &fields):
+				rowErr.Fields = fields
+			case err != nil:
+				rowErr.Message = err.Error()
+			case batch.Output == BatchOutputPDF:
+				if mergeErr == nil {
+					mergeErr = merged.Add(in)
+				}
+			default:
+				for _, format := range batch.Formats {
+					files = append(files, batchFile{fmt.Sprintf("row-%05d.%s", row.number, format), doc.GeneratedFiles[format]})
+				}
+			}
+		}
+		batch.Processed++
+		if len(rowErr.Fields) > 0 || rowErr.Message != "" {
+			batch.Failed++
+			batch.Errors = append(batch.Errors, rowErr)
+		} else {
+			batch.Succeeded++
+		}
+		if batch.Processed%batchProgressEvery == 0 && batch.Processed < batch.Total {
+			s.saveBatch(ctx, batch)
+		}
+	}
+	err := mergeErr
+	if err == nil {
+		err = s.storeResult(ctx, &batch, files, &merged)
+	}
+	if err != nil {
+		batch.Status = BatchFailed
+		batch.Error = err.Error()
+	} else {
+		batch.Status = BatchCompleted
+	}
+	now := time.Now().UTC()
+	batch.CompletedAt = &now
+	s.saveBatch(ctx, batch)
+}
+
+func (s *Service) saveBatch(ctx context.Context, batch Batch) {
+	if err := s.repo.UpdateBatch(ctx, batch); err != nil {
+		log.Printf("batch %s: save progress: %v", batch.BatchID, err)
+	}
+}
+
+// batchFile is a stored document file added to ZIP results.
+type batchFile struct {
+	name, key string
+}
+
+// storeResult writes the result of batch. ZIP results hold the files of
+// generated documents and errors.csv when rows failed; they are streamed to
+// the file store, since batches may have many documents. Storage is
+// accounted once the size is known.
+func (s *Service) storeResult(ctx context.Context, batch *Batch, files []batchFile, merged *render.PDFMerger) error {
+	var content io.Reader
+	if batch.Output == BatchOutputPDF {
+		if batch.Succeeded == 0 {
+			return errors.New("no document was generated")
+		}
+		data, err := merged.Bytes()
+		if err != nil {
+			return err
+		}
+		content = bytes.NewReader(data)
+	} else {
+		pr, pw := io.Pipe()
+		// Closing the reader stops the writer when storing fails.
+		defer pr.Close()
+		go func() { pw.CloseWithError(s.writeArchive(ctx, pw, batch, files)) }()
+		content = pr
+	}
+	key := fmt.Sprintf("tenants/%s/batches/%s.%s", batch.TenantID, batch.BatchID, batch.Output)
+	size, err := s.files.Write(ctx, key, content)
+	if err == nil {
+		err = s.quota.AddStorage(ctx, batch.TenantID, size)
+	}
+	if err != nil {
+		_ = s.files.Delete(ctx, key)
+		return err
+	}
+	batch.ResultKey = key
+	return nil
+}
+
+// writeArchive writes ZIP archive of files and the error report of batch
+// to w, reading one file at a time.
+func (s *Service) writeArchive(ctx context.Context, w io.Writer, batch *Batch, files []batchFile) error {
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		entry, err := zw.CreateHeader(&zip.FileHeader{Name: f.name, Method: zip.Deflate})
+		if err != nil {
+			return err
+		}
+		file, err := s.files.Open(ctx, f.key)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(entry, file)
+		file.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if len(batch.Errors) > 0 {
+		entry, err := zw.CreateHeader(&zip.FileHeader{Name: "errors.csv", Method: zip.Deflate})
+		if err != nil {
+			return err
+		}
+		if _, err := entry.Write(batch.ErrorReport()); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+// ErrorReport returns errors of rows as CSV with a line per field error.
+func (b Batch) ErrorReport() []byte {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"row", "field", "code", "message"})
+	for _, e := range b.Errors {
+		row := strconv.Itoa(e.Row)
+		if e.Message != "" {
+			_ = w.Write([]string{row, "", "", e.Message})
+		}
+		for _, f := range e.Fields {
+			_ = w.Write([]string{row, f.Field, f.Code, f.Message})
+		}
+	}
+	w.Flush()
+	return buf.Bytes()
+}
+
+// GetBatch fetches batch with its progress.
+func (s *Service) GetBatch(ctx context.Context, tenantID, batchID string) (*Batch, error) {
+	return s.repo.GetBatch(ctx, tenantID, batchID)
+}
+
+// BatchResult returns the result archive of a finished batch.
+func (s *Service) BatchResult(ctx context.Context, tenantID, batchID string) (*Batch, []byte, error) {
+	batch, err := s.repo.GetBatch(ctx, tenantID, batchID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if batch.ResultKey == "" {
+		if batch.Status == BatchFailed {
+			return nil, nil, ErrFileNotFound
+		}
+		return nil, nil, ErrBatchPending
+	}
+	data, err := s.files.Get(ctx, batch.ResultKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return batch, data, nil
+}
+
+// csvRows decodes CSV records into data typed by layout fields. Cells of
+// number fields are parsed as numbers and cells of array fields as JSON;
+// empty cells leave fields unset.
+func csvRows(content []byte, mapping map[string]string, layout templates.Layout) ([]batchRow, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(content, []byte("\ufeff"))))
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, validation.Errors{{Field: "content", Code: validation.CodeInvalidValue, Message: "is not valid CSV: " + err.Error()}}
+	}
+	if len(records) == 0 {
+		return nil, validation.Errors{{Field: "content", Code: validation.CodeRequired, Message: "must start with a header"}}
+	}
+	header := map[string]int{}
+	var errs validation.Errors
+	for i, name := range records[0] {
+		name = strings.TrimSpace(name)
+		if _, ok := header[name]; ok {
+			errs.Add("content", validation.CodeInvalidValue, fmt.Sprintf("column %q is repeated", name))
+		}
+		header[name] = i
+	}
+	columns := map[string]int{} // field name -> column index
+	if len(mapping) > 0 {
+		for _, field := range slices.Sorted(maps.Keys(mapping)) {
+			column, ok := header[mapping[field]]
+			switch {
+			case !layoutHas(layout, field):
+				errs.Add("mapping."+field, validation.CodeNotFound, "is not a template field")
+			case !ok:
+				errs.Add("mapping."+field, validation.CodeNotFound, fmt.Sprintf("column %q is not in the CSV header", mapping[field]))
+			default:
+				columns[field] = column
+			}
+		}
+	} else {
+		for _, name := range records[0] {
+			name = strings.TrimSpace(name)
+			if !layoutHas(layout, name) {
+				errs.Add("content", validation.CodeUnknownField, fmt.Sprintf("column %q matches no template field", name))
+				continue
+			}
+			columns[name] = header[name]
+		}
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	rows := make([]batchRow, 0, len(records)-1)
+	for i, record := range records[1:] {
+		row := batchRow{number: i + 1, data: map[string]any{}}
+		for _, field := range slices.Sorted(maps.Keys(columns)) {
+			cell := strings.TrimSpace(record[columns[field]])
+			if cell == "" {
+				continue
+			}
+			f, _ := layout.Field(field)
+			switch f.Type {
+			case templates.FieldNumber:
+				n, err := strconv.ParseFloat(cell, 64)
+				if err != nil {
+					row.errs.Add("data."+field, validation.CodeInvalidType, "must be a number")
+					continue
+				}
+				row.data[field] = n
+			case templates.FieldArray:
+				var items []any
+				if err := json.Unmarshal([]byte(cell), &items); err != nil {
+					row.errs.Add("data."+field, validation.CodeInvalidType, "must be a JSON array")
+					continue
+				}
+				row.data[field] = items
+			default:
+				row.data[field] = cell
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func layoutHas(layout templates.Layout, field string) bool {
+	_, ok := layout.Field(field)
+	return ok
+}
+
+// jsonlRows decodes lines of JSON objects. Blank lines are skipped but
+// counted, so that rows are line numbers.
+func jsonlRows(content []byte) ([]batchRow, error) {
+	sc := bufio.NewScanner(bytes.NewReader(content))
+	sc.Buffer(nil, len(content)+1)
+	var rows []batchRow
+	for line := 1; sc.Scan(); line++ {
+		text := bytes.TrimSpace(sc.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		row := batchRow{number: line}
+		if err := json.Unmarshal(text, &row.data); err != nil || row.data == nil {
+			row.err = "line is not a JSON object"
+		}
+		rows = append(rows, row)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, validation.Errors{{Field: "content", Code: validation.CodeInvalidValue, Message: "is not valid JSONL: " + err.Error()}}
+	}
+	return rows, nil
+}
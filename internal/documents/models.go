@@ -0,0 +1,113 @@
+package documents
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/locale"
+	"github.com/lumiforge/docfactory-backend/internal/render"
+	"github.com/lumiforge/docfactory-backend/internal/validation"
+)
+
+// Document represents the documents table structure. GeneratedFiles maps
+// output formats to storage keys of the rendered files and Metadata holds the
+// data the document was filled with. Locale is the locale the document was
+// rendered in, empty for templates without one. BatchID refers to the batch
+// that generated the document and RegeneratedFrom to the document it was
+// regenerated from, if any. ContentHash and Signature let the public verify
+// the document; revoked documents fail verification, which discloses the
+// data fields named by VerificationFields. CertificateID is the
+// certificate the PDF file is digitally signed with.
+type Document struct {
+	DocumentID         string                   `json:"document_id"`
+	TenantID           string                   `json:"tenant_id"`
+	TemplateID         string                   `json:"template_id"`
+	TemplateVersion    int                      `json:"template_version"`
+	Locale             string                   `json:"locale,omitempty"`
+	BatchID            string                   `json:"batch_id,omitempty"`
+	RegeneratedFrom    string                   `json:"regenerated_from,omitempty"`
+	GeneratedFiles     map[render.Format]string `json:"generated_files"`
+	Metadata           map[string]any           `json:"metadata"`
+	CreatedBy          string                   `json:"created_by"`
+	ContentHash        string                   `json:"content_hash,omitempty"`
+	Signature          string                   `json:"signature,omitempty"`
+	VerificationFields []string                 `json:"verification_fields,omitempty"`
+	CertificateID      string                   `json:"certificate_id,omitempty"`
+	CreatedAt          time.Time                `json:"created_at"`
+	RevokedAt          *time.Time               `json:"revoked_at"`
+	RevocationReason   string                   `json:"revocation_reason,omitempty"`
+	DeletedAt          *time.Time               `json:"deleted_at"`
+}
+
+// GenerateRequest describes a document to generate. Version is a version
+// number or label; empty uses the current template. Locale is a language tag
+// such as "kk-KZ"; empty uses the locale of the template.
+type GenerateRequest struct {
+	TenantID   string
+	TemplateID string
+	Version    string
+	Locale     string
+	Formats    []render.Format
+	Data       map[string]any
+	CreatedBy  string
+}
+
+// RegenerateRequest describes regeneration of a document against a newer
+// template version: the current one when Version is empty. Formats and
+// Locale default to those of the document.
+type RegenerateRequest struct {
+	TenantID   string
+	DocumentID string
+	Version    string
+	Locale     string
+	Formats    []render.Format
+	CreatedBy  string
+}
+
+var (
+	// ErrNotFound is returned when document does not exist in tenant.
+	ErrNotFound = errors.New("documents: document not found")
+	// ErrDeleted is returned for files and regeneration of soft-deleted
+	// documents.
+	ErrDeleted = errors.New("documents: document is deleted")
+	// ErrRevoked is returned for regeneration of revoked documents.
+	ErrRevoked = errors.New("documents: document is revoked")
+	// ErrFileNotFound is returned when document was not generated in format.
+	ErrFileNotFound = errors.New("documents: file not found")
+	// ErrInvalidInput indicates validation error.
+	ErrInvalidInput = errors.New("documents: invalid input")
+)
+
+// Validate ensures generation request business rules.
+func (r GenerateRequest) Validate() error {
+	var errs validation.Errors
+	errs.Required("tenant_id", r.TenantID)
+	errs.Required("template_id", r.TemplateID)
+	errs.Required("created_by", r.CreatedBy)
+	if r.Locale != "" {
+		if _, err := locale.Canonical(r.Locale); err != nil {
+			errs.Add("locale", validation.CodeInvalidValue, "must be a language tag such as kk-KZ")
+		}
+	}
+	if len(r.Formats) == 0 {
+		errs.Add("formats", validation.CodeRequired, "must contain at least one format")
+	}
+	seen := map[render.Format]bool{}
+	for i, f := range r.Formats {
+		field := fmt.Sprintf("formats[%d]", i)
+		switch {
+		case !render.Supported(f):
+			errs.Add(field, validation.CodeInvalidValue, "is not supported")
+		case seen[f]:
+			errs.Add(field, validation.CodeInvalidValue, "is listed twice")
+		}
+		seen[f] = true
+	}
+	return errs.Err()
+}
+
+// fileKey returns object storage key of document file.
+func fileKey(tenantID, documentID string, format render.Format) string {
+	return fmt.Sprintf("tenants/%s/documents/%s.%s", tenantID, documentID, format)
+}
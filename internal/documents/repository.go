@@ -0,0 +1,55 @@
+package documents
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// ListOptions filter and paginate documents. Empty filters match every
+// document; CreatedSince is inclusive and CreatedBefore exclusive.
+type ListOptions struct {
+	TenantID       string
+	TemplateID     string
+	CreatedBy      string
+	BatchID        string
+	CreatedSince   time.Time
+	CreatedBefore  time.Time
+	IncludeDeleted bool
+	Limit          int
+	Offset         int
+}
+
+// Repository defines persistence layer for generated documents.
+type Repository interface {
+	ListDocuments(ctx context.Context, opt ListOptions) ([]Document, error)
+	CountDocuments(ctx context.Context, opt ListOptions) (int, error)
+	GetDocument(ctx context.Context, tenantID, documentID string) (*Document, error)
+	CreateDocument(ctx context.Context, doc Document) (*Document, error)
+	// LookupDocument finds document in any tenant, for public verification.
+	LookupDocument(ctx context.Context, documentID string) (*Document, error)
+	// RevokeDocument records revocation unless document is revoked already.
+	RevokeDocument(ctx context.Context, tenantID, documentID, reason string, at time.Time) (*Document, error)
+	SoftDeleteDocument(ctx context.Context, tenantID, documentID string) error
+	RestoreDocument(ctx context.Context, tenantID, documentID string) (*Document, error)
+	// ReferencedVersions lists template versions documents were generated from.
+	ReferencedVersions(ctx context.Context, tenantID, templateID string) ([]int, error)
+
+	GetBatch(ctx context.Context, tenantID, batchID string) (*Batch, error)
+	CreateBatch(ctx context.Context, batch Batch) (*Batch, error)
+	// UpdateBatch stores progress of batch.
+	UpdateBatch(ctx context.Context, batch Batch) error
+}
+
+// FileStore keeps rendered files in object storage.
+type FileStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	// Write stores the content of r without holding it in memory and
+	// returns its size.
+	Write(ctx context.Context, key string, r io.Reader) (int64, error)
+	Get(ctx context.Context, key string) ([]byte, error)
+	// Delete removes file; missing files are not an error.
+	Delete(ctx context.Context, key string) error
+	// Open returns a reader of file for streaming downloads.
+	Open(ctx context.Context, key string) (io.ReadSeekCloser, error)
+}
@@ -0,0 +1,578 @@
+package documents
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/assets"
+	"github.com/lumiforge/docfactory-backend/internal/ids"
+	"github.com/lumiforge/docfactory-backend/internal/locale"
+	"github.com/lumiforge/docfactory-backend/internal/pdfsign"
+	"github.com/lumiforge/docfactory-backend/internal/render"
+	"github.com/lumiforge/docfactory-backend/internal/templates"
+	"github.com/lumiforge/docfactory-backend/internal/validation"
+)
+
+// NewInMemoryRepository creates thread-safe repository for prototyping.
+func NewInMemoryRepository() Repository {
+	return &inMemoryRepository{documents: make(map[string]Document), batches: make(map[string]Batch)}
+}
+
+// NewInMemoryFileStore creates thread-safe file store for prototyping.
+func NewInMemoryFileStore() FileStore {
+	return &inMemoryFileStore{files: make(map[string][]byte)}
+}
+
+// TemplateSource provides templates documents are generated from.
+type TemplateSource interface {
+	GetTemplate(ctx context.Context, tenantID, templateID string) (*templates.Template, error)
+	ResolveVersion(ctx context.Context, tenantID, templateID, ref string) (*templates.TemplateVersion, error)
+	RecordUsage(ctx context.Context, tenantID, templateID string, documents int) error
+}
+
+// AssetSource provides content of images embedded into documents.
+type AssetSource interface {
+	Content(ctx context.Context, tenantID, assetID string) (*assets.Asset, []byte, error)
+}
+
+// CertificateSource provides certificates PDF files are signed with.
+type CertificateSource interface {
+	Signer(ctx context.Context, tenantID, certificateID string, at time.Time) (*pdfsign.Signer, error)
+}
+
+// Quota meters generated documents and stored bytes against tenant plan.
+type Quota interface {
+	ReserveDocuments(ctx context.Context, tenantID string, n int) error
+	ReleaseDocuments(ctx context.Context, tenantID string, n int) error
+	AddStorage(ctx context.Context, tenantID string, delta int64) error
+	CheckBatch(ctx context.Context, tenantID string, size int) error
+}
+
+type unlimitedQuota struct{}
+
+func (unlimitedQuota) ReserveDocuments(context.Context, string, int) error { return nil }
+func (unlimitedQuota) ReleaseDocuments(context.Context, string, int) error { return nil }
+func (unlimitedQuota) AddStorage(context.Context, string, int64) error     { return nil }
+func (unlimitedQuota) CheckBatch(context.Context, string, int) error       { return nil }
+
+// Service generates documents from templates and stores rendered files.
+type Service struct {
+	repo      Repository
+	files     FileStore
+	templates TemplateSource
+	assets    AssetSource
+	quota     Quota
+	signer    *Signer
+	verifyURL string
+	certs     CertificateSource
+}
+
+// NewService creates service instance.
+func NewService(repo Repository, files FileStore, templates TemplateSource, assets AssetSource) *Service {
+	return &Service{repo: repo, files: files, templates: templates, assets: assets, quota: unlimitedQuota{}}
+}
+
+// WithQuota enables document and storage limit enforcement.
+func (s *Service) WithQuota(quota Quota) *Service {
+	s.quota = quota
+	return s
+}
+
+// Generate validates data against the template layout, renders the document
+// in every requested format and stores the files.
+func (s *Service) Generate(ctx context.Context, req GenerateRequest) (*Document, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	g, err := s.prepare(ctx, req.TenantID, req.TemplateID, req.Version, req.Locale)
+	if err != nil {
+		return nil, err
+	}
+	doc, _, err := s.generate(ctx, g, req, links{})
+	return doc, err
+}
+
+// Regenerate renders the metadata of a document against a newer template
+// version. The result is a new document referring to the original, which is
+// kept unchanged.
+func (s *Service) Regenerate(ctx context.Context, req RegenerateRequest) (*Document, error) {
+	src, err := s.repo.GetDocument(ctx, req.TenantID, req.DocumentID)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case src.DeletedAt != nil:
+		return nil, ErrDeleted
+	case src.RevokedAt != nil:
+		return nil, ErrRevoked
+	}
+	gen := GenerateRequest{
+		TenantID:   req.TenantID,
+		TemplateID: src.TemplateID,
+		Version:    req.Version,
+		Locale:     cmp.Or(req.Locale, src.Locale),
+		Formats:    req.Formats,
+		Data:       maps.Clone(src.Metadata),
+		CreatedBy:  req.CreatedBy,
+	}
+	if len(gen.Formats) == 0 {
+		gen.Formats = slices.Sorted(maps.Keys(src.GeneratedFiles))
+	}
+	if err := gen.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	g, err := s.prepare(ctx, gen.TenantID, gen.TemplateID, gen.Version, gen.Locale)
+	if err != nil {
+		return nil, err
+	}
+	if g.template.Version <= src.TemplateVersion {
+		var errs validation.Errors
+		errs.Add("version", validation.CodeInvalidValue, fmt.Sprintf("must be newer than version %d the document was generated from", src.TemplateVersion))
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, errs.Err())
+	}
+	doc, _, err := s.generate(ctx, g, gen, links{regeneratedFrom: src.DocumentID})
+	return doc, err
+}
+
+// generation is a template prepared for rendering documents: localized, at
+// the requested version, with the content of its images and the signer of
+// its PDF files, if any.
+type generation struct {
+	template templates.Template
+	locale   string
+	images   map[string]render.Image
+	signer   *pdfsign.Signer
+}
+
+func (s *Service) prepare(ctx context.Context, tenantID, templateID, version, tag string) (*generation, error) {
+	tpl, err := s.templates.GetTemplate(ctx, tenantID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if tpl.DeletedAt != nil {
+		return nil, templates.ErrTemplateDeleted
+	}
+	if version != "" {
+		v, err := s.templates.ResolveVersion(ctx, tenantID, templateID, version)
+		if err != nil {
+			return nil, err
+		}
+		*tpl = tpl.AtVersion(*v)
+	}
+	loc := tpl.Localization.Locale
+	if tag != "" {
+		loc, _ = locale.Canonical(tag)
+	}
+	g := &generation{template: tpl.Localized(loc), locale: loc, images: map[string]render.Image{}}
+	for _, assetID := range tpl.Layout.AssetIDs() {
+		asset, content, err := s.assets.Content(ctx, tenantID, assetID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: asset %s used by the template is unavailable: %v", ErrInvalidInput, assetID, err)
+		}
+		g.images[assetID] = render.Image{ContentType: asset.MimeType, Data: content}
+	}
+	if signing := tpl.Signing; signing.Set() {
+		if s.certs == nil {
+			return nil, fmt.Errorf("%w: signing of documents is not available", ErrInvalidInput)
+		}
+		g.signer, err = s.certs.Signer(ctx, tenantID, signing.CertificateID, time.Now())
+		if err != nil {
+			return nil, fmt.Errorf("%w: certificate %s used by the template is unavailable: %v", ErrInvalidInput, signing.CertificateID, err)
+		}
+	}
+	return g, nil
+}
+
+// links refer a generated document to the batch or document it came from.
+type links struct {
+	batchID         string
+	regeneratedFrom string
+}
+
+// generate renders and stores one document of g. It returns the render
+// input too, so that batches can merge documents.
+func (s *Service) generate(ctx context.Context, g *generation, req GenerateRequest, l links) (*Document, render.Input, error) {
+	in := render.Input{Template: g.template, Data: req.Data, Images: g.images, Locale: g.locale}
+	if err := g.template.Layout.ValidateData(req.Data); err != nil {
+		return nil, in, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	doc := Document{
+		DocumentID:      ids.New(),
+		TenantID:        req.TenantID,
+		TemplateID:      req.TemplateID,
+		TemplateVersion: g.template.Version,
+		Locale:          g.locale,
+		BatchID:         l.batchID,
+		RegeneratedFrom: l.regeneratedFrom,
+		GeneratedFiles:  map[render.Format]string{},
+		Metadata:        req.Data,
+		CreatedBy:       req.CreatedBy,
+		CreatedAt:       time.Now().UTC(),
+	}
+	if s.signer != nil {
+		doc.VerificationFields = slices.Clone(g.template.Layout.VerificationFields)
+	}
+	if doc.Metadata == nil {
+		doc.Metadata = map[string]any{}
+	}
+	verification, err := s.sign(&doc)
+	if err != nil {
+		return nil, in, err
+	}
+	in.Verification = verification
+	rendered := map[render.Format][]byte{}
+	var size int64
+	for _, format := range req.Formats {
+		data, err := render.Render(format, in)
+		if err != nil {
+			return nil, in, err
+		}
+		if format == render.FormatPDF && g.signer != nil {
+			if data, err = g.signPDF(data, doc.CreatedAt); err != nil {
+				return nil, in, err
+			}
+			doc.CertificateID = g.template.Signing.CertificateID
+		}
+		rendered[format] = data
+		size += int64(len(data))
+	}
+
+	if err := s.quota.ReserveDocuments(ctx, req.TenantID, 1); err != nil {
+		return nil, in, err
+	}
+	if err := s.quota.AddStorage(ctx, req.TenantID, size); err != nil {
+		_ = s.quota.ReleaseDocuments(ctx, req.TenantID, 1)
+		return nil, in, err
+	}
+	// rollback gives back the allowance of a document that was not stored.
+	rollback := func() {
+		_ = s.quota.AddStorage(ctx, req.TenantID, -size)
+		_ = s.quota.ReleaseDocuments(ctx, req.TenantID, 1)
+	}
+	for _, format := range req.Formats {
+		key := fileKey(req.TenantID, doc.DocumentID, format)
+		if err := s.files.Put(ctx, key, rendered[format]); err != nil {
+			rollback()
+			return nil, in, err
+		}
+		doc.GeneratedFiles[format] = key
+	}
+	created, err := s.repo.CreateDocument(ctx, doc)
+	if err != nil {
+		rollback()
+		return nil, in, err
+	}
+	// The document exists now; failing would make clients retry and create
+	// duplicates, so usage statistics are best effort.
+	if err := s.templates.RecordUsage(ctx, req.TenantID, req.TemplateID, 1); err != nil {
+		log.Printf("document %s: record template usage: %v", created.DocumentID, err)
+	}
+	return created, in, nil
+}
+
+// ListDocuments returns page of documents, newest first, and total number
+// of matching documents.
+func (s *Service) ListDocuments(ctx context.Context, opt ListOptions) ([]Document, int, error) {
+	items, err := s.repo.ListDocuments(ctx, opt)
+	if err != nil {
+		return nil, 0, err
+	}
+	count, err := s.repo.CountDocuments(ctx, opt)
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, count, nil
+}
+
+// GetDocument fetches document.
+func (s *Service) GetDocument(ctx context.Context, tenantID, documentID string) (*Document, error) {
+	return s.repo.GetDocument(ctx, tenantID, documentID)
+}
+
+// DeleteDocument performs soft delete. Files are kept until the document is
+// purged, so that it can be restored.
+func (s *Service) DeleteDocument(ctx context.Context, tenantID, documentID string) error {
+	return s.repo.SoftDeleteDocument(ctx, tenantID, documentID)
+}
+
+// RestoreDocument undoes soft delete.
+func (s *Service) RestoreDocument(ctx context.Context, tenantID, documentID string) (*Document, error) {
+	return s.repo.RestoreDocument(ctx, tenantID, documentID)
+}
+
+// OpenFile returns document and a reader of its rendered file in format.
+// The caller closes the reader.
+func (s *Service) OpenFile(ctx context.Context, tenantID, documentID string, format render.Format) (*Document, io.ReadSeekCloser, error) {
+	doc, err := s.repo.GetDocument(ctx, tenantID, documentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if doc.DeletedAt != nil {
+		return nil, nil, ErrDeleted
+	}
+	key, ok := doc.GeneratedFiles[format]
+	if !ok {
+		return nil, nil, ErrFileNotFound
+	}
+	file, err := s.files.Open(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, file, nil
+}
+
+// ReferencedVersions lists template versions with generated documents, so
+// that retention keeps them.
+func (s *Service) ReferencedVersions(ctx context.Context, tenantID, templateID string) ([]int, error) {
+	return s.repo.ReferencedVersions(ctx, tenantID, templateID)
+}
+
+// inMemoryRepository is prototyping repository with maps.
+type inMemoryRepository struct {
+	documents map[string]Document
+	batches   map[string]Batch
+	mu        sync.RWMutex
+}
+
+func (r *inMemoryRepository) ListDocuments(ctx context.Context, opt ListOptions) ([]Document, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var result []Document
+	for _, doc := range r.documents {
+		if opt.matches(doc) {
+			doc.GeneratedFiles = maps.Clone(doc.GeneratedFiles)
+			result = append(result, doc)
+		}
+	}
+	slices.SortFunc(result, func(a, b Document) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), cmp.Compare(a.DocumentID, b.DocumentID))
+	})
+	start := min(opt.Offset, len(result))
+	end := len(result)
+	if opt.Limit > 0 {
+		end = min(start+opt.Limit, end)
+	}
+	return append([]Document{}, result[start:end]...), nil
+}
+
+func (r *inMemoryRepository) CountDocuments(ctx context.Context, opt ListOptions) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	count := 0
+	for _, doc := range r.documents {
+		if opt.matches(doc) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// matches reports whether doc passes the filters of opt.
+func (opt ListOptions) matches(doc Document) bool {
+	switch {
+	case doc.TenantID != opt.TenantID:
+		return false
+	case !opt.IncludeDeleted && doc.DeletedAt != nil:
+		return false
+	case opt.TemplateID != "" && doc.TemplateID != opt.TemplateID:
+		return false
+	case opt.CreatedBy != "" && doc.CreatedBy != opt.CreatedBy:
+		return false
+	case opt.BatchID != "" && doc.BatchID != opt.BatchID:
+		return false
+	case !opt.CreatedSince.IsZero() && doc.CreatedAt.Before(opt.CreatedSince):
+		return false
+	case !opt.CreatedBefore.IsZero() && !doc.CreatedAt.Before(opt.CreatedBefore):
+		return false
+	}
+	return true
+}
+
+func (r *inMemoryRepository) GetDocument(ctx context.Context, tenantID, documentID string) (*Document, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	doc, ok := r.documents[documentID]
+	if !ok || doc.TenantID != tenantID {
+		return nil, ErrNotFound
+	}
+	clone := doc
+	clone.GeneratedFiles = maps.Clone(doc.GeneratedFiles)
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) CreateDocument(ctx context.Context, doc Document) (*Document, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.documents[doc.DocumentID] = doc
+	clone := doc
+	clone.GeneratedFiles = maps.Clone(doc.GeneratedFiles)
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) LookupDocument(ctx context.Context, documentID string) (*Document, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	doc, ok := r.documents[documentID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	clone := doc
+	clone.GeneratedFiles = maps.Clone(doc.GeneratedFiles)
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) RevokeDocument(ctx context.Context, tenantID, documentID, reason string, at time.Time) (*Document, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	doc, ok := r.documents[documentID]
+	if !ok || doc.TenantID != tenantID {
+		return nil, ErrNotFound
+	}
+	if doc.RevokedAt == nil {
+		doc.RevokedAt = &at
+		doc.RevocationReason = reason
+		r.documents[documentID] = doc
+	}
+	clone := doc
+	clone.GeneratedFiles = maps.Clone(doc.GeneratedFiles)
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) SoftDeleteDocument(ctx context.Context, tenantID, documentID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	doc, ok := r.documents[documentID]
+	if !ok || doc.TenantID != tenantID {
+		return ErrNotFound
+	}
+	if doc.DeletedAt == nil {
+		now := time.Now().UTC()
+		doc.DeletedAt = &now
+		r.documents[documentID] = doc
+	}
+	return nil
+}
+
+func (r *inMemoryRepository) RestoreDocument(ctx context.Context, tenantID, documentID string) (*Document, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	doc, ok := r.documents[documentID]
+	if !ok || doc.TenantID != tenantID {
+		return nil, ErrNotFound
+	}
+	doc.DeletedAt = nil
+	r.documents[documentID] = doc
+	clone := doc
+	clone.GeneratedFiles = maps.Clone(doc.GeneratedFiles)
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) ReferencedVersions(ctx context.Context, tenantID, templateID string) ([]int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	seen := map[int]bool{}
+	for _, doc := range r.documents {
+		if doc.TenantID == tenantID && doc.TemplateID == templateID {
+			seen[doc.TemplateVersion] = true
+		}
+	}
+	return slices.Sorted(maps.Keys(seen)), nil
+}
+
+func (r *inMemoryRepository) GetBatch(ctx context.Context, tenantID, batchID string) (*Batch, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	batch, ok := r.batches[batchID]
+	if !ok || batch.TenantID != tenantID {
+		return nil, ErrBatchNotFound
+	}
+	clone := batch
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) CreateBatch(ctx context.Context, batch Batch) (*Batch, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	batch.Formats = slices.Clone(batch.Formats)
+	batch.Errors = slices.Clone(batch.Errors)
+	r.batches[batch.BatchID] = batch
+	clone := batch
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) UpdateBatch(ctx context.Context, batch Batch) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.batches[batch.BatchID]; !ok {
+		return ErrBatchNotFound
+	}
+	batch.Formats = slices.Clone(batch.Formats)
+	batch.Errors = slices.Clone(batch.Errors)
+	r.batches[batch.BatchID] = batch
+	return nil
+}
+
+// inMemoryFileStore keeps files in a map keyed by storage key.
+type inMemoryFileStore struct {
+	files map[string][]byte
+	mu    sync.RWMutex
+}
+
+func (s *inMemoryFileStore) Put(ctx context.Context, key string, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[key] = bytes.Clone(data)
+	return nil
+}
+
+func (s *inMemoryFileStore) Write(ctx context.Context, key string, r io.Reader) (int64, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[key] = data
+	return int64(len(data)), nil
+}
+
+func (s *inMemoryFileStore) Delete(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.files, key)
+	return nil
+}
+
+func (s *inMemoryFileStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.files[key]
+	if !ok {
+		return nil, ErrFileNotFound
+	}
+	return bytes.Clone(data), nil
+}
+
+func (s *inMemoryFileStore) Open(ctx context.Context, key string) (io.ReadSeekCloser, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	data, ok := s.files[key]
+	if !ok {
+		return nil, ErrFileNotFound
+	}
+	// Stored files are never modified in place, so the reader can share data.
+	return nopCloser{bytes.NewReader(data)}, nil
+}
+
+// nopCloser adds no-op Close to in-memory readers.
+type nopCloser struct{ io.ReadSeeker }
+
+func (nopCloser) Close() error { return nil }
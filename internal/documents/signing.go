@@ -0,0 +1,31 @@
+package documents
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/pdfsign"
+)
+
+// WithSigning enables PAdES signing of PDF files of templates with signing
+// settings, using certificates of certs.
+func (s *Service) WithSigning(certs CertificateSource) *Service {
+	s.certs = certs
+	return s
+}
+
+// signPDF signs rendered PDF file as the template specifies, claiming
+// signing time at.
+func (g *generation) signPDF(pdf []byte, at time.Time) ([]byte, error) {
+	spec := g.template.Signing
+	opt := pdfsign.Options{Reason: spec.Reason, Location: spec.Location, Time: at}
+	if b := spec.Box; b.Set() {
+		opt.Box = &pdfsign.Box{Page: b.Page, X: b.XMM, Y: b.YMM, Width: b.WidthMM, Height: b.HeightMM}
+	}
+	signed, err := pdfsign.Sign(pdf, *g.signer, opt)
+	if errors.Is(err, pdfsign.ErrPageNotFound) {
+		return nil, fmt.Errorf("%w: signature box: %v", ErrInvalidInput, err)
+	}
+	return signed, err
+}
@@ -0,0 +1,179 @@
+package documents
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/validation"
+)
+
+// Signer signs content hashes of documents with HMAC-SHA256, so that
+// verification detects forged documents and records altered in storage.
+type Signer struct {
+	secret []byte
+}
+
+// NewSigner creates signer with secret key.
+func NewSigner(secret []byte) *Signer {
+	return &Signer{secret: secret}
+}
+
+func (s *Signer) sign(hash string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(hash))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// WithVerification enables signing of generated documents. Verification
+// elements of layouts encode baseURL/verify/{document_id}?signature=...,
+// with baseURL the public address of the API.
+func (s *Service) WithVerification(signer *Signer, baseURL string) *Service {
+	s.signer = signer
+	s.verifyURL = strings.TrimSuffix(baseURL, "/") + "/verify/"
+	return s
+}
+
+// contentHash returns hex encoded SHA-256 digest of the data and metadata of
+// doc. Rendered files are not covered, since they embed the signature.
+func contentHash(doc Document) (string, error) {
+	content, err := json.Marshal(struct {
+		DocumentID      string         `json:"document_id"`
+		TenantID        string         `json:"tenant_id"`
+		TemplateID      string         `json:"template_id"`
+		TemplateVersion int            `json:"template_version"`
+		Locale          string         `json:"locale"`
+		BatchID         string         `json:"batch_id"`
+		RegeneratedFrom string         `json:"regenerated_from"`
+		Data            map[string]any `json:"data"`
+		CreatedBy       string         `json:"created_by"`
+		CreatedAt       string         `json:"created_at"`
+		Verification    []string       `json:"verification_fields,omitempty"`
+	}{
+		doc.DocumentID, doc.TenantID, doc.TemplateID, doc.TemplateVersion, doc.Locale,
+		doc.BatchID, doc.RegeneratedFrom, doc.Metadata, doc.CreatedBy, doc.CreatedAt.UTC().Format(time.RFC3339Nano),
+		doc.VerificationFields,
+	})
+	if err != nil {
+		return "", fmt.Errorf("documents: hash content: %w", err)
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// sign sets content hash and signature of doc and returns its verification
+// URL, empty when signing is disabled.
+func (s *Service) sign(doc *Document) (string, error) {
+	if s.signer == nil {
+		return "", nil
+	}
+	hash, err := contentHash(*doc)
+	if err != nil {
+		return "", err
+	}
+	doc.ContentHash = hash
+	doc.Signature = s.signer.sign(hash)
+	return s.verifyURL + url.PathEscape(doc.DocumentID) + "?signature=" + doc.Signature, nil
+}
+
+// VerificationStatus is the outcome of document verification.
+type VerificationStatus string
+
+const (
+	// VerificationValid documents are authentic and in force.
+	VerificationValid VerificationStatus = "valid"
+	// VerificationRevoked documents were withdrawn by their issuer.
+	VerificationRevoked VerificationStatus = "revoked"
+	// VerificationMismatch means the presented hash or signature is not
+	// that of the document, e.g. a QR code copied onto a forged document.
+	VerificationMismatch VerificationStatus = "mismatch"
+	// VerificationTampered documents no longer match their signature.
+	VerificationTampered VerificationStatus = "tampered"
+)
+
+// Verification is the public result of document verification. Fields holds
+// the values of the verification fields chosen by the template, so that a
+// verifier can tell a QR code copied onto a forged document; they are only
+// disclosed to callers presenting the signature of the document, as its QR
+// code does, and never for mismatched or tampered documents.
+type Verification struct {
+	DocumentID       string             `json:"document_id"`
+	Status           VerificationStatus `json:"status"`
+	Valid            bool               `json:"valid"`
+	TemplateID       string             `json:"template_id"`
+	TemplateVersion  int                `json:"template_version"`
+	IssuedAt         time.Time          `json:"issued_at"`
+	RevokedAt        *time.Time         `json:"revoked_at,omitempty"`
+	RevocationReason string             `json:"revocation_reason,omitempty"`
+	Fields           map[string]any     `json:"fields,omitempty"`
+}
+
+// Verify checks that document exists, is not revoked and still matches its
+// signature. Presented hash and signature, such as those of a scanned QR
+// code, must match the document when given. Deleted documents and documents
+// generated without signing are not found.
+func (s *Service) Verify(ctx context.Context, documentID, hash, signature string) (*Verification, error) {
+	if s.signer == nil {
+		return nil, ErrNotFound
+	}
+	doc, err := s.repo.LookupDocument(ctx, documentID)
+	if err != nil {
+		return nil, err
+	}
+	if doc.DeletedAt != nil || doc.Signature == "" {
+		return nil, ErrNotFound
+	}
+	v := &Verification{
+		DocumentID:       doc.DocumentID,
+		TemplateID:       doc.TemplateID,
+		TemplateVersion:  doc.TemplateVersion,
+		IssuedAt:         doc.CreatedAt,
+		RevokedAt:        doc.RevokedAt,
+		RevocationReason: doc.RevocationReason,
+	}
+	actual, err := contentHash(*doc)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case actual != doc.ContentHash || !hmac.Equal([]byte(s.signer.sign(actual)), []byte(doc.Signature)):
+		v.Status = VerificationTampered
+	case hash != "" && !hmac.Equal([]byte(strings.ToLower(hash)), []byte(doc.ContentHash)),
+		signature != "" && !hmac.Equal([]byte(signature), []byte(doc.Signature)):
+		v.Status = VerificationMismatch
+	case doc.RevokedAt != nil:
+		v.Status = VerificationRevoked
+	default:
+		v.Status = VerificationValid
+		v.Valid = true
+	}
+	if signature != "" && (v.Valid || v.Status == VerificationRevoked) {
+		for _, name := range doc.VerificationFields {
+			if value, ok := doc.Metadata[name]; ok {
+				if v.Fields == nil {
+					v.Fields = map[string]any{}
+				}
+				v.Fields[name] = value
+			}
+		}
+	}
+	return v, nil
+}
+
+// Revoke withdraws document, so that its verification fails. Revocation is
+// final; files stay available to the tenant.
+func (s *Service) Revoke(ctx context.Context, tenantID, documentID, reason string) (*Document, error) {
+	var errs validation.Errors
+	errs.Length("reason", reason, 0, 500)
+	if err := errs.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	return s.repo.RevokeDocument(ctx, tenantID, documentID, strings.TrimSpace(reason), time.Now().UTC())
+}
@@ -0,0 +1,83 @@
+package httpapi
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/apikeys"
+	"github.com/lumiforge/docfactory-backend/internal/users"
+)
+
+// APIKeyHandler wires HTTP requests to API key service.
+type APIKeyHandler struct {
+	service *apikeys.KeyService
+}
+
+// NewAPIKeyHandler creates HTTP handler.
+func NewAPIKeyHandler(service *apikeys.KeyService) *APIKeyHandler {
+	return &APIKeyHandler{service: service}
+}
+
+// ListKeys handles GET /api-keys.
+func (h *APIKeyHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.ListKeys(r.Context(), apikeys.ListOptions{
+		TenantID:       currentUser(r).TenantID,
+		IncludeRevoked: r.URL.Query().Get("include_revoked") == "true",
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ItemList[apikeys.APIKey]{Items: keys})
+}
+
+// GetKey handles GET /api-keys/{id}.
+func (h *APIKeyHandler) GetKey(w http.ResponseWriter, r *http.Request) {
+	key, err := h.service.GetKey(r.Context(), currentUser(r).TenantID, pathParam(r, "keyID"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, key)
+}
+
+// CreateKey handles POST /api-keys. The secret is returned only once.
+func (h *APIKeyHandler) CreateKey(w http.ResponseWriter, r *http.Request) {
+	var payload APIKeyPayload
+	if !readPayload(w, r, &payload) {
+		return
+	}
+	key, secret, err := h.service.CreateKey(r.Context(), *currentUser(r), apikeys.CreateRequest{
+		Name:      payload.Name,
+		Role:      payload.Role,
+		Scopes:    payload.Scopes,
+		ExpiresAt: payload.ExpiresAt,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, APIKeyResult{Key: key, Secret: secret})
+}
+
+// RevokeKey handles DELETE /api-keys/{id}.
+func (h *APIKeyHandler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	if _, err := h.service.RevokeKey(r.Context(), *currentUser(r), pathParam(r, "keyID")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type APIKeyPayload struct {
+	Name      string     `json:"name"`
+	Role      users.Role `json:"role"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+// APIKeyResult carries the key secret, shown only at creation.
+type APIKeyResult struct {
+	Key    *apikeys.APIKey `json:"key"`
+	Secret string          `json:"secret"`
+}
@@ -0,0 +1,115 @@
+package httpapi
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/lumiforge/docfactory-backend/internal/assets"
+)
+
+// AssetHandler wires HTTP requests to asset service.
+type AssetHandler struct {
+	service *assets.Service
+}
+
+// NewAssetHandler creates HTTP handler.
+func NewAssetHandler(service *assets.Service) *AssetHandler {
+	return &AssetHandler{service: service}
+}
+
+// UploadAsset handles POST /assets. The body is the raw PNG or JPEG image;
+// metadata comes from the query string.
+func (h *AssetHandler) UploadAsset(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	content, err := readBody(w, r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	q := r.URL.Query()
+	assetType := assets.Type(q.Get("type"))
+	if assetType == "" {
+		assetType = assets.TypeLogo
+	}
+	asset, err := h.service.Upload(r.Context(), assets.UploadRequest{
+		TenantID:   tenantID,
+		TemplateID: q.Get("template_id"),
+		Type:       assetType,
+		FileName:   q.Get("file_name"),
+		UploadedBy: userFromRequest(r),
+		Content:    content,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, asset)
+}
+
+// ListAssets handles GET /assets.
+func (h *AssetHandler) ListAssets(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	items, err := h.service.ListAssets(r.Context(), assets.ListOptions{
+		TenantID: tenantID,
+		Type:     assets.Type(r.URL.Query().Get("type")),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ItemList[assets.Asset]{Items: items})
+}
+
+// GetAsset handles GET /assets/{id}.
+func (h *AssetHandler) GetAsset(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	asset, err := h.service.GetAsset(r.Context(), tenantID, pathParam(r, "assetID"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, asset)
+}
+
+// GetAssetContent handles GET /assets/{id}/content.
+func (h *AssetHandler) GetAssetContent(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	asset, content, err := h.service.Content(r.Context(), tenantID, pathParam(r, "assetID"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Content-Type", asset.MimeType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+	_, _ = w.Write(content)
+}
+
+// DeleteAsset handles DELETE /assets/{id}.
+func (h *AssetHandler) DeleteAsset(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := h.service.DeleteAsset(r.Context(), tenantID, pathParam(r, "assetID")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
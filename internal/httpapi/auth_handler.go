@@ -0,0 +1,122 @@
+package httpapi
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/auth"
+)
+
+const refreshCookieName = "refresh_token"
+
+// AuthHandler wires HTTP requests to auth service.
+type AuthHandler struct {
+	service *auth.AuthService
+}
+
+// NewAuthHandler creates HTTP handler.
+func NewAuthHandler(service *auth.AuthService) *AuthHandler {
+	return &AuthHandler{service: service}
+}
+
+// Login handles POST /auth/login.
+func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var payload LoginPayload
+	if !readPayload(w, r, &payload) {
+		return
+	}
+	session, err := h.service.Login(r.Context(), payload.Email, payload.Password)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	setRefreshCookie(w, session.RefreshToken, session.RefreshExpiresAt)
+	writeJSON(w, http.StatusOK, session)
+}
+
+// Refresh handles POST /auth/refresh.
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	cookie, err := r.Cookie(refreshCookieName)
+	if err != nil || cookie.Value == "" {
+		writeError(w, r, problemUnauthenticated.error("refresh token cookie is required"))
+		return
+	}
+	session, err := h.service.Refresh(r.Context(), cookie.Value)
+	if err != nil {
+		clearRefreshCookie(w)
+		writeError(w, r, err)
+		return
+	}
+	setRefreshCookie(w, session.RefreshToken, session.RefreshExpiresAt)
+	writeJSON(w, http.StatusOK, session)
+}
+
+// Logout handles POST /auth/logout.
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(refreshCookieName); err == nil && cookie.Value != "" {
+		if err := h.service.Logout(r.Context(), cookie.Value); err != nil {
+			writeError(w, r, err)
+			return
+		}
+	}
+	clearRefreshCookie(w)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Me handles GET /auth/me. API key callers receive the key metadata.
+func (h *AuthHandler) Me(w http.ResponseWriter, r *http.Request) {
+	if p := currentPrincipal(r); p != nil && p.Key != nil {
+		writeJSON(w, http.StatusOK, p.Key)
+		return
+	}
+	writeJSON(w, http.StatusOK, currentUser(r))
+}
+
+// ListKeys handles GET /admin/auth/keys.
+func (h *AuthHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	keys, err := h.service.Keys().Keys(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ItemList[auth.SigningKey]{Items: keys})
+}
+
+// RotateKeys handles POST /admin/auth/keys/rotate.
+func (h *AuthHandler) RotateKeys(w http.ResponseWriter, r *http.Request) {
+	key, err := h.service.Keys().Rotate(r.Context())
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, key)
+}
+
+func setRefreshCookie(w http.ResponseWriter, token string, expiresAt time.Time) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    token,
+		Path:     "/auth",
+		Expires:  expiresAt,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+func clearRefreshCookie(w http.ResponseWriter) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     refreshCookieName,
+		Value:    "",
+		Path:     "/auth",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+type LoginPayload struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
@@ -0,0 +1,138 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"github.com/lumiforge/docfactory-backend/internal/certificates"
+	"github.com/lumiforge/docfactory-backend/internal/pdfsign"
+	"github.com/lumiforge/docfactory-backend/internal/users"
+)
+
+// CertificateHandler wires HTTP requests to certificate service.
+type CertificateHandler struct {
+	service *certificates.Service
+}
+
+// NewCertificateHandler creates HTTP handler.
+func NewCertificateHandler(service *certificates.Service) *CertificateHandler {
+	return &CertificateHandler{service: service}
+}
+
+// UploadCertificate handles POST /certificates. Only admins manage the
+// certificates documents are legally signed with.
+func (h *CertificateHandler) UploadCertificate(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if p := currentPrincipal(r); p == nil || !p.Role.AtLeast(users.RoleAdmin) {
+		writeError(w, r, problemForbidden.error("admin role is required"))
+		return
+	}
+	var payload CertificatePayload
+	if !readPayload(w, r, &payload) {
+		return
+	}
+	cert, err := h.service.Upload(r.Context(), certificates.UploadRequest{
+		TenantID:    tenantID,
+		Name:        payload.Name,
+		Certificate: payload.Certificate,
+		PrivateKey:  payload.PrivateKey,
+		UploadedBy:  userFromRequest(r),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, cert)
+}
+
+// ListCertificates handles GET /certificates.
+func (h *CertificateHandler) ListCertificates(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	items, err := h.service.ListCertificates(r.Context(), tenantID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ItemList[certificates.Certificate]{Items: items})
+}
+
+// GetCertificate handles GET /certificates/{id}.
+func (h *CertificateHandler) GetCertificate(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	cert, err := h.service.GetCertificate(r.Context(), tenantID, pathParam(r, "certificateID"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, cert)
+}
+
+// DeleteCertificate handles DELETE /certificates/{id}.
+func (h *CertificateHandler) DeleteCertificate(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if p := currentPrincipal(r); p == nil || !p.Role.AtLeast(users.RoleAdmin) {
+		writeError(w, r, problemForbidden.error("admin role is required"))
+		return
+	}
+	if err := h.service.DeleteCertificate(r.Context(), tenantID, pathParam(r, "certificateID")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ValidateSignatures handles POST /signatures/validate. The body is the raw
+// PDF document.
+func (h *CertificateHandler) ValidateSignatures(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	content, err := readBodyLimit(w, r, maxUploadBytes)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	signatures, err := h.service.Validate(r.Context(), tenantID, content)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	valid := len(signatures) > 0
+	for _, sig := range signatures {
+		valid = valid && sig.Valid
+	}
+	writeJSON(w, http.StatusOK, SignatureReport{Valid: valid, Signatures: signatures})
+}
+
+// CertificatePayload uploads a PEM encoded certificate, optionally followed
+// by its intermediates, with its unencrypted private key.
+type CertificatePayload struct {
+	Name        string `json:"name"`
+	Certificate string `json:"certificate"`
+	PrivateKey  string `json:"private_key"`
+}
+
+// SignatureReport lists signatures of a PDF document in order of
+// appearance. Valid tells that the document has signatures and all of them
+// are valid.
+type SignatureReport struct {
+	Valid      bool                `json:"valid"`
+	Signatures []pdfsign.Signature `json:"signatures"`
+}
@@ -0,0 +1,381 @@
+package httpapi
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/documents"
+	"github.com/lumiforge/docfactory-backend/internal/render"
+	"github.com/lumiforge/docfactory-backend/internal/users"
+	"github.com/lumiforge/docfactory-backend/internal/validation"
+)
+
+// DocumentHandler wires HTTP requests to document service.
+type DocumentHandler struct {
+	service *documents.Service
+}
+
+// NewDocumentHandler creates HTTP handler.
+func NewDocumentHandler(service *documents.Service) *DocumentHandler {
+	return &DocumentHandler{service: service}
+}
+
+// GenerateDocument handles POST /templates/{id}/documents.
+func (h *DocumentHandler) GenerateDocument(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	var payload GeneratePayload
+	if !readPayload(w, r, &payload) {
+		return
+	}
+	doc, err := h.service.Generate(r.Context(), documents.GenerateRequest{
+		TenantID:   tenantID,
+		TemplateID: pathParam(r, "templateID"),
+		Version:    payload.Version,
+		Locale:     payload.Locale,
+		Formats:    payload.Formats,
+		Data:       payload.Data,
+		CreatedBy:  userFromRequest(r),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, doc)
+}
+
+// ListDocuments handles GET /documents.
+func (h *DocumentHandler) ListDocuments(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	limit, offset := paginationFromRequest(r, 50)
+	q := r.URL.Query()
+	var errs validation.Errors
+	opt := documents.ListOptions{
+		TenantID:       tenantID,
+		TemplateID:     q.Get("template_id"),
+		CreatedBy:      q.Get("created_by"),
+		BatchID:        q.Get("batch_id"),
+		CreatedSince:   timeQuery(&errs, q, "created_since"),
+		CreatedBefore:  timeQuery(&errs, q, "created_before"),
+		IncludeDeleted: q.Get("include_deleted") == "true",
+		Limit:          limit,
+		Offset:         offset,
+	}
+	if err := errs.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	items, total, err := h.service.ListDocuments(r.Context(), opt)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, Page[documents.Document]{Items: items, Total: total, Limit: limit, Offset: offset})
+}
+
+// timeQuery parses query parameter name as an RFC 3339 timestamp or a date,
+// which stands for its midnight in UTC.
+func timeQuery(errs *validation.Errors, q url.Values, name string) time.Time {
+	v := q.Get(name)
+	if v == "" {
+		return time.Time{}
+	}
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t
+		}
+	}
+	errs.Add(name, validation.CodeInvalidValue, "must be a date or an RFC 3339 timestamp")
+	return time.Time{}
+}
+
+// GetDocument handles GET /documents/{id}.
+func (h *DocumentHandler) GetDocument(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	doc, err := h.service.GetDocument(r.Context(), tenantID, pathParam(r, "documentID"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
+
+// DeleteDocument handles DELETE /documents/{id}.
+func (h *DocumentHandler) DeleteDocument(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := h.service.DeleteDocument(r.Context(), tenantID, pathParam(r, "documentID")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreDocument handles POST /documents/{id}/restore.
+func (h *DocumentHandler) RestoreDocument(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	doc, err := h.service.RestoreDocument(r.Context(), tenantID, pathParam(r, "documentID"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
+
+// RegenerateDocument handles POST /documents/{id}/regenerate.
+func (h *DocumentHandler) RegenerateDocument(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	var payload RegeneratePayload
+	if !readPayload(w, r, &payload) {
+		return
+	}
+	doc, err := h.service.Regenerate(r.Context(), documents.RegenerateRequest{
+		TenantID:   tenantID,
+		DocumentID: pathParam(r, "documentID"),
+		Version:    payload.Version,
+		Locale:     payload.Locale,
+		Formats:    payload.Formats,
+		CreatedBy:  userFromRequest(r),
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/documents/"+doc.DocumentID)
+	writeJSON(w, http.StatusCreated, doc)
+}
+
+// RevokeDocument handles POST /documents/{id}/revoke. Revocation is
+// reserved to tenant admins.
+func (h *DocumentHandler) RevokeDocument(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if p := currentPrincipal(r); p == nil || !p.Role.AtLeast(users.RoleAdmin) {
+		writeError(w, r, problemForbidden.error("admin role is required"))
+		return
+	}
+	var payload RevokePayload
+	if !readPayload(w, r, &payload) {
+		return
+	}
+	doc, err := h.service.Revoke(r.Context(), tenantID, pathParam(r, "documentID"), payload.Reason)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, doc)
+}
+
+// VerifyDocument handles GET /verify/{id}, the public check of documents.
+func (h *DocumentHandler) VerifyDocument(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	v, err := h.service.Verify(r.Context(), pathParam(r, "documentID"), q.Get("hash"), q.Get("signature"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, v)
+}
+
+// DownloadFile handles GET /documents/{id}/files/{format}. The file is
+// streamed from storage with support for range and conditional requests.
+func (h *DocumentHandler) DownloadFile(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	documentID := pathParam(r, "documentID")
+	format := render.Format(pathParam(r, "format"))
+	doc, file, err := h.service.OpenFile(r.Context(), tenantID, documentID, format)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	defer file.Close()
+	name := fmt.Sprintf("%s.%s", documentID, format)
+	w.Header().Set("Content-Type", render.ContentType(format))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": name}))
+	http.ServeContent(w, r, name, doc.CreatedAt, file)
+}
+
+// batchSources maps media types of batch uploads to their formats.
+var batchSources = map[string]documents.SourceFormat{
+	"text/csv":             documents.SourceCSV,
+	"application/x-ndjson": documents.SourceJSONL,
+	"application/jsonl":    documents.SourceJSONL,
+}
+
+// StartBatch handles POST /templates/{id}/documents/batch. The body is CSV
+// or JSONL; options are query parameters, with CSV columns mapped to fields
+// by repeated map=field=column parameters.
+func (h *DocumentHandler) StartBatch(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	source, ok := batchSources[mediaType]
+	if !ok {
+		writeError(w, r, problemUnsupportedMediaType.error("Content-Type must be text/csv or application/x-ndjson"))
+		return
+	}
+	content, err := readBodyLimit(w, r, maxUploadBytes)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	q := r.URL.Query()
+	req := documents.BatchRequest{
+		TenantID:   tenantID,
+		TemplateID: pathParam(r, "templateID"),
+		Version:    q.Get("version"),
+		Locale:     q.Get("locale"),
+		Output:     documents.BatchOutput(q.Get("output")),
+		Source:     source,
+		Content:    content,
+		Mapping:    map[string]string{},
+		CreatedBy:  userFromRequest(r),
+	}
+	if req.Output == "" {
+		req.Output = documents.BatchOutputZIP
+	}
+	if formats := q.Get("formats"); formats != "" {
+		for _, f := range strings.Split(formats, ",") {
+			req.Formats = append(req.Formats, render.Format(strings.TrimSpace(f)))
+		}
+	}
+	var errs validation.Errors
+	for _, m := range q["map"] {
+		field, column, ok := strings.Cut(m, "=")
+		if !ok || field == "" || column == "" {
+			errs.Add("map", validation.CodeInvalidValue, fmt.Sprintf("%q must be field=column", m))
+			continue
+		}
+		req.Mapping[field] = column
+	}
+	if err := errs.Err(); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	batch, err := h.service.StartBatch(r.Context(), req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/batches/"+batch.BatchID)
+	writeJSON(w, http.StatusAccepted, batch)
+}
+
+// GetBatch handles GET /batches/{id}.
+func (h *DocumentHandler) GetBatch(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	batch, err := h.service.GetBatch(r.Context(), tenantID, pathParam(r, "batchID"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, batch)
+}
+
+// BatchErrors handles GET /batches/{id}/errors.
+func (h *DocumentHandler) BatchErrors(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	batch, err := h.service.GetBatch(r.Context(), tenantID, pathParam(r, "batchID"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeFile(w, "text/csv; charset=utf-8", batch.BatchID+"-errors.csv", batch.ErrorReport())
+}
+
+// BatchResult handles GET /batches/{id}/result.
+func (h *DocumentHandler) BatchResult(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	batch, data, err := h.service.BatchResult(r.Context(), tenantID, pathParam(r, "batchID"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	contentType := "application/zip"
+	if batch.Output == documents.BatchOutputPDF {
+		contentType = render.ContentType(render.FormatPDF)
+	}
+	writeFile(w, contentType, fmt.Sprintf("%s.%s", batch.BatchID, batch.Output), data)
+}
+
+// writeFile writes data as attachment.
+func writeFile(w http.ResponseWriter, contentType, filename string, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	_, _ = w.Write(data)
+}
+
+// RegeneratePayload requests regeneration of a document. Version is a
+// version number or label, by default the current template; Formats and
+// Locale default to those of the document.
+type RegeneratePayload struct {
+	Version string          `json:"version,omitempty"`
+	Locale  string          `json:"locale,omitempty"`
+	Formats []render.Format `json:"formats,omitempty"`
+}
+
+// RevokePayload explains revocation of a document to those verifying it.
+type RevokePayload struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// GeneratePayload requests a document. Version is a version number or label;
+// the current template is used when it is empty. Locale selects translations
+// and formatting, by default those of the template locale.
+type GeneratePayload struct {
+	Version string          `json:"version,omitempty"`
+	Locale  string          `json:"locale,omitempty"`
+	Formats []render.Format `json:"formats"`
+	Data    map[string]any  `json:"data"`
+}
@@ -0,0 +1,414 @@
+package httpapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/apikeys"
+	"github.com/lumiforge/docfactory-backend/internal/auth"
+	"github.com/lumiforge/docfactory-backend/internal/idempotency"
+	"github.com/lumiforge/docfactory-backend/internal/ids"
+	"github.com/lumiforge/docfactory-backend/internal/ratelimit"
+	"github.com/lumiforge/docfactory-backend/internal/tenants"
+	"github.com/lumiforge/docfactory-backend/internal/users"
+)
+
+// requireActiveTenant rejects requests whose tenant does not reference an
+// existing active tenant before they reach the domain handlers.
+func requireActiveTenant(service *tenants.TenantService, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenantID, err := tenantFromRequest(r)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if _, err := service.ActiveTenant(r.Context(), tenantID); err != nil {
+			if errors.Is(err, tenants.ErrNotFound) {
+				err = problemForbidden.error("unknown tenant")
+			}
+			writeError(w, r, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireAdmin guards platform administration endpoints with a static token
+// passed in X-Admin-Token. An empty token disables the endpoints entirely.
+func requireAdmin(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if token == "" {
+			writeError(w, r, problemNotFound.error("no route for "+r.URL.Path))
+			return
+		}
+		presented := strings.TrimSpace(r.Header.Get("X-Admin-Token"))
+		if subtle.ConstantTimeCompare([]byte(presented), []byte(token)) != 1 {
+			writeError(w, r, problemUnauthenticated.error("admin token is invalid"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+type principalContextKey struct{}
+
+// principal is the authenticated caller of a request: either a user holding
+// an access token or an API key.
+type principal struct {
+	TenantID string
+	ActorID  string
+	Role     users.Role
+	User     *users.User
+	Key      *apikeys.APIKey
+}
+
+// allows reports whether caller may use scope. Users are limited by role only.
+func (p *principal) allows(scope string) bool {
+	return p.Key == nil || p.Key.HasScope(scope)
+}
+
+// authenticator resolves credentials into a principal.
+type authenticator struct {
+	auth  *auth.AuthService
+	users *users.UserService
+	keys  *apikeys.KeyService
+}
+
+// middleware accepts either a bearer access token, a bearer API key or an
+// X-API-Key header. User tokens are resolved into active users so that
+// attribution fields always reference real records.
+func (a authenticator) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := bearerToken(r)
+		if !ok {
+			unauthorized(w, r, problemUnauthenticated.error("bearer token or X-API-Key is required"))
+			return
+		}
+		var p *principal
+		var err error
+		if strings.HasPrefix(token, apikeys.TokenPrefix) {
+			p, err = a.apiKeyPrincipal(r, token)
+		} else {
+			p, err = a.userPrincipal(r, token)
+		}
+		if err != nil {
+			if errors.Is(err, errUnauthenticated) {
+				unauthorized(w, r, err)
+				return
+			}
+			writeError(w, r, err)
+			return
+		}
+		ctx := context.WithValue(r.Context(), principalContextKey{}, p)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+var errUnauthenticated = problemUnauthenticated.error("invalid credentials")
+
+func (a authenticator) userPrincipal(r *http.Request, token string) (*principal, error) {
+	claims, err := a.auth.Authenticate(r.Context(), token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errUnauthenticated, err)
+	}
+	user, err := a.users.ActiveUser(r.Context(), claims.TenantID, claims.Subject)
+	if err != nil {
+		if errors.Is(err, users.ErrNotFound) || errors.Is(err, users.ErrInactive) {
+			return nil, fmt.Errorf("%w: unknown or inactive user", errUnauthenticated)
+		}
+		return nil, err
+	}
+	return &principal{TenantID: user.TenantID, ActorID: user.UserID, Role: user.Role, User: user}, nil
+}
+
+func (a authenticator) apiKeyPrincipal(r *http.Request, token string) (*principal, error) {
+	key, err := a.keys.Authenticate(r.Context(), token)
+	if err != nil {
+		if errors.Is(err, apikeys.ErrInvalidKey) {
+			return nil, fmt.Errorf("%w: %v", errUnauthenticated, err)
+		}
+		return nil, err
+	}
+	return &principal{TenantID: key.TenantID, ActorID: key.ActorID(), Role: key.Role, Key: key}, nil
+}
+
+// requireAccess checks role and API key scope: reads need readScope, while
+// state-changing requests need writeScope and at least the editor role.
+func requireAccess(readScope, writeScope string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := currentPrincipal(r)
+		scope := readScope
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			scope = writeScope
+			if p == nil || !p.Role.AtLeast(users.RoleEditor) {
+				writeError(w, r, problemForbidden.error("editor role is required"))
+				return
+			}
+		}
+		if p == nil || !p.allows(scope) {
+			writeError(w, r, problemForbidden.error("scope "+scope+" is required"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// requireUserSession rejects API keys on endpoints reserved to people, such as
+// user and key management.
+func requireUserSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if currentUser(r) == nil {
+			writeError(w, r, problemForbidden.error("user session is required"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	if key := strings.TrimSpace(r.Header.Get("X-API-Key")); key != "" {
+		return key, true
+	}
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+func unauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="docfactory"`)
+	writeError(w, r, err)
+}
+
+// currentPrincipal returns caller resolved by authenticator.
+func currentPrincipal(r *http.Request) *principal {
+	p, _ := r.Context().Value(principalContextKey{}).(*principal)
+	return p
+}
+
+// currentUser returns user behind the request, nil for API keys.
+func currentUser(r *http.Request) *users.User {
+	if p := currentPrincipal(r); p != nil {
+		return p.User
+	}
+	return nil
+}
+
+// rateLimit applies tenant and principal token buckets of the route class and
+// advertises bucket state with RateLimit-* headers.
+func rateLimit(limiter *ratelimit.Limiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := currentPrincipal(r)
+		if p == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if allow(w, r, limiter, p.TenantID, p.ActorID) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// publicRateLimit applies buckets of public routes: one shared by every
+// caller and one per client address. Proxies are not trusted, so the
+// address is that of the connection.
+func publicRateLimit(limiter *ratelimit.Limiter, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addr, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			addr = r.RemoteAddr
+		}
+		if allow(w, r, limiter, "public", addr) {
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// allow takes a token of the route class and writes RateLimit-* headers, or
+// the rate limited problem when buckets are empty.
+func allow(w http.ResponseWriter, r *http.Request, limiter *ratelimit.Limiter, tenantID, principalID string) bool {
+	res, err := limiter.Allow(r.Context(), tenantID, principalID, routeClass(r))
+	if err != nil {
+		writeError(w, r, err)
+		return false
+	}
+	if res.Remaining >= 0 {
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+	}
+	if !res.Allowed {
+		w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+		writeError(w, r, problemRateLimited.error("rate limit exceeded"))
+		return false
+	}
+	return true
+}
+
+type routeClassContextKey struct{}
+
+// withRouteClass tells rate limiting the class of the matched route.
+func withRouteClass(class ratelimit.Class, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), routeClassContextKey{}, class)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// routeClass returns rate limit class set by withRouteClass.
+func routeClass(r *http.Request) ratelimit.Class {
+	if class, ok := r.Context().Value(routeClassContextKey{}).(ratelimit.Class); ok {
+		return class
+	}
+	return ratelimit.ClassWrite
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
+
+type requestIDContextKey struct{}
+
+// maxRequestIDLength bounds client supplied X-Request-ID values.
+const maxRequestIDLength = 128
+
+// withRequestID tags every request with an ID echoed in X-Request-ID and in
+// problem responses. Printable client supplied IDs are kept for tracing.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := strings.TrimSpace(r.Header.Get("X-Request-ID"))
+		if !validRequestID(id) {
+			id = ids.New()
+		}
+		w.Header().Set("X-Request-ID", id)
+		ctx := context.WithValue(r.Context(), requestIDContextKey{}, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		if c < 0x21 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// requestIDFrom returns ID assigned by withRequestID.
+func requestIDFrom(r *http.Request) string {
+	id, _ := r.Context().Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// replayedHeaders are response headers stored with idempotent responses;
+// per-request headers such as rate limit state are not replayed.
+var replayedHeaders = []string{"Content-Type", "Location"}
+
+// idempotent stores the first response to a request carrying Idempotency-Key
+// and replays it to retries with the same key by the same principal. Retries
+// with a different method, target, content type or body are rejected.
+// Responses with 5xx statuses are not stored so that such requests can be
+// retried. Bodies are read up to the upload limit; handlers still apply their
+// own limits.
+func idempotent(service *idempotency.Service, next http.Handler) http.Handler {
+	if service == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+		if key == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		body, err := readBodyLimit(w, r, maxUploadBytes)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		scope := idempotencyScope(r)
+		stored, err := service.Begin(r.Context(), scope, key, idempotency.Fingerprint(r.Method, r.URL.RequestURI(), r.Header.Get("Content-Type"), body))
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+		if stored != nil {
+			for name, values := range stored.Header {
+				w.Header()[name] = values
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			w.WriteHeader(stored.Status)
+			_, _ = w.Write(stored.Body)
+			return
+		}
+		rec := &responseRecorder{ResponseWriter: w}
+		completed := false
+		defer func() {
+			if !completed {
+				_ = service.Release(context.WithoutCancel(r.Context()), scope, key)
+			}
+		}()
+		next.ServeHTTP(rec, r)
+		if rec.status == 0 || rec.status >= http.StatusInternalServerError {
+			return
+		}
+		header := http.Header{}
+		for _, name := range replayedHeaders {
+			if v := rec.header.Values(name); len(v) > 0 {
+				header[name] = v
+			}
+		}
+		resp := idempotency.Response{Status: rec.status, Header: header, Body: rec.body.Bytes()}
+		if err := service.Complete(context.WithoutCancel(r.Context()), scope, key, resp); err == nil {
+			completed = true
+		}
+	})
+}
+
+// idempotencyScope isolates keys per principal of a tenant, so that nobody
+// is replayed the response of another user or API key; operator requests
+// share a scope.
+func idempotencyScope(r *http.Request) string {
+	if p := currentPrincipal(r); p != nil {
+		return "tenant:" + p.TenantID + ":" + p.ActorID
+	}
+	return "admin"
+}
+
+// responseRecorder passes response through while keeping a copy of it.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+		r.header = r.ResponseWriter.Header().Clone()
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
@@ -0,0 +1,453 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/assets"
+	"github.com/lumiforge/docfactory-backend/internal/barcode"
+	"github.com/lumiforge/docfactory-backend/internal/documents"
+	"github.com/lumiforge/docfactory-backend/internal/ratelimit"
+	"github.com/lumiforge/docfactory-backend/internal/render"
+	"github.com/lumiforge/docfactory-backend/internal/templates"
+	"github.com/lumiforge/docfactory-backend/internal/tenants"
+	"github.com/lumiforge/docfactory-backend/internal/users"
+)
+
+// openAPIVersion is the version of this API reported in the document.
+const openAPIVersion = "1.0.0"
+
+// enums lists allowed values of string types, which reflection cannot see.
+var enums = map[reflect.Type][]string{
+	reflect.TypeFor[templates.DocumentType](): {
+		string(templates.DocumentTypeWarranty), string(templates.DocumentTypeInstruction),
+		string(templates.DocumentTypeCertificate), string(templates.DocumentTypeLabel),
+	},
+	reflect.TypeFor[templates.PageSize](): stringValues(templates.PageSizes()),
+	reflect.TypeFor[templates.Unit]():     stringValues(templates.Units()),
+	reflect.TypeFor[templates.Orientation](): {
+		string(templates.OrientationPortrait), string(templates.OrientationLandscape),
+	},
+	reflect.TypeFor[templates.FieldType](): {
+		string(templates.FieldString), string(templates.FieldNumber), string(templates.FieldDate), string(templates.FieldArray),
+	},
+	reflect.TypeFor[templates.ElementType](): {
+		string(templates.ElementText), string(templates.ElementImage), string(templates.ElementTable), string(templates.ElementBarcode),
+		string(templates.ElementPageBreak), string(templates.ElementVerification),
+	},
+	reflect.TypeFor[templates.Align](): {
+		string(templates.AlignLeft), string(templates.AlignCenter), string(templates.AlignRight),
+	},
+	reflect.TypeFor[barcode.Symbology](): stringValues(barcode.Symbologies()),
+	reflect.TypeFor[barcode.Level]():     stringValues(barcode.Levels()),
+	reflect.TypeFor[assets.Type](): {
+		string(assets.TypeLogo), string(assets.TypeImage), string(assets.TypeWatermark),
+	},
+	reflect.TypeFor[render.Format](): stringValues(render.Formats()),
+	reflect.TypeFor[documents.BatchStatus](): {
+		string(documents.BatchQueued), string(documents.BatchRunning), string(documents.BatchCompleted), string(documents.BatchFailed),
+	},
+	reflect.TypeFor[documents.BatchOutput](): {string(documents.BatchOutputZIP), string(documents.BatchOutputPDF)},
+	reflect.TypeFor[documents.VerificationStatus](): {
+		string(documents.VerificationValid), string(documents.VerificationRevoked),
+		string(documents.VerificationMismatch), string(documents.VerificationTampered),
+	},
+	reflect.TypeFor[tenants.Subscription](): {
+		string(tenants.SubscriptionFree), string(tenants.SubscriptionPro), string(tenants.SubscriptionEnterprise),
+	},
+	reflect.TypeFor[users.Role](): {
+		string(users.RoleOwner), string(users.RoleAdmin), string(users.RoleEditor), string(users.RoleViewer),
+	},
+	reflect.TypeFor[ratelimit.Class](): stringValues(ratelimit.Classes()),
+}
+
+func stringValues[T ~string](values []T) []string {
+	result := make([]string, len(values))
+	for i, v := range values {
+		result[i] = string(v)
+	}
+	return result
+}
+
+var (
+	specOnce  sync.Once
+	specBytes []byte
+	specErr   error
+)
+
+// serveOpenAPI handles GET /openapi.json.
+func serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	specOnce.Do(func() {
+		var spec map[string]any
+		if spec, specErr = openAPISpec(routes(Handlers{})); specErr == nil {
+			specBytes, specErr = json.Marshal(spec)
+		}
+	})
+	if specErr != nil {
+		writeError(w, r, specErr)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(specBytes)
+}
+
+// openAPISpec builds OpenAPI 3.1 document of the route table. Schemas are
+// derived from payload and model structs through their json tags. It fails on
+// tables that cannot be documented consistently.
+func openAPISpec(table []route) (map[string]any, error) {
+	b := schemaBuilder{schemas: map[string]any{}, types: map[string]reflect.Type{}}
+	paths := map[string]map[string]any{}
+	ids := map[string]bool{}
+	for _, rt := range table {
+		if rt.id == "" || rt.summary == "" || rt.tag == "" {
+			return nil, fmt.Errorf("openapi: %s %s lacks id, summary or tag", rt.method, rt.path)
+		}
+		if ids[rt.id] {
+			return nil, fmt.Errorf("openapi: duplicate operation id %q", rt.id)
+		}
+		ids[rt.id] = true
+		if rt.status == 0 || (rt.status == http.StatusNoContent) != (rt.response == nil) {
+			return nil, fmt.Errorf("openapi: %s %s has inconsistent response", rt.method, rt.path)
+		}
+		op, err := b.operation(rt)
+		if err != nil {
+			return nil, err
+		}
+		if paths[rt.path] == nil {
+			paths[rt.path] = map[string]any{}
+		}
+		paths[rt.path][strings.ToLower(rt.method)] = op
+	}
+	if _, err := b.schema(reflect.TypeFor[Problem]()); err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"openapi": "3.1.0",
+		"info": map[string]any{
+			"title":   "DocFactory API",
+			"version": openAPIVersion,
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": b.schemas,
+			"securitySchemes": map[string]any{
+				"bearerAuth": map[string]any{"type": "http", "scheme": "bearer", "bearerFormat": "JWT or API key"},
+				"apiKey":     map[string]any{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"adminToken": map[string]any{"type": "apiKey", "in": "header", "name": "X-Admin-Token"},
+			},
+		},
+	}, nil
+}
+
+// schemaBuilder collects named struct schemas into components.
+type schemaBuilder struct {
+	schemas map[string]any
+	types   map[string]reflect.Type
+}
+
+func (b *schemaBuilder) operation(rt route) (map[string]any, error) {
+	op := map[string]any{
+		"operationId": rt.id,
+		"summary":     rt.summary,
+		"tags":        []string{rt.tag},
+		"security":    security(rt.access),
+	}
+	switch rt.access {
+	case accessTemplates:
+		op["description"] = "API keys need the templates:read scope for GET and templates:write otherwise."
+	case accessDocuments:
+		op["description"] = "API keys need the documents:read scope for GET and documents:write otherwise."
+	case accessPublicLimited:
+		op["description"] = "Rate limited per client address."
+	}
+	params, err := parameters(rt)
+	if err != nil {
+		return nil, err
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+	if rt.request != nil {
+		content, err := b.content(rt.request)
+		if err != nil {
+			return nil, err
+		}
+		op["requestBody"] = map[string]any{"required": true, "content": content}
+	}
+	success := map[string]any{"description": http.StatusText(rt.status)}
+	if rt.response != nil {
+		content, err := b.content(rt.response)
+		if err != nil {
+			return nil, err
+		}
+		success["content"] = content
+	}
+	op["responses"] = map[string]any{
+		fmt.Sprint(rt.status): success,
+		"default": map[string]any{
+			"description": "Problem details",
+			"content": map[string]any{problemContentType: map[string]any{
+				"schema": map[string]any{"$ref": "#/components/schemas/Problem"},
+			}},
+		},
+	}
+	return op, nil
+}
+
+// content documents body in each of its media types; bodies that are not
+// mediaTypes are JSON.
+func (b *schemaBuilder) content(body any) (map[string]any, error) {
+	bodies, ok := body.(mediaTypes)
+	if !ok {
+		bodies = mediaTypes{"application/json": body}
+	}
+	content := map[string]any{}
+	for mediaType, body := range bodies {
+		schema, err := b.bodySchema(body)
+		if err != nil {
+			return nil, err
+		}
+		content[mediaType] = map[string]any{"schema": schema}
+	}
+	return content, nil
+}
+
+func (b *schemaBuilder) bodySchema(body any) (map[string]any, error) {
+	variants, ok := body.(oneOf)
+	if !ok {
+		return b.schema(reflect.TypeOf(body))
+	}
+	schemas := make([]any, 0, len(variants))
+	for _, v := range variants {
+		schema, err := b.schema(reflect.TypeOf(v))
+		if err != nil {
+			return nil, err
+		}
+		schemas = append(schemas, schema)
+	}
+	return map[string]any{"oneOf": schemas}, nil
+}
+
+// parameters documents path wildcards of the route pattern and its declared
+// query parameters. Declared path parameters must exist in the pattern.
+func parameters(rt route) ([]any, error) {
+	declared := map[string]param{}
+	for _, p := range rt.params {
+		if p.in == "path" {
+			if !strings.Contains(rt.path, "{"+p.name+"}") {
+				return nil, fmt.Errorf("openapi: %s %s documents unknown path parameter %q", rt.method, rt.path, p.name)
+			}
+			declared[p.name] = p
+		}
+	}
+	var result []any
+	for _, segment := range strings.Split(rt.path, "/") {
+		name, ok := strings.CutPrefix(segment, "{")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSuffix(name, "}")
+		p, ok := declared[name]
+		if !ok {
+			p = param{name: name, in: "path", kind: "string"}
+		}
+		result = append(result, parameterObject(p, true))
+	}
+	for _, p := range rt.params {
+		if p.in == "query" {
+			result = append(result, parameterObject(p, false))
+		}
+	}
+	if rt.idempotent() {
+		result = append(result, parameterObject(param{
+			name:        "Idempotency-Key",
+			in:          "header",
+			kind:        "string",
+			description: "Replays the stored response of an earlier identical request by the same caller with the same key.",
+		}, false))
+	}
+	return result, nil
+}
+
+func parameterObject(p param, required bool) map[string]any {
+	obj := map[string]any{
+		"name":     p.name,
+		"in":       p.in,
+		"required": required,
+		"schema":   map[string]any{"type": p.kind},
+	}
+	if p.description != "" {
+		obj["description"] = p.description
+	}
+	return obj
+}
+
+func security(a access) []any {
+	switch a {
+	case accessPublic, accessPublicLimited:
+		return []any{}
+	case accessAdmin:
+		return []any{map[string]any{"adminToken": []string{}}}
+	case accessUserSession:
+		return []any{map[string]any{"bearerAuth": []string{}}}
+	default:
+		return []any{map[string]any{"bearerAuth": []string{}}, map[string]any{"apiKey": []string{}}}
+	}
+}
+
+// schema returns JSON Schema of t; named structs are referenced from
+// components.
+func (b *schemaBuilder) schema(t reflect.Type) (map[string]any, error) {
+	switch t {
+	case reflect.TypeFor[time.Time]():
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	case reflect.TypeFor[json.RawMessage]():
+		return map[string]any{}, nil
+	case reflect.TypeFor[binary]():
+		return map[string]any{"type": "string", "format": "binary"}, nil
+	}
+	switch t.Kind() {
+	case reflect.Pointer:
+		elem, err := b.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return nullable(elem), nil
+	case reflect.String:
+		s := map[string]any{"type": "string"}
+		if values, ok := enums[t]; ok {
+			s["enum"] = values
+		}
+		return s, nil
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "contentEncoding": "base64"}, nil
+		}
+		items, err := b.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		values, err := b.schema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		s := map[string]any{"type": "object", "additionalProperties": values}
+		if keys, ok := enums[t.Key()]; ok {
+			s["propertyNames"] = map[string]any{"enum": keys}
+		}
+		return s, nil
+	case reflect.Interface:
+		return map[string]any{}, nil
+	case reflect.Struct:
+		return b.structRef(t)
+	default:
+		return nil, fmt.Errorf("openapi: unsupported type %s", t)
+	}
+}
+
+func (b *schemaBuilder) structRef(t reflect.Type) (map[string]any, error) {
+	if t.Name() == "" {
+		properties := map[string]any{}
+		required := []string{}
+		if err := b.fields(t, properties, &required); err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "properties": properties, "required": required}, nil
+	}
+	name := schemaName(t)
+	ref := map[string]any{"$ref": "#/components/schemas/" + name}
+	if known, ok := b.types[name]; ok {
+		if known != t {
+			return nil, fmt.Errorf("openapi: schema name %s used by %s and %s", name, known, t)
+		}
+		return ref, nil
+	}
+	b.types[name] = t
+	properties := map[string]any{}
+	required := []string{}
+	if err := b.fields(t, properties, &required); err != nil {
+		return nil, err
+	}
+	b.schemas[name] = map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+	return ref, nil
+}
+
+// fields mirrors encoding/json: unexported and "-" fields are skipped,
+// embedded structs are flattened and omitempty fields are optional.
+func (b *schemaBuilder) fields(t reflect.Type, properties map[string]any, required *[]string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			if err := b.fields(field.Type, properties, required); err != nil {
+				return err
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema, err := b.schema(field.Type)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", t.Name(), field.Name, err)
+		}
+		properties[name] = schema
+		if !strings.Contains(opts, "omitempty") && !strings.Contains(opts, "omitzero") {
+			*required = append(*required, name)
+		}
+	}
+	return nil
+}
+
+// schemaName turns Page[pkg.Template] into TemplatePage.
+func schemaName(t reflect.Type) string {
+	name := t.Name()
+	base, arg, ok := strings.Cut(name, "[")
+	if !ok {
+		return name
+	}
+	arg = strings.TrimSuffix(arg, "]")
+	if i := strings.LastIndexAny(arg, "./"); i >= 0 {
+		arg = arg[i+1:]
+	}
+	return arg + base
+}
+
+func nullable(s map[string]any) map[string]any {
+	if kind, ok := s["type"].(string); ok {
+		clone := map[string]any{}
+		for k, v := range s {
+			clone[k] = v
+		}
+		clone["type"] = []string{kind, "null"}
+		return clone
+	}
+	return map[string]any{"oneOf": []any{s, map[string]any{"type": "null"}}}
+}
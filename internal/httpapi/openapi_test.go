@@ -0,0 +1,393 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// servedSpec fetches the OpenAPI document as clients see it.
+func servedSpec(t *testing.T) map[string]any {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	serveOpenAPI(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json: status %d: %s", rec.Code, rec.Body)
+	}
+	var spec map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &spec); err != nil {
+		t.Fatalf("decode OpenAPI document: %v", err)
+	}
+	return spec
+}
+
+// apiOperations lists every operation the API serves. It is kept by hand,
+// apart from the route table, so that routes missing from the document or
+// added by accident fail the test.
+var apiOperations = []string{
+	"GET /openapi.json",
+	"POST /auth/login",
+	"POST /auth/refresh",
+	"POST /auth/logout",
+	"GET /auth/me",
+	"POST /invitations/accept",
+	"GET /tenant/usage",
+	"GET /templates",
+	"POST /templates",
+	"GET /templates/{templateID}",
+	"PUT /templates/{templateID}",
+	"PATCH /templates/{templateID}",
+	"DELETE /templates/{templateID}",
+	"POST /templates/{templateID}/restore",
+	"POST /templates/{templateID}/duplicate",
+	"GET /templates/{templateID}/versions",
+	"GET /templates/{templateID}/history",
+	"GET /templates/{templateID}/translations",
+	"GET /templates/{templateID}/versions/compare",
+	"GET /templates/{templateID}/versions/{version}",
+	"DELETE /templates/{templateID}/versions/{version}",
+	"PUT /templates/{templateID}/versions/{version}/pin",
+	"DELETE /templates/{templateID}/versions/{version}/pin",
+	"PUT /templates/{templateID}/versions/{version}/labels/{label}",
+	"DELETE /templates/{templateID}/versions/{version}/labels/{label}",
+	"POST /templates/{templateID}/versions/{version}/restore",
+	"POST /templates/{templateID}/versions/{version}/publish",
+	"POST /templates/bulk/delete",
+	"POST /templates/bulk/export",
+	"POST /templates/bulk/duplicate",
+	"GET /assets",
+	"POST /assets",
+	"GET /assets/{assetID}",
+	"GET /assets/{assetID}/content",
+	"DELETE /assets/{assetID}",
+	"POST /templates/{templateID}/documents",
+	"POST /templates/{templateID}/documents/batch",
+	"GET /batches/{batchID}",
+	"GET /batches/{batchID}/errors",
+	"GET /batches/{batchID}/result",
+	"GET /documents",
+	"GET /documents/{documentID}",
+	"DELETE /documents/{documentID}",
+	"POST /documents/{documentID}/restore",
+	"POST /documents/{documentID}/regenerate",
+	"POST /documents/{documentID}/revoke",
+	"GET /verify/{documentID}",
+	"GET /documents/{documentID}/files/{format}",
+	"GET /certificates",
+	"POST /certificates",
+	"GET /certificates/{certificateID}",
+	"DELETE /certificates/{certificateID}",
+	"POST /signatures/validate",
+	"GET /users",
+	"POST /users/invitations",
+	"GET /users/{userID}",
+	"PUT /users/{userID}",
+	"PUT /users/{userID}/role",
+	"POST /users/{userID}/deactivate",
+	"POST /users/{userID}/reactivate",
+	"GET /api-keys",
+	"POST /api-keys",
+	"GET /api-keys/{keyID}",
+	"DELETE /api-keys/{keyID}",
+	"GET /admin/tenants",
+	"POST /admin/tenants",
+	"GET /admin/tenants/{tenantID}",
+	"PUT /admin/tenants/{tenantID}",
+	"POST /admin/tenants/{tenantID}/suspend",
+	"POST /admin/tenants/{tenantID}/reactivate",
+	"POST /admin/tenants/{tenantID}/owner-invitation",
+	"GET /admin/tenants/{tenantID}/rate-limits",
+	"PUT /admin/tenants/{tenantID}/rate-limits",
+	"GET /admin/tenants/{tenantID}/version-retention",
+	"PUT /admin/tenants/{tenantID}/version-retention",
+	"POST /admin/tenants/{tenantID}/version-retention/prune",
+	"GET /admin/auth/keys",
+	"POST /admin/auth/keys/rotate",
+}
+
+func TestOpenAPIDocumentsEveryRoute(t *testing.T) {
+	spec := servedSpec(t)
+	paths, _ := spec["paths"].(map[string]any)
+	var documented []string
+	for path, item := range paths {
+		for method := range item.(map[string]any) {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+	for _, op := range apiOperations {
+		if !slices.Contains(documented, op) {
+			t.Errorf("%s is not documented", op)
+		}
+	}
+	for _, op := range documented {
+		if !slices.Contains(apiOperations, op) {
+			t.Errorf("%s is documented but not a known operation", op)
+		}
+	}
+	for _, rt := range routes(Handlers{}) {
+		item, _ := paths[rt.path].(map[string]any)
+		op, ok := item[strings.ToLower(rt.method)].(map[string]any)
+		if !ok {
+			continue
+		}
+		if op["operationId"] != rt.id {
+			t.Errorf("%s %s documented as %v, want %s", rt.method, rt.path, op["operationId"], rt.id)
+		}
+		if _, ok := op["responses"].(map[string]any)[strconv.Itoa(rt.status)]; !ok {
+			t.Errorf("%s %s does not document status %d", rt.method, rt.path, rt.status)
+		}
+		if (rt.request != nil) != (op["requestBody"] != nil) {
+			t.Errorf("%s %s request body documented: %t, want %t", rt.method, rt.path, op["requestBody"] != nil, rt.request != nil)
+		}
+	}
+}
+
+func TestOpenAPISchemasMatchJSONEncoding(t *testing.T) {
+	spec := servedSpec(t)
+	schemas := spec["components"].(map[string]any)["schemas"].(map[string]any)
+	types := map[reflect.Type]bool{}
+	for _, rt := range routes(Handlers{}) {
+		collectBodyTypes(rt.request, types)
+		collectBodyTypes(rt.response, types)
+	}
+	collectTypes(reflect.TypeFor[Problem](), types)
+	if len(types) == 0 {
+		t.Fatal("route table has no structs")
+	}
+	for typ := range types {
+		name := schemaName(typ)
+		schema, ok := schemas[name].(map[string]any)
+		if !ok {
+			t.Errorf("%s has no schema %s", typ, name)
+			continue
+		}
+		documented := keys(schema["properties"].(map[string]any))
+		if encoded := encodedKeys(t, filled(typ)); !slices.Equal(documented, encoded) {
+			t.Errorf("%s: schema properties %v, JSON encoding has %v", name, documented, encoded)
+		}
+		zero := encodedKeys(t, reflect.New(typ).Elem())
+		for _, field := range schema["required"].([]any) {
+			if !slices.Contains(zero, field.(string)) {
+				t.Errorf("%s: required property %s is omitted from zero value", name, field)
+			}
+		}
+		for _, field := range zero {
+			if !slices.Contains(schema["required"].([]any), any(field)) && !optionalStruct(typ, field) {
+				t.Errorf("%s: property %s is always encoded but not required", name, field)
+			}
+		}
+	}
+}
+
+func TestOpenAPIEnumsMatchConstants(t *testing.T) {
+	for typ, values := range enums {
+		constants := declaredConstants(t, typ)
+		if len(constants) == 0 {
+			t.Errorf("%s: no constants found", typ)
+			continue
+		}
+		documented := slices.Clone(values)
+		slices.Sort(documented)
+		if !slices.Equal(documented, constants) {
+			t.Errorf("%s: enum %v, constants %v", typ, documented, constants)
+		}
+	}
+}
+
+// collectBodyTypes adds struct types of a route body, including every
+// variant and media type, to types.
+func collectBodyTypes(body any, types map[reflect.Type]bool) {
+	switch b := body.(type) {
+	case nil:
+	case mediaTypes:
+		for _, v := range b {
+			collectBodyTypes(v, types)
+		}
+	case oneOf:
+		for _, v := range b {
+			collectBodyTypes(v, types)
+		}
+	default:
+		collectTypes(reflect.TypeOf(body), types)
+	}
+}
+
+// collectTypes adds named structs reachable from typ to types.
+func collectTypes(typ reflect.Type, types map[reflect.Type]bool) {
+	switch typ.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		collectTypes(typ.Elem(), types)
+	case reflect.Struct:
+		if typ == reflect.TypeFor[time.Time]() || typ == reflect.TypeFor[binary]() || types[typ] {
+			return
+		}
+		if typ.Name() != "" {
+			types[typ] = true
+		}
+		for i := 0; i < typ.NumField(); i++ {
+			if f := typ.Field(i); f.IsExported() || f.Anonymous {
+				collectTypes(f.Type, types)
+			}
+		}
+	}
+}
+
+// filled returns value of typ with every field set to a non-zero value, so
+// that encoding/json emits all of them.
+func filled(typ reflect.Type) reflect.Value {
+	v := reflect.New(typ).Elem()
+	fill(v, 0)
+	return v
+}
+
+func fill(v reflect.Value, depth int) {
+	if depth > 8 || !v.CanSet() {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Pointer:
+		v.Set(reflect.New(v.Type().Elem()))
+		fill(v.Elem(), depth+1)
+	case reflect.Struct:
+		if v.Type() == reflect.TypeFor[time.Time]() {
+			v.Set(reflect.ValueOf(time.Unix(1, 0).UTC()))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			fill(v.Field(i), depth+1)
+		}
+	case reflect.Slice:
+		switch {
+		case v.Type() == reflect.TypeFor[json.RawMessage]():
+			v.SetBytes([]byte("{}"))
+		case v.Type().Elem().Kind() == reflect.Uint8:
+			v.SetBytes([]byte{1})
+		default:
+			s := reflect.MakeSlice(v.Type(), 1, 1)
+			fill(s.Index(0), depth+1)
+			v.Set(s)
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fill(v.Index(i), depth+1)
+		}
+	case reflect.Map:
+		m := reflect.MakeMap(v.Type())
+		key, elem := reflect.New(v.Type().Key()).Elem(), reflect.New(v.Type().Elem()).Elem()
+		fill(key, depth+1)
+		fill(elem, depth+1)
+		m.SetMapIndex(key, elem)
+		v.Set(m)
+	case reflect.Interface:
+		if v.NumMethod() == 0 {
+			v.Set(reflect.ValueOf("x"))
+		}
+	case reflect.String:
+		v.SetString("x")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1)
+	}
+}
+
+// encodedKeys returns sorted object keys of v encoded by encoding/json.
+func encodedKeys(t *testing.T, v reflect.Value) []string {
+	t.Helper()
+	raw, err := json.Marshal(v.Interface())
+	if err != nil {
+		t.Fatalf("encode %s: %v", v.Type(), err)
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		t.Fatalf("%s does not encode as object: %s", v.Type(), raw)
+	}
+	return keys(obj)
+}
+
+// optionalStruct reports whether field of typ is a struct tagged omitempty,
+// which encoding/json always emits although the schema marks it optional.
+func optionalStruct(typ reflect.Type, name string) bool {
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tag, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if tag == name {
+			return f.Type.Kind() == reflect.Struct && strings.Contains(opts, "omitempty")
+		}
+	}
+	return false
+}
+
+func keys(m map[string]any) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	slices.Sort(result)
+	return result
+}
+
+// declaredConstants returns sorted values of string constants of typ
+// declared in the sources of its package.
+func declaredConstants(t *testing.T, typ reflect.Type) []string {
+	t.Helper()
+	const module = "github.com/lumiforge/docfactory-backend/"
+	dir, ok := strings.CutPrefix(typ.PkgPath(), module)
+	if !ok {
+		t.Fatalf("%s is not declared in this module", typ)
+	}
+	fset := token.NewFileSet()
+	files, err := filepath.Glob(filepath.Join("..", "..", dir, "*.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var values []string
+	for _, path := range files {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			t.Fatalf("parse %s: %v", path, err)
+		}
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.CONST {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				vs := spec.(*ast.ValueSpec)
+				if ident, ok := vs.Type.(*ast.Ident); !ok || ident.Name != typ.Name() {
+					continue
+				}
+				for _, value := range vs.Values {
+					lit, ok := value.(*ast.BasicLit)
+					if !ok || lit.Kind != token.STRING {
+						continue
+					}
+					s, err := strconv.Unquote(lit.Value)
+					if err != nil {
+						t.Fatalf("%s: %v", path, err)
+					}
+					values = append(values, s)
+				}
+			}
+		}
+	}
+	slices.Sort(values)
+	return values
+}
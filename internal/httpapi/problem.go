@@ -0,0 +1,236 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/lumiforge/docfactory-backend/internal/apikeys"
+	"github.com/lumiforge/docfactory-backend/internal/assets"
+	"github.com/lumiforge/docfactory-backend/internal/auth"
+	"github.com/lumiforge/docfactory-backend/internal/certificates"
+	"github.com/lumiforge/docfactory-backend/internal/documents"
+	"github.com/lumiforge/docfactory-backend/internal/idempotency"
+	"github.com/lumiforge/docfactory-backend/internal/jsonpatch"
+	"github.com/lumiforge/docfactory-backend/internal/quotas"
+	"github.com/lumiforge/docfactory-backend/internal/render"
+	"github.com/lumiforge/docfactory-backend/internal/templates"
+	"github.com/lumiforge/docfactory-backend/internal/tenants"
+	"github.com/lumiforge/docfactory-backend/internal/users"
+	"github.com/lumiforge/docfactory-backend/internal/validation"
+)
+
+const (
+	problemContentType = "application/problem+json"
+	// problemTypePrefix prefixes stable error codes to form problem type URIs.
+	problemTypePrefix = "urn:docfactory:problem:"
+)
+
+// Problem is an RFC 9457 problem details response. Code repeats the last
+// segment of Type for clients that switch on plain strings; Fields lists
+// every invalid input field of validation failures.
+type Problem struct {
+	Type      string                  `json:"type"`
+	Title     string                  `json:"title"`
+	Status    int                     `json:"status"`
+	Detail    string                  `json:"detail,omitempty"`
+	Instance  string                  `json:"instance,omitempty"`
+	Code      string                  `json:"code"`
+	RequestID string                  `json:"request_id,omitempty"`
+	Fields    []validation.FieldError `json:"fields,omitempty"`
+}
+
+// problemType is a class of errors sharing status, code and title.
+type problemType struct {
+	status int
+	code   string
+	title  string
+}
+
+// error returns error of this type with given detail.
+func (p problemType) error(detail string) error {
+	return &apiError{problemType: p, detail: detail}
+}
+
+// apiError is an error raised by the HTTP layer itself, such as failed
+// authentication or a malformed body.
+type apiError struct {
+	problemType
+	detail string
+}
+
+func (e *apiError) Error() string {
+	return e.detail
+}
+
+var (
+	problemBadRequest           = problemType{http.StatusBadRequest, "bad_request", "Bad request"}
+	problemValidation           = problemType{http.StatusBadRequest, "validation_failed", "Validation failed"}
+	problemUnauthenticated      = problemType{http.StatusUnauthorized, "unauthenticated", "Authentication required"}
+	problemForbidden            = problemType{http.StatusForbidden, "forbidden", "Forbidden"}
+	problemNotFound             = problemType{http.StatusNotFound, "not_found", "Not found"}
+	problemMethodNotAllowed     = problemType{http.StatusMethodNotAllowed, "method_not_allowed", "Method not allowed"}
+	problemPayloadTooLarge      = problemType{http.StatusRequestEntityTooLarge, "payload_too_large", "Payload too large"}
+	problemUnsupportedMediaType = problemType{http.StatusUnsupportedMediaType, "unsupported_media_type", "Unsupported media type"}
+	problemRateLimited          = problemType{http.StatusTooManyRequests, "rate_limited", "Rate limit exceeded"}
+	problemInternal             = problemType{http.StatusInternalServerError, "internal_error", "Internal server error"}
+)
+
+// templateKinds maps template error kinds to statuses and titles; codes come
+// from the errors themselves.
+var templateKinds = map[templates.Kind]problemType{
+	templates.KindNotFound:     {status: http.StatusNotFound, title: "Not found"},
+	templates.KindConflict:     {status: http.StatusConflict, title: "Conflict"},
+	templates.KindInvalidInput: {status: http.StatusBadRequest, title: "Invalid input"},
+	templates.KindGone:         {status: http.StatusGone, title: "Gone"},
+}
+
+// domainErrors maps sentinel errors of the other domain packages. Entries are
+// checked in order.
+var domainErrors = []struct {
+	err     error
+	problem problemType
+}{
+	{idempotency.ErrInvalidKey, problemType{http.StatusBadRequest, "invalid_idempotency_key", "Invalid idempotency key"}},
+	{idempotency.ErrInProgress, problemType{http.StatusConflict, "idempotency_in_progress", "Request in progress"}},
+	{idempotency.ErrMismatch, problemType{http.StatusUnprocessableEntity, "idempotency_key_reused", "Idempotency key reused"}},
+
+	{jsonpatch.ErrInvalidPatch, problemType{http.StatusBadRequest, "invalid_patch", "Invalid patch"}},
+	{jsonpatch.ErrConflict, problemType{http.StatusConflict, "patch_conflict", "Patch does not apply"}},
+
+	{quotas.ErrLimitExceeded, problemType{http.StatusPaymentRequired, "plan_limit_exceeded", "Plan limit exceeded"}},
+	{quotas.ErrRateExceeded, problemType{http.StatusTooManyRequests, "monthly_allowance_exhausted", "Monthly allowance exhausted"}},
+
+	{tenants.ErrNotFound, problemType{http.StatusNotFound, "tenant_not_found", "Not found"}},
+	{tenants.ErrConflict, problemType{http.StatusConflict, "tenant_conflict", "Conflict"}},
+	{tenants.ErrInvalidInput, problemType{http.StatusBadRequest, "invalid_input", "Invalid input"}},
+	{tenants.ErrInactive, problemType{http.StatusForbidden, "tenant_inactive", "Tenant is suspended"}},
+
+	{users.ErrNotFound, problemType{http.StatusNotFound, "user_not_found", "Not found"}},
+	{users.ErrConflict, problemType{http.StatusConflict, "user_conflict", "Conflict"}},
+	{users.ErrInvalidInput, problemType{http.StatusBadRequest, "invalid_input", "Invalid input"}},
+	{users.ErrForbidden, problemType{http.StatusForbidden, "forbidden", "Forbidden"}},
+	{users.ErrInvalidToken, problemType{http.StatusUnauthorized, "invalid_invitation", "Invalid invitation"}},
+	{users.ErrInactive, problemType{http.StatusForbidden, "user_inactive", "User is deactivated"}},
+
+	{apikeys.ErrNotFound, problemType{http.StatusNotFound, "api_key_not_found", "Not found"}},
+	{apikeys.ErrInvalidInput, problemType{http.StatusBadRequest, "invalid_input", "Invalid input"}},
+	{apikeys.ErrForbidden, problemType{http.StatusForbidden, "forbidden", "Forbidden"}},
+	{apikeys.ErrInvalidKey, problemType{http.StatusUnauthorized, "unauthenticated", "Authentication required"}},
+
+	{assets.ErrNotFound, problemType{http.StatusNotFound, "asset_not_found", "Not found"}},
+	{assets.ErrInvalidInput, problemType{http.StatusBadRequest, "invalid_input", "Invalid input"}},
+
+	{documents.ErrNotFound, problemType{http.StatusNotFound, "document_not_found", "Not found"}},
+	{documents.ErrDeleted, problemType{http.StatusConflict, "document_deleted", "Document is deleted"}},
+	{documents.ErrRevoked, problemType{http.StatusConflict, "document_revoked", "Document is revoked"}},
+	{documents.ErrFileNotFound, problemType{http.StatusNotFound, "document_file_not_found", "Not found"}},
+	{documents.ErrInvalidInput, problemType{http.StatusBadRequest, "invalid_input", "Invalid input"}},
+	{documents.ErrBatchNotFound, problemType{http.StatusNotFound, "batch_not_found", "Not found"}},
+	{documents.ErrBatchPending, problemType{http.StatusConflict, "batch_pending", "Batch has not finished"}},
+	{certificates.ErrNotFound, problemType{http.StatusNotFound, "certificate_not_found", "Not found"}},
+	{certificates.ErrConflict, problemType{http.StatusConflict, "certificate_conflict", "Certificate already uploaded"}},
+	{certificates.ErrExpired, problemType{http.StatusConflict, "certificate_expired", "Certificate is not valid"}},
+	{certificates.ErrInvalidInput, problemType{http.StatusBadRequest, "invalid_input", "Invalid input"}},
+	{render.ErrUnsupportedFormat, problemType{http.StatusBadRequest, "unsupported_format", "Unsupported format"}},
+
+	{auth.ErrInvalidCredentials, problemType{http.StatusUnauthorized, "invalid_credentials", "Invalid credentials"}},
+	{auth.ErrInvalidToken, problemType{http.StatusUnauthorized, "invalid_token", "Invalid token"}},
+	{auth.ErrTokenReuse, problemType{http.StatusUnauthorized, "token_reused", "Refresh token reused"}},
+	{auth.ErrLocked, problemType{http.StatusTooManyRequests, "account_locked", "Account temporarily locked"}},
+	{auth.ErrNotFound, problemType{http.StatusNotFound, "not_found", "Not found"}},
+}
+
+// classify is the single mapping of errors to problem types.
+func classify(err error) problemType {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr.problemType
+	}
+	var fields validation.Errors
+	if errors.As(err, &fields) {
+		return problemValidation
+	}
+	var tplErr *templates.Error
+	if errors.As(err, &tplErr) {
+		p := templateKinds[tplErr.Kind]
+		p.code = tplErr.Code
+		return p
+	}
+	for _, entry := range domainErrors {
+		if errors.Is(err, entry.err) {
+			return entry.problem
+		}
+	}
+	return problemInternal
+}
+
+// writeError writes err as problem details. Details of internal errors are
+// logged with the request ID instead of being returned to clients.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	p := classify(err)
+	problem := Problem{
+		Type:      problemTypePrefix + p.code,
+		Title:     p.title,
+		Status:    p.status,
+		Detail:    err.Error(),
+		Instance:  r.URL.Path,
+		Code:      p.code,
+		RequestID: requestIDFrom(r),
+	}
+	var fields validation.Errors
+	if errors.As(err, &fields) {
+		problem.Fields = fields
+	}
+	if p.status == http.StatusInternalServerError {
+		log.Printf("request %s: %s %s: %v", problem.RequestID, r.Method, r.URL.Path, err)
+		problem.Detail = ""
+	}
+	w.Header().Set("Content-Type", problemContentType)
+	w.WriteHeader(p.status)
+	_ = json.NewEncoder(w).Encode(problem)
+}
+
+// problemFallback serves problem details for paths and methods that mux does
+// not route; other responses pass through untouched.
+func problemFallback(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler, pattern := mux.Handler(r)
+		if pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+		rec := &statusRecorder{header: http.Header{}}
+		handler.ServeHTTP(rec, r)
+		switch rec.status {
+		case http.StatusNotFound:
+			writeError(w, r, problemNotFound.error("no route for "+r.URL.Path))
+		case http.StatusMethodNotAllowed:
+			w.Header().Set("Allow", rec.header.Get("Allow"))
+			writeError(w, r, problemMethodNotAllowed.error(r.Method+" is not allowed on "+r.URL.Path))
+		default:
+			for k, v := range rec.header {
+				w.Header()[k] = v
+			}
+			w.WriteHeader(rec.status)
+		}
+	})
+}
+
+// statusRecorder captures the status and headers of mux fallback handlers.
+type statusRecorder struct {
+	header http.Header
+	status int
+}
+
+func (r *statusRecorder) Header() http.Header { return r.header }
+
+func (r *statusRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return len(b), nil
+}
+
+func (r *statusRecorder) WriteHeader(status int) { r.status = status }
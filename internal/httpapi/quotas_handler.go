@@ -0,0 +1,32 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"github.com/lumiforge/docfactory-backend/internal/quotas"
+)
+
+// QuotaHandler exposes tenant plan usage.
+type QuotaHandler struct {
+	service *quotas.QuotaService
+}
+
+// NewQuotaHandler creates HTTP handler.
+func NewQuotaHandler(service *quotas.QuotaService) *QuotaHandler {
+	return &QuotaHandler{service: service}
+}
+
+// Usage handles GET /tenant/usage.
+func (h *QuotaHandler) Usage(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	report, err := h.service.Usage(r.Context(), tenantID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
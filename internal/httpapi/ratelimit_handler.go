@@ -0,0 +1,33 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"github.com/lumiforge/docfactory-backend/internal/ratelimit"
+)
+
+// RateLimitHandler exposes per-tenant rate limit configuration to admins.
+type RateLimitHandler struct {
+	limiter *ratelimit.Limiter
+}
+
+// NewRateLimitHandler creates HTTP handler.
+func NewRateLimitHandler(limiter *ratelimit.Limiter) *RateLimitHandler {
+	return &RateLimitHandler{limiter: limiter}
+}
+
+// GetTenantLimits handles GET /admin/tenants/{id}/rate-limits.
+func (h *RateLimitHandler) GetTenantLimits(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.limiter.TenantLimits(pathParam(r, "tenantID")))
+}
+
+// SetTenantLimits handles PUT /admin/tenants/{id}/rate-limits.
+func (h *RateLimitHandler) SetTenantLimits(w http.ResponseWriter, r *http.Request) {
+	var payload ratelimit.TenantLimits
+	if !readPayload(w, r, &payload) {
+		return
+	}
+	tenantID := pathParam(r, "tenantID")
+	h.limiter.SetTenantLimits(tenantID, payload)
+	writeJSON(w, http.StatusOK, h.limiter.TenantLimits(tenantID))
+}
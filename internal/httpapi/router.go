@@ -2,122 +2,663 @@ package httpapi
 
 import (
 	"net/http"
-	"strings"
+
+	"github.com/lumiforge/docfactory-backend/internal/apikeys"
+	"github.com/lumiforge/docfactory-backend/internal/assets"
+	"github.com/lumiforge/docfactory-backend/internal/auth"
+	"github.com/lumiforge/docfactory-backend/internal/certificates"
+	"github.com/lumiforge/docfactory-backend/internal/documents"
+	"github.com/lumiforge/docfactory-backend/internal/idempotency"
+	"github.com/lumiforge/docfactory-backend/internal/jsonpatch"
+	"github.com/lumiforge/docfactory-backend/internal/quotas"
+	"github.com/lumiforge/docfactory-backend/internal/ratelimit"
+	"github.com/lumiforge/docfactory-backend/internal/render"
+	"github.com/lumiforge/docfactory-backend/internal/templates"
+	"github.com/lumiforge/docfactory-backend/internal/tenants"
+	"github.com/lumiforge/docfactory-backend/internal/users"
 )
 
-// Router builds HTTP handler using net/http without external deps.
-func Router(handler *TemplateHandler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		path := strings.Trim(r.URL.Path, "/")
-		if path == "" {
-			http.NotFound(w, r)
-			return
-		}
-		segments := strings.Split(path, "/")
-		if segments[0] != "templates" {
-			http.NotFound(w, r)
-			return
-		}
-		switch {
-		case len(segments) == 1:
-			handleTemplatesCollection(handler, w, r)
-		case len(segments) >= 2:
-			if segments[1] == "bulk" {
-				handleTemplatesBulk(handler, w, r, segments[2:])
-				return
-			}
-			ctx := withPathParam(r.Context(), "templateID", segments[1])
-			if len(segments) == 2 {
-				handlerTemplate(handler, w, r.WithContext(ctx))
-				return
-			}
-			switch segments[2] {
-			case "restore":
-				handler.RestoreTemplate(w, r.WithContext(ctx))
-			case "duplicate":
-				handler.DuplicateTemplate(w, r.WithContext(ctx))
-			case "versions":
-				handleVersions(handler, w, r.WithContext(ctx), segments[3:])
-			default:
-				http.NotFound(w, r)
-			}
-		default:
-			http.NotFound(w, r)
-		}
-	})
+// Handlers groups HTTP handlers and settings served by Router.
+type Handlers struct {
+	Templates    *TemplateHandler
+	Assets       *AssetHandler
+	Documents    *DocumentHandler
+	Certificates *CertificateHandler
+	Tenants      *TenantHandler
+	Users        *UserHandler
+	Auth         *AuthHandler
+	APIKeys      *APIKeyHandler
+	Quotas       *QuotaHandler
+	RateLimits   *RateLimitHandler
+	// PublicLimits limits public routes per client address, such as
+	// sign-in and document verification.
+	PublicLimits *ratelimit.Limiter
+	Idempotency  *idempotency.Service
+	AdminToken   string
 }
 
-func handleTemplatesCollection(handler *TemplateHandler, w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		handler.ListTemplates(w, r)
-	case http.MethodPost:
-		handler.CreateTemplate(w, r)
-	default:
-		methodNotAllowed(w)
-	}
+// access selects middleware guarding a route.
+type access int
+
+const (
+	// accessPublic routes need no credentials.
+	accessPublic access = iota
+	// accessPublicLimited routes need no credentials and are rate limited
+	// per client address.
+	accessPublicLimited
+	// accessTenant routes accept any principal of an active tenant.
+	accessTenant
+	// accessTemplates routes additionally require templates scopes.
+	accessTemplates
+	// accessDocuments routes additionally require documents scopes.
+	accessDocuments
+	// accessUserSession routes accept signed-in users but not API keys.
+	accessUserSession
+	// accessAdmin routes require the operator admin token.
+	accessAdmin
+)
+
+// route is a single endpoint. The same table registers handlers in Router and
+// generates the OpenAPI document, so the two cannot drift apart.
+type route struct {
+	method   string
+	path     string
+	access   access
+	handler  http.HandlerFunc
+	id       string
+	tag      string
+	summary  string
+	params   []param
+	request  any
+	status   int
+	response any
+	// secret routes respond with credentials shown only once, which must not
+	// be kept for replay.
+	secret bool
+	// class overrides the rate limit class, which is read for GET routes and
+	// write otherwise.
+	class ratelimit.Class
 }
 
-func handlerTemplate(handler *TemplateHandler, w http.ResponseWriter, r *http.Request) {
-	switch r.Method {
-	case http.MethodGet:
-		handler.GetTemplate(w, r)
-	case http.MethodPut:
-		handler.UpdateTemplate(w, r)
-	case http.MethodDelete:
-		handler.DeleteTemplate(w, r)
+// rateClass returns rate limit class of route.
+func (rt route) rateClass() ratelimit.Class {
+	switch {
+	case rt.class != "":
+		return rt.class
+	case rt.method == http.MethodGet:
+		return ratelimit.ClassRead
 	default:
-		methodNotAllowed(w)
+		return ratelimit.ClassWrite
 	}
 }
 
-func handleTemplatesBulk(handler *TemplateHandler, w http.ResponseWriter, r *http.Request, segments []string) {
-	if len(segments) == 0 {
-		http.NotFound(w, r)
-		return
-	}
-	if r.Method != http.MethodPost {
-		methodNotAllowed(w)
-		return
-	}
-	switch segments[0] {
-	case "delete":
-		handler.BulkDelete(w, r)
-	case "export":
-		handler.BulkExport(w, r)
-	case "duplicate":
-		handler.BulkDuplicate(w, r)
-	default:
-		http.NotFound(w, r)
+// idempotent reports whether route honours Idempotency-Key. Public routes are
+// excluded because replaying them would hand out session cookies, and secret
+// routes because stored responses would keep their credentials in plaintext.
+func (rt route) idempotent() bool {
+	return rt.method != http.MethodGet && rt.access != accessPublic && rt.access != accessPublicLimited && !rt.secret
+}
+
+// param documents a path, query or header parameter.
+type param struct {
+	name        string
+	in          string
+	kind        string
+	description string
+}
+
+func query(name, kind, description string) param {
+	return param{name: name, in: "query", kind: kind, description: description}
+}
+
+// mediaTypes documents a body accepted or returned in several media types,
+// keyed by content type.
+type mediaTypes map[string]any
+
+// binary documents a raw body such as an image or a rendered file.
+type binary struct{}
+
+// documentFiles documents downloads of rendered files in every format.
+func documentFiles() mediaTypes {
+	files := mediaTypes{}
+	for _, format := range render.Formats() {
+		files[render.ContentType(format)] = binary{}
 	}
+	return files
 }
 
-func handleVersions(handler *TemplateHandler, w http.ResponseWriter, r *http.Request, segments []string) {
-	if len(segments) == 0 {
-		if r.Method == http.MethodGet {
-			handler.ListVersions(w, r)
-			return
-		}
-		methodNotAllowed(w)
-		return
+// oneOf documents a response that may have any of the given shapes.
+type oneOf []any
+
+// versionRef documents {version} wildcards that accept labels.
+var versionRef = param{name: "version", in: "path", kind: "string", description: "Version number or label."}
+
+var paginationParams = []param{
+	query("limit", "integer", "Maximum number of items to return."),
+	query("offset", "integer", "Number of items to skip."),
+}
+
+// Router builds HTTP handler using net/http without external deps. It panics
+// when the route table cannot be documented, which keeps every registered
+// route in the OpenAPI document.
+func Router(h Handlers) http.Handler {
+	table := routes(h)
+	if _, err := openAPISpec(table); err != nil {
+		panic(err)
 	}
-	switch segments[0] {
-	case "compare":
-		if r.Method != http.MethodGet {
-			methodNotAllowed(w)
-			return
-		}
-		handler.CompareVersions(w, r)
-	default:
-		if len(segments) == 2 && segments[1] == "restore" && r.Method == http.MethodPost {
-			ctx := withPathParam(r.Context(), "version", segments[0])
-			handler.RestoreVersion(w, r.WithContext(ctx))
-			return
+	authn := authenticator{auth: h.Auth.service, users: h.Users.service, keys: h.APIKeys.service}
+	tenantScoped := func(next http.Handler) http.Handler {
+		return authn.middleware(requireActiveTenant(h.Tenants.service, rateLimit(h.RateLimits.limiter, next)))
+	}
+	guards := map[access]func(http.Handler) http.Handler{
+		accessPublic: func(next http.Handler) http.Handler { return next },
+		accessPublicLimited: func(next http.Handler) http.Handler {
+			return publicRateLimit(h.PublicLimits, next)
+		},
+		accessTenant: tenantScoped,
+		accessTemplates: func(next http.Handler) http.Handler {
+			return tenantScoped(requireAccess(apikeys.ScopeTemplatesRead, apikeys.ScopeTemplatesWrite, next))
+		},
+		accessDocuments: func(next http.Handler) http.Handler {
+			return tenantScoped(requireAccess(apikeys.ScopeDocumentsRead, apikeys.ScopeDocumentsWrite, next))
+		},
+		accessUserSession: func(next http.Handler) http.Handler {
+			return tenantScoped(requireUserSession(next))
+		},
+		accessAdmin: func(next http.Handler) http.Handler {
+			return requireAdmin(h.AdminToken, next)
+		},
+	}
+	mux := http.NewServeMux()
+	for _, rt := range table {
+		handler := http.Handler(rt.handler)
+		if rt.idempotent() {
+			handler = idempotent(h.Idempotency, handler)
 		}
-		http.NotFound(w, r)
+		mux.Handle(rt.method+" "+rt.path, withRouteClass(rt.rateClass(), guards[rt.access](handler)))
 	}
+	return withRequestID(problemFallback(mux))
 }
 
-func methodNotAllowed(w http.ResponseWriter) {
-	http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+// routes lists every endpoint of the API. Handlers may be nil when the table
+// is only used for documentation.
+func routes(h Handlers) []route {
+	return []route{
+		{
+			method: http.MethodGet, path: "/openapi.json", access: accessPublic, handler: serveOpenAPI,
+			id: "getOpenAPI", tag: "meta", summary: "OpenAPI document of this API",
+			status: http.StatusOK, response: map[string]any{},
+		},
+
+		// Authentication.
+		{
+			method: http.MethodPost, path: "/auth/login", access: accessPublicLimited, handler: h.Auth.Login,
+			id: "login", tag: "auth", summary: "Sign in with email and password; sets refresh token cookie",
+			request: LoginPayload{}, status: http.StatusOK, response: auth.Session{},
+		},
+		{
+			method: http.MethodPost, path: "/auth/refresh", access: accessPublicLimited, handler: h.Auth.Refresh,
+			id: "refreshSession", tag: "auth", summary: "Rotate refresh token cookie and issue new access token",
+			status: http.StatusOK, response: auth.Session{},
+		},
+		{
+			method: http.MethodPost, path: "/auth/logout", access: accessPublic, handler: h.Auth.Logout,
+			id: "logout", tag: "auth", summary: "Revoke refresh token family and clear cookie",
+			status: http.StatusNoContent,
+		},
+		{
+			method: http.MethodGet, path: "/auth/me", access: accessTenant, handler: h.Auth.Me,
+			id: "getCurrentPrincipal", tag: "auth", summary: "Current user, or key metadata for API keys",
+			status: http.StatusOK, response: oneOf{users.User{}, apikeys.APIKey{}},
+		},
+		{
+			method: http.MethodPost, path: "/invitations/accept", access: accessPublicLimited, handler: h.Users.AcceptInvitation,
+			id: "acceptInvitation", tag: "users", summary: "Accept invitation and create user account",
+			request: AcceptInvitationPayload{}, status: http.StatusCreated, response: users.User{},
+		},
+
+		// Tenant.
+		{
+			method: http.MethodGet, path: "/tenant/usage", access: accessTenant, handler: h.Quotas.Usage,
+			id: "getTenantUsage", tag: "tenant", summary: "Usage of current tenant against its plan",
+			status: http.StatusOK, response: quotas.Report{},
+		},
+
+		// Templates.
+		{
+			method: http.MethodGet, path: "/templates", access: accessTemplates, handler: h.Templates.ListTemplates,
+			id: "listTemplates", tag: "templates", summary: "List templates",
+			params: append([]param{
+				query("search", "string", "Case-insensitive name filter."),
+				query("document_type", "string", "Document type filter."),
+				query("include_deleted", "boolean", "Include soft-deleted templates."),
+			}, paginationParams...),
+			status: http.StatusOK, response: Page[templates.Template]{},
+		},
+		{
+			method: http.MethodPost, path: "/templates", access: accessTemplates, handler: h.Templates.CreateTemplate,
+			id: "createTemplate", tag: "templates", summary: "Create template",
+			request: TemplatePayload{}, status: http.StatusCreated, response: templates.Template{},
+		},
+		{
+			method: http.MethodGet, path: "/templates/{templateID}", access: accessTemplates, handler: h.Templates.GetTemplate,
+			id: "getTemplate", tag: "templates", summary: "Get template",
+			status: http.StatusOK, response: templates.Template{},
+		},
+		{
+			method: http.MethodPut, path: "/templates/{templateID}", access: accessTemplates, handler: h.Templates.UpdateTemplate,
+			id: "updateTemplate", tag: "templates", summary: "Update template; schema and layout changes record new version",
+			request: TemplatePayload{}, status: http.StatusOK, response: templates.Template{},
+		},
+		{
+			method: http.MethodPatch, path: "/templates/{templateID}", access: accessTemplates, handler: h.Templates.PatchTemplate,
+			id: "patchTemplate", tag: "templates", summary: "Apply merge patch or JSON Patch to template",
+			params: []param{query("change_summary", "string", "Summary recorded when the patch creates a new version.")},
+			request: mediaTypes{
+				mergePatchContentType: PatchableTemplate{},
+				jsonPatchContentType:  []jsonpatch.Operation{},
+			},
+			status: http.StatusOK, response: templates.Template{},
+		},
+		{
+			method: http.MethodDelete, path: "/templates/{templateID}", access: accessTemplates, handler: h.Templates.DeleteTemplate,
+			id: "deleteTemplate", tag: "templates", summary: "Soft delete template",
+			status: http.StatusNoContent,
+		},
+		{
+			method: http.MethodPost, path: "/templates/{templateID}/restore", access: accessTemplates, handler: h.Templates.RestoreTemplate,
+			id: "restoreTemplate", tag: "templates", summary: "Restore soft-deleted template",
+			status: http.StatusOK, response: templates.Template{},
+		},
+		{
+			method: http.MethodPost, path: "/templates/{templateID}/duplicate", access: accessTemplates, handler: h.Templates.DuplicateTemplate,
+			id: "duplicateTemplate", tag: "templates", summary: "Duplicate template",
+			request: DuplicatePayload{}, status: http.StatusCreated, response: templates.Template{},
+		},
+		{
+			method: http.MethodGet, path: "/templates/{templateID}/versions", access: accessTemplates, handler: h.Templates.ListVersions,
+			id: "listTemplateVersions", tag: "templates", summary: "List template versions, newest first",
+			params: paginationParams,
+			status: http.StatusOK, response: Page[templates.TemplateVersion]{},
+		},
+		{
+			method: http.MethodGet, path: "/templates/{templateID}/history", access: accessTemplates, handler: h.Templates.ListMetadataChanges,
+			id: "listTemplateMetadataChanges", tag: "templates", summary: "List metadata edits such as renames, newest first",
+			status: http.StatusOK, response: ItemList[templates.MetadataChange]{},
+		},
+		{
+			method: http.MethodGet, path: "/templates/{templateID}/translations", access: accessTemplates, handler: h.Templates.TranslationReport,
+			id: "getTemplateTranslationReport", tag: "templates", summary: "Report untranslated text of template per locale",
+			params: []param{query("locale", "string", "Language tag such as kk-KZ; defaults to every translated locale.")},
+			status: http.StatusOK, response: templates.TranslationReport{},
+		},
+		{
+			method: http.MethodGet, path: "/templates/{templateID}/versions/compare", access: accessTemplates, handler: h.Templates.CompareVersions,
+			id: "compareTemplateVersions", tag: "templates", summary: "Compare two template versions",
+			params: []param{
+				query("left", "integer", "Left version number."),
+				query("right", "integer", "Right version number."),
+			},
+			status: http.StatusOK, response: templates.VersionComparison{},
+		},
+		{
+			method: http.MethodGet, path: "/templates/{templateID}/versions/{version}", access: accessTemplates, handler: h.Templates.GetVersion,
+			id: "getTemplateVersion", tag: "templates", summary: "Get template version by number or label",
+			params: []param{versionRef},
+			status: http.StatusOK, response: templates.TemplateVersion{},
+		},
+		{
+			method: http.MethodDelete, path: "/templates/{templateID}/versions/{version}", access: accessTemplates, handler: h.Templates.DeleteVersion,
+			id: "deleteTemplateVersion", tag: "templates", summary: "Delete version unless current, pinned or labelled",
+			params: []param{versionRef},
+			status: http.StatusNoContent,
+		},
+		{
+			method: http.MethodPut, path: "/templates/{templateID}/versions/{version}/pin", access: accessTemplates, handler: h.Templates.PinVersion,
+			id: "pinTemplateVersion", tag: "templates", summary: "Protect version from deletion and pruning",
+			params: []param{versionRef},
+			status: http.StatusOK, response: templates.TemplateVersion{},
+		},
+		{
+			method: http.MethodDelete, path: "/templates/{templateID}/versions/{version}/pin", access: accessTemplates, handler: h.Templates.UnpinVersion,
+			id: "unpinTemplateVersion", tag: "templates", summary: "Remove pin of version",
+			params: []param{versionRef},
+			status: http.StatusOK, response: templates.TemplateVersion{},
+		},
+		{
+			method: http.MethodPut, path: "/templates/{templateID}/versions/{version}/labels/{label}", access: accessTemplates, handler: h.Templates.SetVersionLabel,
+			id: "setTemplateVersionLabel", tag: "templates", summary: "Attach label to version, moving it from another version",
+			params: []param{versionRef},
+			status: http.StatusOK, response: templates.TemplateVersion{},
+		},
+		{
+			method: http.MethodDelete, path: "/templates/{templateID}/versions/{version}/labels/{label}", access: accessTemplates, handler: h.Templates.RemoveVersionLabel,
+			id: "removeTemplateVersionLabel", tag: "templates", summary: "Remove label from version",
+			params: []param{versionRef},
+			status: http.StatusNoContent,
+		},
+		{
+			method: http.MethodPost, path: "/templates/{templateID}/versions/{version}/restore", access: accessTemplates, handler: h.Templates.RestoreVersion,
+			id: "restoreTemplateVersion", tag: "templates", summary: "Restore template to version",
+			params: []param{{name: "version", in: "path", kind: "integer", description: "Version number."}},
+			status: http.StatusOK, response: templates.TemplateVersion{},
+		},
+		{
+			method: http.MethodPost, path: "/templates/{templateID}/versions/{version}/publish", access: accessTemplates, handler: h.Templates.PublishVersion,
+			id: "publishTemplateVersion", tag: "templates", summary: "Mark version as published",
+			params: []param{{name: "version", in: "path", kind: "integer", description: "Version number."}},
+			status: http.StatusOK, response: templates.TemplateVersion{},
+		},
+		{
+			method: http.MethodPost, path: "/templates/bulk/delete", access: accessTemplates, handler: h.Templates.BulkDelete,
+			id: "bulkDeleteTemplates", tag: "templates", summary: "Soft delete several templates",
+			request: BulkIDsPayload{}, status: http.StatusMultiStatus, response: BulkResult{},
+			class: ratelimit.ClassBulk,
+		},
+		{
+			method: http.MethodPost, path: "/templates/bulk/export", access: accessTemplates, handler: h.Templates.BulkExport,
+			id: "bulkExportTemplates", tag: "templates", summary: "Schedule export of several templates",
+			request: BulkIDsPayload{}, status: http.StatusAccepted, response: ExportResult{},
+			class: ratelimit.ClassBulk,
+		},
+		{
+			method: http.MethodPost, path: "/templates/bulk/duplicate", access: accessTemplates, handler: h.Templates.BulkDuplicate,
+			id: "bulkDuplicateTemplates", tag: "templates", summary: "Duplicate several templates",
+			request: BulkDuplicatePayload{}, status: http.StatusMultiStatus, response: BulkResult{},
+			class: ratelimit.ClassBulk,
+		},
+
+		// Assets.
+		{
+			method: http.MethodGet, path: "/assets", access: accessTemplates, handler: h.Assets.ListAssets,
+			id: "listAssets", tag: "assets", summary: "List logos and images of tenant, newest first",
+			params: []param{query("type", "string", "Asset type filter.")},
+			status: http.StatusOK, response: ItemList[assets.Asset]{},
+		},
+		{
+			method: http.MethodPost, path: "/assets", access: accessTemplates, handler: h.Assets.UploadAsset,
+			id: "uploadAsset", tag: "assets", summary: "Upload PNG or JPEG image of at most 1 MiB",
+			params: []param{
+				query("type", "string", "Asset type; defaults to logo."),
+				query("file_name", "string", "Original file name."),
+				query("template_id", "string", "Template the asset belongs to, if any."),
+			},
+			request: mediaTypes{assets.ContentTypePNG: binary{}, assets.ContentTypeJPEG: binary{}},
+			status:  http.StatusCreated, response: assets.Asset{},
+		},
+		{
+			method: http.MethodGet, path: "/assets/{assetID}", access: accessTemplates, handler: h.Assets.GetAsset,
+			id: "getAsset", tag: "assets", summary: "Get asset metadata",
+			status: http.StatusOK, response: assets.Asset{},
+		},
+		{
+			method: http.MethodGet, path: "/assets/{assetID}/content", access: accessTemplates, handler: h.Assets.GetAssetContent,
+			id: "getAssetContent", tag: "assets", summary: "Download asset image",
+			status: http.StatusOK, response: mediaTypes{assets.ContentTypePNG: binary{}, assets.ContentTypeJPEG: binary{}},
+		},
+		{
+			method: http.MethodDelete, path: "/assets/{assetID}", access: accessTemplates, handler: h.Assets.DeleteAsset,
+			id: "deleteAsset", tag: "assets", summary: "Delete asset",
+			status: http.StatusNoContent,
+		},
+
+		// Documents.
+		{
+			method: http.MethodPost, path: "/templates/{templateID}/documents", access: accessDocuments, handler: h.Documents.GenerateDocument,
+			id: "generateDocument", tag: "documents", summary: "Generate document from template and data",
+			request: GeneratePayload{}, status: http.StatusCreated, response: documents.Document{},
+		},
+		{
+			method: http.MethodPost, path: "/templates/{templateID}/documents/batch", access: accessDocuments, handler: h.Documents.StartBatch,
+			id: "startDocumentBatch", tag: "documents", summary: "Generate a document per row of CSV or JSONL upload in background",
+			params: []param{
+				query("formats", "string", "Comma-separated output formats of every document; defaults to pdf for PDF output."),
+				query("output", "string", "Result of the batch: zip of document files (default) or a merged pdf of at most 1000 rows, not offered for templates with signing."),
+				query("version", "string", "Template version number or label; defaults to the current version."),
+				query("locale", "string", "Language tag of the documents such as kk-KZ."),
+				query("map", "string", "Repeatable field=column mapping of CSV columns to template fields."),
+			},
+			request: mediaTypes{"text/csv": binary{}, "application/x-ndjson": binary{}},
+			status:  http.StatusAccepted, response: documents.Batch{},
+			class: ratelimit.ClassBulk,
+		},
+		{
+			method: http.MethodGet, path: "/batches/{batchID}", access: accessDocuments, handler: h.Documents.GetBatch,
+			id: "getDocumentBatch", tag: "documents", summary: "Get progress and row errors of batch",
+			status: http.StatusOK, response: documents.Batch{},
+		},
+		{
+			method: http.MethodGet, path: "/batches/{batchID}/errors", access: accessDocuments, handler: h.Documents.BatchErrors,
+			id: "downloadDocumentBatchErrors", tag: "documents", summary: "Download row errors of batch as CSV",
+			status: http.StatusOK, response: mediaTypes{"text/csv": binary{}},
+		},
+		{
+			method: http.MethodGet, path: "/batches/{batchID}/result", access: accessDocuments, handler: h.Documents.BatchResult,
+			id: "downloadDocumentBatchResult", tag: "documents", summary: "Download ZIP or merged PDF of finished batch",
+			status: http.StatusOK, response: mediaTypes{"application/zip": binary{}, render.ContentType(render.FormatPDF): binary{}},
+		},
+		{
+			method: http.MethodGet, path: "/documents", access: accessDocuments, handler: h.Documents.ListDocuments,
+			id: "listDocuments", tag: "documents", summary: "List documents, newest first",
+			params: append([]param{
+				query("template_id", "string", "Template filter."),
+				query("created_by", "string", "Creator filter."),
+				query("batch_id", "string", "Batch filter."),
+				query("created_since", "string", "Documents created at or after this date or RFC 3339 timestamp."),
+				query("created_before", "string", "Documents created before this date or RFC 3339 timestamp."),
+				query("include_deleted", "boolean", "Include soft-deleted documents."),
+			}, paginationParams...),
+			status: http.StatusOK, response: Page[documents.Document]{},
+		},
+		{
+			method: http.MethodGet, path: "/documents/{documentID}", access: accessDocuments, handler: h.Documents.GetDocument,
+			id: "getDocument", tag: "documents", summary: "Get document",
+			status: http.StatusOK, response: documents.Document{},
+		},
+		{
+			method: http.MethodDelete, path: "/documents/{documentID}", access: accessDocuments, handler: h.Documents.DeleteDocument,
+			id: "deleteDocument", tag: "documents", summary: "Soft delete document",
+			status: http.StatusNoContent,
+		},
+		{
+			method: http.MethodPost, path: "/documents/{documentID}/restore", access: accessDocuments, handler: h.Documents.RestoreDocument,
+			id: "restoreDocument", tag: "documents", summary: "Restore soft-deleted document",
+			status: http.StatusOK, response: documents.Document{},
+		},
+		{
+			method: http.MethodPost, path: "/documents/{documentID}/regenerate", access: accessDocuments, handler: h.Documents.RegenerateDocument,
+			id: "regenerateDocument", tag: "documents", summary: "Render document metadata again against newer template version",
+			request: RegeneratePayload{}, status: http.StatusCreated, response: documents.Document{},
+		},
+		{
+			method: http.MethodPost, path: "/documents/{documentID}/revoke", access: accessDocuments, handler: h.Documents.RevokeDocument,
+			id: "revokeDocument", tag: "documents", summary: "Revoke document so that its verification fails; admins only",
+			request: RevokePayload{}, status: http.StatusOK, response: documents.Document{},
+		},
+		{
+			method: http.MethodGet, path: "/verify/{documentID}", access: accessPublicLimited, handler: h.Documents.VerifyDocument,
+			id: "verifyDocument", tag: "documents", summary: "Verify authenticity of document, e.g. from its QR code",
+			params: []param{
+				query("hash", "string", "Content hash presented as the document's, hex encoded."),
+				query("signature", "string", "Signature presented as the document's, as encoded in its QR code. Verification fields of the document are only returned when it is given."),
+			},
+			status: http.StatusOK, response: documents.Verification{},
+		},
+		{
+			method: http.MethodGet, path: "/documents/{documentID}/files/{format}", access: accessDocuments, handler: h.Documents.DownloadFile,
+			id: "downloadDocumentFile", tag: "documents", summary: "Download rendered file of document",
+			status: http.StatusOK, response: documentFiles(),
+		},
+
+		// Signing certificates.
+		{
+			method: http.MethodGet, path: "/certificates", access: accessTemplates, handler: h.Certificates.ListCertificates,
+			id: "listCertificates", tag: "certificates", summary: "List certificates documents are signed with, newest first",
+			status: http.StatusOK, response: ItemList[certificates.Certificate]{},
+		},
+		{
+			method: http.MethodPost, path: "/certificates", access: accessUserSession, handler: h.Certificates.UploadCertificate,
+			id: "uploadCertificate", tag: "certificates", summary: "Upload X.509 certificate with its private key; admins only",
+			request: CertificatePayload{}, status: http.StatusCreated, response: certificates.Certificate{},
+		},
+		{
+			method: http.MethodGet, path: "/certificates/{certificateID}", access: accessTemplates, handler: h.Certificates.GetCertificate,
+			id: "getCertificate", tag: "certificates", summary: "Get certificate",
+			status: http.StatusOK, response: certificates.Certificate{},
+		},
+		{
+			method: http.MethodDelete, path: "/certificates/{certificateID}", access: accessUserSession, handler: h.Certificates.DeleteCertificate,
+			id: "deleteCertificate", tag: "certificates", summary: "Delete certificate and its private key; admins only",
+			status: http.StatusNoContent,
+		},
+		{
+			method: http.MethodPost, path: "/signatures/validate", access: accessDocuments, handler: h.Certificates.ValidateSignatures,
+			id: "validateSignatures", tag: "certificates", summary: "Validate digital signatures of PDF document",
+			request: mediaTypes{"application/pdf": binary{}}, status: http.StatusOK, response: SignatureReport{},
+		},
+
+		// Users.
+		{
+			method: http.MethodGet, path: "/users", access: accessUserSession, handler: h.Users.ListUsers,
+			id: "listUsers", tag: "users", summary: "List users of tenant",
+			params: append([]param{
+				query("search", "string", "Email or name filter."),
+				query("role", "string", "Role filter."),
+				query("include_inactive", "boolean", "Include deactivated users."),
+			}, paginationParams...),
+			status: http.StatusOK, response: Page[users.User]{},
+		},
+		{
+			method: http.MethodPost, path: "/users/invitations", access: accessUserSession, handler: h.Users.InviteUser,
+			id: "inviteUser", tag: "users", summary: "Invite user to tenant",
+			request: InvitationPayload{}, status: http.StatusCreated, response: InvitationResult{},
+			secret: true,
+		},
+		{
+			method: http.MethodGet, path: "/users/{userID}", access: accessUserSession, handler: h.Users.GetUser,
+			id: "getUser", tag: "users", summary: "Get user",
+			status: http.StatusOK, response: users.User{},
+		},
+		{
+			method: http.MethodPut, path: "/users/{userID}", access: accessUserSession, handler: h.Users.UpdateUser,
+			id: "updateUser", tag: "users", summary: "Update user profile",
+			request: UserPayload{}, status: http.StatusOK, response: users.User{},
+		},
+		{
+			method: http.MethodPut, path: "/users/{userID}/role", access: accessUserSession, handler: h.Users.ChangeRole,
+			id: "changeUserRole", tag: "users", summary: "Change user role",
+			request: RolePayload{}, status: http.StatusOK, response: users.User{},
+		},
+		{
+			method: http.MethodPost, path: "/users/{userID}/deactivate", access: accessUserSession, handler: h.Users.DeactivateUser,
+			id: "deactivateUser", tag: "users", summary: "Deactivate user",
+			status: http.StatusOK, response: users.User{},
+		},
+		{
+			method: http.MethodPost, path: "/users/{userID}/reactivate", access: accessUserSession, handler: h.Users.ReactivateUser,
+			id: "reactivateUser", tag: "users", summary: "Reactivate user",
+			status: http.StatusOK, response: users.User{},
+		},
+
+		// API keys.
+		{
+			method: http.MethodGet, path: "/api-keys", access: accessUserSession, handler: h.APIKeys.ListKeys,
+			id: "listAPIKeys", tag: "api-keys", summary: "List API keys of tenant",
+			params: []param{query("include_revoked", "boolean", "Include revoked keys.")},
+			status: http.StatusOK, response: ItemList[apikeys.APIKey]{},
+		},
+		{
+			method: http.MethodPost, path: "/api-keys", access: accessUserSession, handler: h.APIKeys.CreateKey,
+			id: "createAPIKey", tag: "api-keys", summary: "Create API key; the secret is returned once",
+			request: APIKeyPayload{}, status: http.StatusCreated, response: APIKeyResult{},
+			secret: true,
+		},
+		{
+			method: http.MethodGet, path: "/api-keys/{keyID}", access: accessUserSession, handler: h.APIKeys.GetKey,
+			id: "getAPIKey", tag: "api-keys", summary: "Get API key metadata",
+			status: http.StatusOK, response: apikeys.APIKey{},
+		},
+		{
+			method: http.MethodDelete, path: "/api-keys/{keyID}", access: accessUserSession, handler: h.APIKeys.RevokeKey,
+			id: "revokeAPIKey", tag: "api-keys", summary: "Revoke API key",
+			status: http.StatusNoContent,
+		},
+
+		// Operator administration.
+		{
+			method: http.MethodGet, path: "/admin/tenants", access: accessAdmin, handler: h.Tenants.ListTenants,
+			id: "listTenants", tag: "admin", summary: "List tenants",
+			params: append([]param{
+				query("search", "string", "Name filter."),
+				query("include_inactive", "boolean", "Include suspended tenants."),
+			}, paginationParams...),
+			status: http.StatusOK, response: Page[tenants.Tenant]{},
+		},
+		{
+			method: http.MethodPost, path: "/admin/tenants", access: accessAdmin, handler: h.Tenants.CreateTenant,
+			id: "createTenant", tag: "admin", summary: "Create tenant",
+			request: TenantPayload{}, status: http.StatusCreated, response: tenants.Tenant{},
+		},
+		{
+			method: http.MethodGet, path: "/admin/tenants/{tenantID}", access: accessAdmin, handler: h.Tenants.GetTenant,
+			id: "getTenant", tag: "admin", summary: "Get tenant",
+			status: http.StatusOK, response: tenants.Tenant{},
+		},
+		{
+			method: http.MethodPut, path: "/admin/tenants/{tenantID}", access: accessAdmin, handler: h.Tenants.UpdateTenant,
+			id: "updateTenant", tag: "admin", summary: "Update tenant",
+			request: TenantPayload{}, status: http.StatusOK, response: tenants.Tenant{},
+		},
+		{
+			method: http.MethodPost, path: "/admin/tenants/{tenantID}/suspend", access: accessAdmin, handler: h.Tenants.SuspendTenant,
+			id: "suspendTenant", tag: "admin", summary: "Suspend tenant",
+			status: http.StatusOK, response: tenants.Tenant{},
+		},
+		{
+			method: http.MethodPost, path: "/admin/tenants/{tenantID}/reactivate", access: accessAdmin, handler: h.Tenants.ReactivateTenant,
+			id: "reactivateTenant", tag: "admin", summary: "Reactivate tenant",
+			status: http.StatusOK, response: tenants.Tenant{},
+		},
+		{
+			method: http.MethodPost, path: "/admin/tenants/{tenantID}/owner-invitation", access: accessAdmin, handler: h.Users.InviteOwner,
+			id: "inviteTenantOwner", tag: "admin", summary: "Invite first owner of tenant",
+			request: InvitationPayload{}, status: http.StatusCreated, response: InvitationResult{},
+			secret: true,
+		},
+		{
+			method: http.MethodGet, path: "/admin/tenants/{tenantID}/rate-limits", access: accessAdmin, handler: h.RateLimits.GetTenantLimits,
+			id: "getTenantRateLimits", tag: "admin", summary: "Effective rate limits of tenant",
+			status: http.StatusOK, response: ratelimit.TenantLimits{},
+		},
+		{
+			method: http.MethodPut, path: "/admin/tenants/{tenantID}/rate-limits", access: accessAdmin, handler: h.RateLimits.SetTenantLimits,
+			id: "setTenantRateLimits", tag: "admin", summary: "Override rate limits of tenant",
+			request: ratelimit.TenantLimits{}, status: http.StatusOK, response: ratelimit.TenantLimits{},
+		},
+		{
+			method: http.MethodGet, path: "/admin/tenants/{tenantID}/version-retention", access: accessAdmin, handler: h.Templates.GetRetentionPolicy,
+			id: "getVersionRetention", tag: "admin", summary: "Template version retention policy of tenant",
+			status: http.StatusOK, response: templates.RetentionPolicy{},
+		},
+		{
+			method: http.MethodPut, path: "/admin/tenants/{tenantID}/version-retention", access: accessAdmin, handler: h.Templates.SetRetentionPolicy,
+			id: "setVersionRetention", tag: "admin", summary: "Set template version retention policy of tenant",
+			request: templates.RetentionPolicy{}, status: http.StatusOK, response: templates.RetentionPolicy{},
+		},
+		{
+			method: http.MethodPost, path: "/admin/tenants/{tenantID}/version-retention/prune", access: accessAdmin, handler: h.Templates.PruneVersions,
+			id: "pruneTemplateVersions", tag: "admin", summary: "Prune template versions of tenant now",
+			status: http.StatusOK, response: templates.PruneResult{},
+		},
+		{
+			method: http.MethodGet, path: "/admin/auth/keys", access: accessAdmin, handler: h.Auth.ListKeys,
+			id: "listSigningKeys", tag: "admin", summary: "List token signing keys",
+			status: http.StatusOK, response: ItemList[auth.SigningKey]{},
+		},
+		{
+			method: http.MethodPost, path: "/admin/auth/keys/rotate", access: accessAdmin, handler: h.Auth.RotateKeys,
+			id: "rotateSigningKeys", tag: "admin", summary: "Rotate token signing key",
+			status: http.StatusCreated, response: auth.SigningKey{},
+		},
+	}
 }
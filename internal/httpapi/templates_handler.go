@@ -1,32 +1,41 @@
 package httpapi
 
 import (
-	"context"
+	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/lumiforge/docfactory-backend/internal/jsonpatch"
 	"github.com/lumiforge/docfactory-backend/internal/templates"
+	"github.com/lumiforge/docfactory-backend/internal/users"
+	"github.com/lumiforge/docfactory-backend/internal/validation"
 )
 
 // TemplateHandler wires HTTP requests to template service.
 type TemplateHandler struct {
 	service *templates.TemplateService
+	users   *users.UserService
 }
 
 // NewTemplateHandler creates HTTP handler.
-func NewTemplateHandler(service *templates.TemplateService) *TemplateHandler {
-	return &TemplateHandler{service: service}
+func NewTemplateHandler(service *templates.TemplateService, userService *users.UserService) *TemplateHandler {
+	return &TemplateHandler{service: service, users: userService}
 }
 
 // ListTemplates handles GET /templates.
 func (h *TemplateHandler) ListTemplates(w http.ResponseWriter, r *http.Request) {
 	tenantID, err := tenantFromRequest(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeError(w, r, err)
 		return
 	}
 	limit, offset := paginationFromRequest(r, 50)
@@ -44,32 +53,23 @@ func (h *TemplateHandler) ListTemplates(w http.ResponseWriter, r *http.Request)
 
 	templatesList, total, err := h.service.ListTemplates(r.Context(), opt)
 	if err != nil {
-		writeError(w, http.StatusInternalServerError, err)
+		writeError(w, r, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{
-		"items":  templatesList,
-		"total":  total,
-		"limit":  limit,
-		"offset": offset,
-	})
+	writeJSON(w, http.StatusOK, Page[templates.Template]{Items: templatesList, Total: total, Limit: limit, Offset: offset})
 }
 
 // GetTemplate handles GET /templates/{id}.
 func (h *TemplateHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
 	tenantID, err := tenantFromRequest(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeError(w, r, err)
 		return
 	}
 	templateID := pathParam(r, "templateID")
 	tpl, err := h.service.GetTemplate(r.Context(), tenantID, templateID)
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, templates.ErrNotFound) {
-			status = http.StatusNotFound
-		}
-		writeError(w, status, err)
+		writeError(w, r, err)
 		return
 	}
 	writeJSON(w, http.StatusOK, tpl)
@@ -79,13 +79,12 @@ func (h *TemplateHandler) GetTemplate(w http.ResponseWriter, r *http.Request) {
 func (h *TemplateHandler) CreateTemplate(w http.ResponseWriter, r *http.Request) {
 	tenantID, err := tenantFromRequest(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeError(w, r, err)
 		return
 	}
 	userID := userFromRequest(r)
 	var payload TemplatePayload
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+	if !readPayload(w, r, &payload) {
 		return
 	}
 	tpl := payload.ToTemplate()
@@ -94,11 +93,7 @@ func (h *TemplateHandler) CreateTemplate(w http.ResponseWriter, r *http.Request)
 	tpl.UpdatedBy = userID
 	created, err := h.service.CreateTemplate(r.Context(), tpl)
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, templates.ErrInvalidInput) {
-			status = http.StatusBadRequest
-		}
-		writeError(w, status, err)
+		writeError(w, r, err)
 		return
 	}
 	writeJSON(w, http.StatusCreated, created)
@@ -108,14 +103,13 @@ func (h *TemplateHandler) CreateTemplate(w http.ResponseWriter, r *http.Request)
 func (h *TemplateHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request) {
 	tenantID, err := tenantFromRequest(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeError(w, r, err)
 		return
 	}
 	userID := userFromRequest(r)
 	templateID := pathParam(r, "templateID")
 	var payload TemplatePayload
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+	if !readPayload(w, r, &payload) {
 		return
 	}
 	updated, err := h.service.UpdateTemplate(r.Context(), tenantID, templateID, func(t *templates.Template) error {
@@ -138,16 +132,86 @@ func (h *TemplateHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request)
 		if payload.ThumbnailURL != "" {
 			t.ThumbnailURL = payload.ThumbnailURL
 		}
+		if payload.Page != nil {
+			t.Page = *payload.Page
+		}
+		if payload.Label != nil {
+			t.Label = *payload.Label
+		}
+		if payload.Signing != nil {
+			t.Signing = *payload.Signing
+		}
+		if payload.Layout != nil {
+			t.Layout = *payload.Layout
+		}
+		if payload.Localization != nil {
+			t.Localization = *payload.Localization
+		}
 		return nil
 	}, userID, payload.ChangeSummary)
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, templates.ErrInvalidInput) {
-			status = http.StatusBadRequest
-		} else if errors.Is(err, templates.ErrNotFound) {
-			status = http.StatusNotFound
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// Patch media types accepted by PatchTemplate.
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+// PatchTemplate handles PATCH /templates/{id}. The body is an RFC 7396 merge
+// patch or an RFC 6902 JSON Patch, selected by Content-Type, applied to the
+// PatchableTemplate view of the stored template.
+func (h *TemplateHandler) PatchTemplate(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	var apply func(doc, patch []byte) ([]byte, error)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case mergePatchContentType:
+		apply = jsonpatch.MergePatch
+	case jsonPatchContentType:
+		apply = jsonpatch.Apply
+	default:
+		w.Header().Set("Accept-Patch", mergePatchContentType+", "+jsonPatchContentType)
+		writeError(w, r, problemUnsupportedMediaType.error("Content-Type must be "+mergePatchContentType+" or "+jsonPatchContentType))
+		return
+	}
+	patch, err := readBody(w, r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	changeSummary := r.URL.Query().Get("change_summary")
+	if len(changeSummary) > 500 {
+		writeError(w, r, validation.Errors{{Field: "change_summary", Code: validation.CodeLength, Message: "must be at most 500 characters"}})
+		return
+	}
+	templateID := pathParam(r, "templateID")
+	updated, err := h.service.UpdateTemplate(r.Context(), tenantID, templateID, func(t *templates.Template) error {
+		doc, err := json.Marshal(patchableFrom(*t))
+		if err != nil {
+			return err
+		}
+		patched, err := apply(doc, patch)
+		if err != nil {
+			return err
+		}
+		var result PatchableTemplate
+		if err := decodeStrict(patched, &result); err != nil {
+			return err
 		}
-		writeError(w, status, err)
+		result.applyTo(t)
+		return nil
+	}, userFromRequest(r), changeSummary)
+	if err != nil {
+		writeError(w, r, err)
 		return
 	}
 	writeJSON(w, http.StatusOK, updated)
@@ -157,16 +221,12 @@ func (h *TemplateHandler) UpdateTemplate(w http.ResponseWriter, r *http.Request)
 func (h *TemplateHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
 	tenantID, err := tenantFromRequest(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeError(w, r, err)
 		return
 	}
 	templateID := pathParam(r, "templateID")
 	if err := h.service.DeleteTemplate(r.Context(), tenantID, templateID); err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, templates.ErrNotFound) {
-			status = http.StatusNotFound
-		}
-		writeError(w, status, err)
+		writeError(w, r, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
@@ -176,17 +236,13 @@ func (h *TemplateHandler) DeleteTemplate(w http.ResponseWriter, r *http.Request)
 func (h *TemplateHandler) RestoreTemplate(w http.ResponseWriter, r *http.Request) {
 	tenantID, err := tenantFromRequest(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeError(w, r, err)
 		return
 	}
 	templateID := pathParam(r, "templateID")
 	tpl, err := h.service.RestoreTemplate(r.Context(), tenantID, templateID)
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, templates.ErrNotFound) {
-			status = http.StatusNotFound
-		}
-		writeError(w, status, err)
+		writeError(w, r, err)
 		return
 	}
 	writeJSON(w, http.StatusOK, tpl)
@@ -196,16 +252,36 @@ func (h *TemplateHandler) RestoreTemplate(w http.ResponseWriter, r *http.Request
 func (h *TemplateHandler) DuplicateTemplate(w http.ResponseWriter, r *http.Request) {
 	tenantID, err := tenantFromRequest(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeError(w, r, err)
 		return
 	}
 	templateID := pathParam(r, "templateID")
 	var payload DuplicatePayload
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+	if !readPayload(w, r, &payload) {
+		return
+	}
+	actorID := userFromRequest(r)
+	payload.Defaults(actorID)
+	var errs validation.Errors
+	for _, ref := range []struct{ field, userID string }{
+		{"created_by", payload.CreatedBy},
+		{"updated_by", payload.UpdatedBy},
+	} {
+		if ref.userID == actorID {
+			continue
+		}
+		if _, err := h.users.ActiveUser(r.Context(), tenantID, ref.userID); err != nil {
+			if !errors.Is(err, users.ErrNotFound) && !errors.Is(err, users.ErrInactive) {
+				writeError(w, r, err)
+				return
+			}
+			errs.Add(ref.field, validation.CodeNotFound, "must reference an active user")
+		}
+	}
+	if err := errs.Err(); err != nil {
+		writeError(w, r, err)
 		return
 	}
-	payload.Defaults(userFromRequest(r))
 	dup, err := h.service.DuplicateTemplate(r.Context(), tenantID, templateID, templates.DuplicateOptions{
 		CreatedBy:           payload.CreatedBy,
 		UpdatedBy:           payload.UpdatedBy,
@@ -214,11 +290,7 @@ func (h *TemplateHandler) DuplicateTemplate(w http.ResponseWriter, r *http.Reque
 		DescriptionOverride: payload.DescriptionOverride,
 	})
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, templates.ErrNotFound) {
-			status = http.StatusNotFound
-		}
-		writeError(w, status, err)
+		writeError(w, r, err)
 		return
 	}
 	writeJSON(w, http.StatusCreated, dup)
@@ -228,89 +300,251 @@ func (h *TemplateHandler) DuplicateTemplate(w http.ResponseWriter, r *http.Reque
 func (h *TemplateHandler) ListVersions(w http.ResponseWriter, r *http.Request) {
 	tenantID, err := tenantFromRequest(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeError(w, r, err)
 		return
 	}
 	templateID := pathParam(r, "templateID")
-	versions, err := h.service.ListVersions(r.Context(), tenantID, templateID)
+	limit, offset := paginationFromRequest(r, 50)
+	versions, total, err := h.service.ListVersions(r.Context(), tenantID, templateID, limit, offset)
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, templates.ErrNotFound) {
-			status = http.StatusNotFound
-		}
-		writeError(w, status, err)
+		writeError(w, r, err)
 		return
 	}
-	writeJSON(w, http.StatusOK, versions)
+	writeJSON(w, http.StatusOK, Page[templates.TemplateVersion]{Items: versions, Total: total, Limit: limit, Offset: offset})
+}
+
+// GetVersion handles GET /templates/{id}/versions/{version}, where version is
+// a number or a label.
+func (h *TemplateHandler) GetVersion(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	version, err := h.service.ResolveVersion(r.Context(), tenantID, pathParam(r, "templateID"), pathParam(r, "version"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, version)
+}
+
+// DeleteVersion handles DELETE /templates/{id}/versions/{version}.
+func (h *TemplateHandler) DeleteVersion(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := h.service.DeleteVersion(r.Context(), tenantID, pathParam(r, "templateID"), pathParam(r, "version")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PinVersion handles PUT /templates/{id}/versions/{version}/pin.
+func (h *TemplateHandler) PinVersion(w http.ResponseWriter, r *http.Request) {
+	h.setPinned(w, r, true)
+}
+
+// UnpinVersion handles DELETE /templates/{id}/versions/{version}/pin.
+func (h *TemplateHandler) UnpinVersion(w http.ResponseWriter, r *http.Request) {
+	h.setPinned(w, r, false)
+}
+
+func (h *TemplateHandler) setPinned(w http.ResponseWriter, r *http.Request, pinned bool) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	version, err := h.service.PinVersion(r.Context(), tenantID, pathParam(r, "templateID"), pathParam(r, "version"), pinned)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, version)
+}
+
+// SetVersionLabel handles PUT /templates/{id}/versions/{version}/labels/{label}.
+func (h *TemplateHandler) SetVersionLabel(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	version, err := h.service.SetLabel(r.Context(), tenantID, pathParam(r, "templateID"), pathParam(r, "version"), pathParam(r, "label"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, version)
+}
+
+// RemoveVersionLabel handles DELETE /templates/{id}/versions/{version}/labels/{label}.
+func (h *TemplateHandler) RemoveVersionLabel(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	if err := h.service.RemoveLabel(r.Context(), tenantID, pathParam(r, "templateID"), pathParam(r, "version"), pathParam(r, "label")); err != nil {
+		writeError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PublishVersion handles POST /templates/{id}/versions/{version}/publish.
+func (h *TemplateHandler) PublishVersion(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	versionNumber, err := versionFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	published, err := h.service.PublishVersion(r.Context(), tenantID, pathParam(r, "templateID"), versionNumber)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, published)
+}
+
+// ListMetadataChanges handles GET /templates/{id}/history.
+func (h *TemplateHandler) ListMetadataChanges(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	templateID := pathParam(r, "templateID")
+	changes, err := h.service.ListMetadataChanges(r.Context(), tenantID, templateID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, ItemList[templates.MetadataChange]{Items: changes})
 }
 
 // RestoreVersion handles POST /templates/{id}/versions/{version}/restore.
 func (h *TemplateHandler) RestoreVersion(w http.ResponseWriter, r *http.Request) {
 	tenantID, err := tenantFromRequest(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeError(w, r, err)
 		return
 	}
 	templateID := pathParam(r, "templateID")
-	versionParam := pathParam(r, "version")
-	versionNumber, err := strconv.Atoi(versionParam)
+	versionNumber, err := versionFromRequest(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, errors.New("version must be integer"))
+		writeError(w, r, err)
 		return
 	}
-	restored, err := h.service.RestoreVersion(r.Context(), tenantID, templateID, versionNumber)
+	restored, err := h.service.RestoreVersion(r.Context(), tenantID, templateID, versionNumber, userFromRequest(r))
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, templates.ErrNotFound) {
-			status = http.StatusNotFound
-		}
-		writeError(w, status, err)
+		writeError(w, r, err)
 		return
 	}
 	writeJSON(w, http.StatusOK, restored)
 }
 
+// GetRetentionPolicy handles GET /admin/tenants/{id}/version-retention.
+func (h *TemplateHandler) GetRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	policy, err := h.service.RetentionPolicy(r.Context(), pathParam(r, "tenantID"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, policy)
+}
+
+// SetRetentionPolicy handles PUT /admin/tenants/{id}/version-retention.
+func (h *TemplateHandler) SetRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	var payload templates.RetentionPolicy
+	if !readPayload(w, r, &payload) {
+		return
+	}
+	policy, err := h.service.SetRetentionPolicy(r.Context(), pathParam(r, "tenantID"), payload)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, policy)
+}
+
+// PruneVersions handles POST /admin/tenants/{id}/version-retention/prune,
+// running the pruning job for one tenant immediately.
+func (h *TemplateHandler) PruneVersions(w http.ResponseWriter, r *http.Request) {
+	result, err := h.service.PruneVersions(r.Context(), pathParam(r, "tenantID"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
 // CompareVersions handles GET /templates/{id}/versions/compare.
 func (h *TemplateHandler) CompareVersions(w http.ResponseWriter, r *http.Request) {
 	tenantID, err := tenantFromRequest(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeError(w, r, err)
 		return
 	}
 	templateID := pathParam(r, "templateID")
-	left, right := r.URL.Query().Get("left"), r.URL.Query().Get("right")
-	leftVersion, err := strconv.Atoi(left)
+	var errs validation.Errors
+	leftVersion, err := strconv.Atoi(r.URL.Query().Get("left"))
 	if err != nil {
-		writeError(w, http.StatusBadRequest, errors.New("left is required integer"))
-		return
+		errs.Add("left", validation.CodeInvalidType, "must be an integer")
 	}
-	rightVersion, err := strconv.Atoi(right)
+	rightVersion, err := strconv.Atoi(r.URL.Query().Get("right"))
 	if err != nil {
-		writeError(w, http.StatusBadRequest, errors.New("right is required integer"))
+		errs.Add("right", validation.CodeInvalidType, "must be an integer")
+	}
+	if err := errs.Err(); err != nil {
+		writeError(w, r, err)
 		return
 	}
 	comparison, err := h.service.CompareVersions(r.Context(), tenantID, templateID, leftVersion, rightVersion)
 	if err != nil {
-		status := http.StatusInternalServerError
-		if errors.Is(err, templates.ErrNotFound) {
-			status = http.StatusNotFound
-		}
-		writeError(w, status, err)
+		writeError(w, r, err)
 		return
 	}
 	writeJSON(w, http.StatusOK, comparison)
 }
 
+// TranslationReport handles GET /templates/{id}/translations.
+func (h *TemplateHandler) TranslationReport(w http.ResponseWriter, r *http.Request) {
+	tenantID, err := tenantFromRequest(r)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	report, err := h.service.TranslationReport(r.Context(), tenantID, pathParam(r, "templateID"), r.URL.Query().Get("locale"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, report)
+}
+
 // BulkDelete handles POST /templates/bulk/delete.
 func (h *TemplateHandler) BulkDelete(w http.ResponseWriter, r *http.Request) {
 	tenantID, err := tenantFromRequest(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeError(w, r, err)
 		return
 	}
 	var payload BulkIDsPayload
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+	if !readPayload(w, r, &payload) {
+		return
+	}
+	if err := h.service.CheckBatch(r.Context(), tenantID, len(payload.TemplateIDs)); err != nil {
+		writeError(w, r, err)
 		return
 	}
 	result := BulkResult{Succeeded: []string{}, Failed: map[string]string{}}
@@ -328,20 +562,22 @@ func (h *TemplateHandler) BulkDelete(w http.ResponseWriter, r *http.Request) {
 func (h *TemplateHandler) BulkExport(w http.ResponseWriter, r *http.Request) {
 	tenantID, err := tenantFromRequest(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeError(w, r, err)
 		return
 	}
-	_ = tenantID
 	var payload BulkIDsPayload
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+	if !readPayload(w, r, &payload) {
+		return
+	}
+	if err := h.service.CheckBatch(r.Context(), tenantID, len(payload.TemplateIDs)); err != nil {
+		writeError(w, r, err)
 		return
 	}
 	exportID := "export-" + strconv.FormatInt(time.Now().Unix(), 10)
-	writeJSON(w, http.StatusAccepted, map[string]any{
-		"export_id":    exportID,
-		"template_ids": payload.TemplateIDs,
-		"status":       "scheduled",
+	writeJSON(w, http.StatusAccepted, ExportResult{
+		ExportID:    exportID,
+		TemplateIDs: payload.TemplateIDs,
+		Status:      "scheduled",
 	})
 }
 
@@ -349,13 +585,16 @@ func (h *TemplateHandler) BulkExport(w http.ResponseWriter, r *http.Request) {
 func (h *TemplateHandler) BulkDuplicate(w http.ResponseWriter, r *http.Request) {
 	tenantID, err := tenantFromRequest(r)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err)
+		writeError(w, r, err)
 		return
 	}
 	userID := userFromRequest(r)
 	var payload BulkDuplicatePayload
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		writeError(w, http.StatusBadRequest, err)
+	if !readPayload(w, r, &payload) {
+		return
+	}
+	if err := h.service.CheckBatch(r.Context(), tenantID, len(payload.TemplateIDs)); err != nil {
+		writeError(w, r, err)
 		return
 	}
 	result := BulkResult{Succeeded: []string{}, Failed: map[string]string{}}
@@ -384,11 +623,28 @@ type TemplatePayload struct {
 	Orientation   templates.Orientation  `json:"orientation"`
 	JSONSchemaURL string                 `json:"json_schema_url"`
 	ThumbnailURL  string                 `json:"thumbnail_url"`
-	ChangeSummary string                 `json:"change_summary"`
+	// Page, Label, Signing, Layout and Localization replace page setup,
+	// label and signing settings, the layout and its translations; updates
+	// keep them when omitted. Empty objects remove the settings.
+	Page          *templates.PageSetup    `json:"page,omitempty"`
+	Label         *templates.LabelSpec    `json:"label,omitempty"`
+	Signing       *templates.SigningSpec  `json:"signing,omitempty"`
+	Layout        *templates.Layout       `json:"layout,omitempty"`
+	Localization  *templates.Localization `json:"localization,omitempty"`
+	ChangeSummary string                  `json:"change_summary"`
+}
+
+// Validate checks fields not covered by templates.Template.Validate.
+func (p TemplatePayload) Validate() error {
+	var errs validation.Errors
+	if len(p.ChangeSummary) > 500 {
+		errs.Add("change_summary", validation.CodeLength, "must be at most 500 characters")
+	}
+	return errs.Err()
 }
 
 func (p TemplatePayload) ToTemplate() templates.Template {
-	return templates.Template{
+	tpl := templates.Template{
 		Name:          strings.TrimSpace(p.Name),
 		Description:   strings.TrimSpace(p.Description),
 		DocumentType:  p.DocumentType,
@@ -397,6 +653,72 @@ func (p TemplatePayload) ToTemplate() templates.Template {
 		JSONSchemaURL: strings.TrimSpace(p.JSONSchemaURL),
 		ThumbnailURL:  strings.TrimSpace(p.ThumbnailURL),
 	}
+	if p.Page != nil {
+		tpl.Page = *p.Page
+	}
+	if p.Label != nil {
+		tpl.Label = *p.Label
+	}
+	if p.Signing != nil {
+		tpl.Signing = *p.Signing
+	}
+	if p.Layout != nil {
+		tpl.Layout = *p.Layout
+	}
+	if p.Localization != nil {
+		tpl.Localization = *p.Localization
+	}
+	return tpl
+}
+
+// PatchableTemplate is the document patched by PATCH /templates/{id}. Fields
+// missing from the patched document are cleared and then rejected by
+// template validation when required.
+type PatchableTemplate struct {
+	Name          string                 `json:"name"`
+	Description   string                 `json:"description"`
+	DocumentType  templates.DocumentType `json:"document_type"`
+	PageSize      templates.PageSize     `json:"page_size"`
+	Orientation   templates.Orientation  `json:"orientation"`
+	JSONSchemaURL string                 `json:"json_schema_url"`
+	ThumbnailURL  string                 `json:"thumbnail_url"`
+	Page          templates.PageSetup    `json:"page,omitzero"`
+	Label         templates.LabelSpec    `json:"label,omitzero"`
+	Signing       templates.SigningSpec  `json:"signing,omitzero"`
+	Layout        templates.Layout       `json:"layout"`
+	Localization  templates.Localization `json:"localization,omitzero"`
+}
+
+func patchableFrom(t templates.Template) PatchableTemplate {
+	return PatchableTemplate{
+		Name:          t.Name,
+		Description:   t.Description,
+		DocumentType:  t.DocumentType,
+		PageSize:      t.PageSize,
+		Orientation:   t.Orientation,
+		JSONSchemaURL: t.JSONSchemaURL,
+		ThumbnailURL:  t.ThumbnailURL,
+		Page:          t.Page,
+		Label:         t.Label,
+		Signing:       t.Signing,
+		Layout:        t.Layout,
+		Localization:  t.Localization,
+	}
+}
+
+func (p PatchableTemplate) applyTo(t *templates.Template) {
+	t.Name = strings.TrimSpace(p.Name)
+	t.Description = strings.TrimSpace(p.Description)
+	t.DocumentType = p.DocumentType
+	t.PageSize = p.PageSize
+	t.Orientation = p.Orientation
+	t.JSONSchemaURL = strings.TrimSpace(p.JSONSchemaURL)
+	t.ThumbnailURL = strings.TrimSpace(p.ThumbnailURL)
+	t.Page = p.Page
+	t.Label = p.Label
+	t.Signing = p.Signing
+	t.Layout = p.Layout
+	t.Localization = p.Localization
 }
 
 type DuplicatePayload struct {
@@ -407,6 +729,17 @@ type DuplicatePayload struct {
 	UpdatedBy           string `json:"updated_by"`
 }
 
+func (p DuplicatePayload) Validate() error {
+	var errs validation.Errors
+	if p.NameOverride != "" {
+		errs.Length("name_override", p.NameOverride, 3, 100)
+	}
+	if len(p.DescriptionOverride) > 500 {
+		errs.Add("description_override", validation.CodeLength, "must be at most 500 characters")
+	}
+	return errs.Err()
+}
+
 func (p *DuplicatePayload) Defaults(userID string) {
 	if p.CreatedBy == "" {
 		p.CreatedBy = userID
@@ -420,43 +753,82 @@ type BulkIDsPayload struct {
 	TemplateIDs []string `json:"template_ids"`
 }
 
+func (p BulkIDsPayload) Validate() error {
+	return validateTemplateIDs(p.TemplateIDs)
+}
+
 type BulkDuplicatePayload struct {
 	TemplateIDs  []string `json:"template_ids"`
 	CopyVersions bool     `json:"copy_versions"`
 }
 
+func (p BulkDuplicatePayload) Validate() error {
+	return validateTemplateIDs(p.TemplateIDs)
+}
+
+func validateTemplateIDs(ids []string) error {
+	var errs validation.Errors
+	if len(ids) == 0 {
+		errs.Add("template_ids", validation.CodeRequired, "must contain at least one id")
+	}
+	for i, id := range ids {
+		errs.Required(fmt.Sprintf("template_ids[%d]", i), id)
+	}
+	return errs.Err()
+}
+
 type BulkResult struct {
 	Succeeded []string          `json:"succeeded"`
 	Failed    map[string]string `json:"failed"`
 }
 
-type contextKey string
+type ExportResult struct {
+	ExportID    string   `json:"export_id"`
+	TemplateIDs []string `json:"template_ids"`
+	Status      string   `json:"status"`
+}
 
-func withPathParam(ctx context.Context, key, value string) context.Context {
-	return context.WithValue(ctx, contextKey(key), value)
+// Page is the envelope of paginated list responses.
+type Page[T any] struct {
+	Items  []T `json:"items"`
+	Total  int `json:"total"`
+	Limit  int `json:"limit"`
+	Offset int `json:"offset"`
+}
+
+// ItemList is the envelope of unpaginated list responses.
+type ItemList[T any] struct {
+	Items []T `json:"items"`
 }
 
 func pathParam(r *http.Request, key string) string {
-	if value, ok := r.Context().Value(contextKey(key)).(string); ok {
-		return value
+	return r.PathValue(key)
+}
+
+// versionFromRequest parses the {version} path wildcard.
+func versionFromRequest(r *http.Request) (int, error) {
+	versionNumber, err := strconv.Atoi(pathParam(r, "version"))
+	if err != nil {
+		return 0, validation.Errors{{Field: "version", Code: validation.CodeInvalidType, Message: "must be an integer"}}
 	}
-	return ""
+	return versionNumber, nil
 }
 
 func tenantFromRequest(r *http.Request) (string, error) {
-	tenantID := strings.TrimSpace(r.Header.Get("X-Tenant-ID"))
-	if tenantID == "" {
-		return "", errors.New("X-Tenant-ID header is required")
+	p := currentPrincipal(r)
+	if p == nil {
+		return "", problemUnauthenticated.error("authentication is required")
 	}
-	return tenantID, nil
+	return p.TenantID, nil
 }
 
+// userFromRequest returns actor recorded in CreatedBy/UpdatedBy: user ID for
+// people and apikey:<key_id> for API keys.
 func userFromRequest(r *http.Request) string {
-	userID := strings.TrimSpace(r.Header.Get("X-User-ID"))
-	if userID == "" {
-		return "system"
+	if p := currentPrincipal(r); p != nil {
+		return p.ActorID
 	}
-	return userID
+	return "system"
 }
 
 func paginationFromRequest(r *http.Request, defaultLimit int) (int, int) {
@@ -481,6 +853,230 @@ func writeJSON(w http.ResponseWriter, status int, payload any) {
 	_ = json.NewEncoder(w).Encode(payload)
 }
 
-func writeError(w http.ResponseWriter, status int, err error) {
-	writeJSON(w, status, map[string]string{"error": err.Error()})
+// maxBodyBytes caps size of JSON request bodies.
+const maxBodyBytes = 1 << 20
+
+// maxUploadBytes caps size of batch uploads and PDF documents to validate,
+// the largest bodies accepted.
+const maxUploadBytes = 32 << 20
+
+// readPayload strictly decodes request body into dst and validates it when
+// dst has a Validate method. All handlers read JSON bodies through it.
+// Unknown fields, trailing data and oversized bodies are rejected. On
+// failure it writes the error response and returns false.
+func readPayload(w http.ResponseWriter, r *http.Request, dst any) bool {
+	err := decodeJSON(w, r, dst)
+	if err == nil {
+		if v, ok := dst.(interface{ Validate() error }); ok {
+			if err = v.Validate(); err != nil && classify(err) == problemInternal {
+				err = problemBadRequest.error(err.Error())
+			}
+		}
+	}
+	if err == nil {
+		return true
+	}
+	writeError(w, r, err)
+	return false
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) error {
+	body, err := readBody(w, r)
+	if err != nil {
+		return err
+	}
+	return decodeStrict(body, dst)
+}
+
+// readBody reads request body of at most maxBodyBytes.
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	return readBodyLimit(w, r, maxBodyBytes)
+}
+
+// readBodyLimit reads request body of at most limit bytes.
+func readBodyLimit(w http.ResponseWriter, r *http.Request, limit int64) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, limit))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, problemPayloadTooLarge.error(fmt.Sprintf("request body exceeds %d bytes", limit))
+		}
+		return nil, problemBadRequest.error("read request body: " + err.Error())
+	}
+	return body, nil
+}
+
+// decodeStrict decodes single JSON value of body into dst, rejecting unknown
+// fields and reporting type mismatches as field errors. The decoder stops at
+// the first problem, so the value is checked against dst first to report
+// every one of them, at any depth.
+func decodeStrict(body []byte, dst any) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return problemBadRequest.error("request body is required")
+	}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var value any
+	if err := dec.Decode(&value); err != nil {
+		return problemBadRequest.error("malformed JSON body: " + err.Error())
+	}
+	if dec.More() {
+		return problemBadRequest.error("request body must contain a single JSON value")
+	}
+	var errs validation.Errors
+	checkJSON(&errs, "", value, reflect.TypeOf(dst))
+	if err := errs.Err(); err != nil {
+		sort.SliceStable(errs, func(i, j int) bool { return errs[i].Field < errs[j].Field })
+		return errs
+	}
+	dec = json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(dst); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) {
+			return validation.Errors{{Field: typeErr.Field, Code: validation.CodeInvalidType, Message: "must be " + jsonTypeName(typeErr.Type)}}
+		}
+		return problemBadRequest.error("malformed JSON body: " + err.Error())
+	}
+	return nil
+}
+
+// checkJSON adds errors for keys of value that type t does not accept and
+// for values of the wrong JSON type. Paths are written as in validation
+// errors, e.g. layout.elements[0].type.
+func checkJSON(errs *validation.Errors, path string, value any, t reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if value == nil || t.Kind() == reflect.Interface || t == reflect.TypeFor[json.RawMessage]() {
+		return
+	}
+	invalid := func() {
+		errs.Add(path, validation.CodeInvalidType, "must be "+jsonTypeName(t))
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if t == reflect.TypeFor[time.Time]() {
+			if _, ok := value.(string); !ok {
+				errs.Add(path, validation.CodeInvalidType, "must be a string")
+			}
+			return
+		}
+		obj, ok := value.(map[string]any)
+		if !ok {
+			invalid()
+			return
+		}
+		fields := jsonFields(t)
+		for key, v := range obj {
+			field, ok := fields[strings.ToLower(key)]
+			if !ok {
+				errs.Add(joinPath(path, key), validation.CodeUnknownField, "is not allowed")
+				continue
+			}
+			checkJSON(errs, joinPath(path, key), v, field)
+		}
+	case reflect.Map:
+		obj, ok := value.(map[string]any)
+		if !ok {
+			invalid()
+			return
+		}
+		for key, v := range obj {
+			checkJSON(errs, joinPath(path, key), v, t.Elem())
+		}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			if _, ok := value.(string); !ok {
+				errs.Add(path, validation.CodeInvalidType, "must be a base64 string")
+			}
+			return
+		}
+		items, ok := value.([]any)
+		if !ok {
+			invalid()
+			return
+		}
+		for i, v := range items {
+			checkJSON(errs, fmt.Sprintf("%s[%d]", path, i), v, t.Elem())
+		}
+	case reflect.String:
+		if _, ok := value.(string); !ok {
+			invalid()
+		}
+	case reflect.Bool:
+		if _, ok := value.(bool); !ok {
+			invalid()
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, ok := value.(json.Number)
+		if !ok {
+			invalid()
+			return
+		}
+		if _, err := strconv.ParseInt(n.String(), 10, 64); err != nil {
+			invalid()
+		}
+	case reflect.Float32, reflect.Float64:
+		if _, ok := value.(json.Number); !ok {
+			invalid()
+		}
+	}
+}
+
+// jsonFields maps lower-cased JSON names of fields of struct t, including
+// those promoted from embedded structs, to their types.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || !f.IsExported() && !f.Anonymous {
+			continue
+		}
+		if embedded := f.Type; f.Anonymous && name == "" {
+			for embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				for k, v := range jsonFields(embedded) {
+					if _, ok := fields[k]; !ok {
+						fields[k] = v
+					}
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = f.Type
+	}
+	return fields
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	default:
+		return "an object"
+	}
 }
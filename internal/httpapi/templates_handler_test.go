@@ -0,0 +1,40 @@
+package httpapi
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/lumiforge/docfactory-backend/internal/ratelimit"
+	"github.com/lumiforge/docfactory-backend/internal/validation"
+)
+
+func TestDecodeStrictReportsEveryField(t *testing.T) {
+	for _, tc := range []struct {
+		body   string
+		dst    any
+		fields []string
+	}{
+		{`{"name":"abc","page_size":"A4"}`, &TemplatePayload{}, nil},
+		{`{"name":5,"bogus":1,"page_size":true}`, &TemplatePayload{}, []string{"bogus", "name", "page_size"}},
+		{`{"tenant":{"read":{"rate":1,"burst":2}}}`, &ratelimit.TenantLimits{}, nil},
+		{
+			`{"tenant":{"read":{"rate":"x","burst":1.5,"bogus":1}},"principal":[]}`, &ratelimit.TenantLimits{},
+			[]string{"principal", "tenant.read.bogus", "tenant.read.burst", "tenant.read.rate"},
+		},
+	} {
+		err := decodeStrict([]byte(tc.body), tc.dst)
+		var errs validation.Errors
+		if err != nil && !errors.As(err, &errs) {
+			t.Errorf("%s: error %v is not a field error", tc.body, err)
+			continue
+		}
+		var fields []string
+		for _, f := range errs {
+			fields = append(fields, f.Field)
+		}
+		if !slices.Equal(fields, tc.fields) {
+			t.Errorf("%s: field errors %v, want %v", tc.body, fields, tc.fields)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"github.com/lumiforge/docfactory-backend/internal/tenants"
+)
+
+// TenantHandler wires admin HTTP requests to tenant service.
+type TenantHandler struct {
+	service *tenants.TenantService
+}
+
+// NewTenantHandler creates HTTP handler.
+func NewTenantHandler(service *tenants.TenantService) *TenantHandler {
+	return &TenantHandler{service: service}
+}
+
+// ListTenants handles GET /admin/tenants.
+func (h *TenantHandler) ListTenants(w http.ResponseWriter, r *http.Request) {
+	limit, offset := paginationFromRequest(r, 50)
+	opt := tenants.ListOptions{
+		Search:          r.URL.Query().Get("search"),
+		IncludeInactive: r.URL.Query().Get("include_inactive") == "true",
+		Limit:           limit,
+		Offset:          offset,
+	}
+	items, total, err := h.service.ListTenants(r.Context(), opt)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, Page[tenants.Tenant]{Items: items, Total: total, Limit: limit, Offset: offset})
+}
+
+// GetTenant handles GET /admin/tenants/{id}.
+func (h *TenantHandler) GetTenant(w http.ResponseWriter, r *http.Request) {
+	tenant, err := h.service.GetTenant(r.Context(), pathParam(r, "tenantID"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, tenant)
+}
+
+// CreateTenant handles POST /admin/tenants.
+func (h *TenantHandler) CreateTenant(w http.ResponseWriter, r *http.Request) {
+	var payload TenantPayload
+	if !readPayload(w, r, &payload) {
+		return
+	}
+	created, err := h.service.CreateTenant(r.Context(), tenants.Tenant{
+		Name:         payload.Name,
+		Subscription: payload.Subscription,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, created)
+}
+
+// UpdateTenant handles PUT /admin/tenants/{id}.
+func (h *TenantHandler) UpdateTenant(w http.ResponseWriter, r *http.Request) {
+	var payload TenantPayload
+	if !readPayload(w, r, &payload) {
+		return
+	}
+	updated, err := h.service.UpdateTenant(r.Context(), pathParam(r, "tenantID"), func(t *tenants.Tenant) error {
+		if payload.Name != "" {
+			t.Name = payload.Name
+		}
+		if payload.Subscription != "" {
+			t.Subscription = payload.Subscription
+		}
+		return nil
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// SuspendTenant handles POST /admin/tenants/{id}/suspend.
+func (h *TenantHandler) SuspendTenant(w http.ResponseWriter, r *http.Request) {
+	tenant, err := h.service.SuspendTenant(r.Context(), pathParam(r, "tenantID"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, tenant)
+}
+
+// ReactivateTenant handles POST /admin/tenants/{id}/reactivate.
+func (h *TenantHandler) ReactivateTenant(w http.ResponseWriter, r *http.Request) {
+	tenant, err := h.service.ReactivateTenant(r.Context(), pathParam(r, "tenantID"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, tenant)
+}
+
+type TenantPayload struct {
+	Name         string               `json:"name"`
+	Subscription tenants.Subscription `json:"subscription"`
+}
@@ -0,0 +1,165 @@
+package httpapi
+
+import (
+	"net/http"
+
+	"github.com/lumiforge/docfactory-backend/internal/users"
+)
+
+// UserHandler wires HTTP requests to user service.
+type UserHandler struct {
+	service *users.UserService
+}
+
+// NewUserHandler creates HTTP handler.
+func NewUserHandler(service *users.UserService) *UserHandler {
+	return &UserHandler{service: service}
+}
+
+// ListUsers handles GET /users.
+func (h *UserHandler) ListUsers(w http.ResponseWriter, r *http.Request) {
+	actor := currentUser(r)
+	limit, offset := paginationFromRequest(r, 50)
+	opt := users.ListOptions{
+		TenantID:        actor.TenantID,
+		Search:          r.URL.Query().Get("search"),
+		Role:            users.Role(r.URL.Query().Get("role")),
+		IncludeInactive: r.URL.Query().Get("include_inactive") == "true",
+		Limit:           limit,
+		Offset:          offset,
+	}
+	items, total, err := h.service.ListUsers(r.Context(), opt)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, Page[users.User]{Items: items, Total: total, Limit: limit, Offset: offset})
+}
+
+// GetUser handles GET /users/{id}.
+func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	user, err := h.service.GetUser(r.Context(), currentUser(r).TenantID, pathParam(r, "userID"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, user)
+}
+
+// UpdateUser handles PUT /users/{id}.
+func (h *UserHandler) UpdateUser(w http.ResponseWriter, r *http.Request) {
+	var payload UserPayload
+	if !readPayload(w, r, &payload) {
+		return
+	}
+	updated, err := h.service.UpdateProfile(r.Context(), *currentUser(r), pathParam(r, "userID"), func(u *users.User) error {
+		u.FullName = payload.FullName
+		return nil
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// ChangeRole handles PUT /users/{id}/role.
+func (h *UserHandler) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	var payload RolePayload
+	if !readPayload(w, r, &payload) {
+		return
+	}
+	updated, err := h.service.ChangeRole(r.Context(), *currentUser(r), pathParam(r, "userID"), payload.Role)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// DeactivateUser handles POST /users/{id}/deactivate.
+func (h *UserHandler) DeactivateUser(w http.ResponseWriter, r *http.Request) {
+	updated, err := h.service.DeactivateUser(r.Context(), *currentUser(r), pathParam(r, "userID"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// ReactivateUser handles POST /users/{id}/reactivate.
+func (h *UserHandler) ReactivateUser(w http.ResponseWriter, r *http.Request) {
+	updated, err := h.service.ReactivateUser(r.Context(), *currentUser(r), pathParam(r, "userID"))
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, updated)
+}
+
+// InviteUser handles POST /users/invitations.
+func (h *UserHandler) InviteUser(w http.ResponseWriter, r *http.Request) {
+	var payload InvitationPayload
+	if !readPayload(w, r, &payload) {
+		return
+	}
+	invitation, token, err := h.service.InviteUser(r.Context(), *currentUser(r), payload.Email, payload.Role)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, InvitationResult{Invitation: invitation, Token: token})
+}
+
+// InviteOwner handles POST /admin/tenants/{id}/owner-invitation.
+func (h *UserHandler) InviteOwner(w http.ResponseWriter, r *http.Request) {
+	var payload InvitationPayload
+	if !readPayload(w, r, &payload) {
+		return
+	}
+	invitation, token, err := h.service.InviteOwner(r.Context(), pathParam(r, "tenantID"), payload.Email)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, InvitationResult{Invitation: invitation, Token: token})
+}
+
+// AcceptInvitation handles POST /invitations/accept.
+func (h *UserHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var payload AcceptInvitationPayload
+	if !readPayload(w, r, &payload) {
+		return
+	}
+	user, err := h.service.AcceptInvitation(r.Context(), payload.Token, payload.FullName, payload.Password)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, user)
+}
+
+type UserPayload struct {
+	FullName string `json:"full_name"`
+}
+
+type RolePayload struct {
+	Role users.Role `json:"role"`
+}
+
+type InvitationPayload struct {
+	Email string     `json:"email"`
+	Role  users.Role `json:"role"`
+}
+
+type AcceptInvitationPayload struct {
+	Token    string `json:"token"`
+	FullName string `json:"full_name"`
+	Password string `json:"password"`
+}
+
+// InvitationResult carries the invitation token, shown only at creation.
+type InvitationResult struct {
+	Invitation *users.Invitation `json:"invitation"`
+	Token      string            `json:"token"`
+}
@@ -0,0 +1,42 @@
+package idempotency
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// MaxKeyLength bounds client supplied Idempotency-Key values.
+const MaxKeyLength = 255
+
+// DefaultTTL is how long completed responses are replayed.
+const DefaultTTL = 24 * time.Hour
+
+// Response is a stored HTTP response replayed for repeated requests.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Record tracks a request made with an idempotency key. Response is nil while
+// the first request is still being processed.
+type Record struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+	Response    *Response
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+var (
+	// ErrInvalidKey is returned for empty or oversized keys.
+	ErrInvalidKey = errors.New("idempotency: key must be 1-255 characters")
+	// ErrInProgress is returned while the first request with key is running.
+	ErrInProgress = errors.New("idempotency: request with this key is in progress")
+	// ErrMismatch is returned when key is reused with a different request.
+	ErrMismatch = errors.New("idempotency: key was used with a different request")
+	// ErrNotFound is returned when key has no pending record.
+	ErrNotFound = errors.New("idempotency: key not found")
+)
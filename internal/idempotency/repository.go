@@ -0,0 +1,15 @@
+package idempotency
+
+import (
+	"context"
+	"time"
+)
+
+// Repository defines persistence layer for idempotency records.
+type Repository interface {
+	// Reserve stores rec unless an unexpired record with the same scope and
+	// key exists, in which case that record is returned with false.
+	Reserve(ctx context.Context, rec Record, now time.Time) (*Record, bool, error)
+	Complete(ctx context.Context, scope, key string, resp Response) error
+	Release(ctx context.Context, scope, key string) error
+}
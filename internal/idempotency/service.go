@@ -0,0 +1,155 @@
+package idempotency
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// sweepInterval controls how often expired records are evicted.
+const sweepInterval = 10 * time.Minute
+
+// NewInMemoryRepository creates thread-safe repository for prototyping.
+func NewInMemoryRepository() Repository {
+	return &inMemoryRepository{records: make(map[string]Record)}
+}
+
+// Service makes retried requests safe: the first response to a key is stored
+// and replayed to later requests carrying the same key and payload.
+type Service struct {
+	repo Repository
+	ttl  time.Duration
+}
+
+// NewService creates service keeping responses for ttl; non-positive ttl
+// selects DefaultTTL.
+func NewService(repo Repository, ttl time.Duration) *Service {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+	return &Service{repo: repo, ttl: ttl}
+}
+
+// Fingerprint identifies request method, target with query string, content
+// type and body. The content type tells apart bodies that are identical but
+// mean different things, such as merge patches and JSON Patches.
+func Fingerprint(method, target, contentType string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method + " " + target + "\n" + contentType + "\n"))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Begin reserves key within scope for request with fingerprint. It returns
+// the stored response when the key was already completed, nil when the caller
+// should process the request and then call Complete or Release.
+func (s *Service) Begin(ctx context.Context, scope, key, fingerprint string) (*Response, error) {
+	if key == "" || len(key) > MaxKeyLength {
+		return nil, ErrInvalidKey
+	}
+	now := time.Now().UTC()
+	existing, reserved, err := s.repo.Reserve(ctx, Record{
+		Scope:       scope,
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+	if reserved {
+		return nil, nil
+	}
+	if existing.Fingerprint != fingerprint {
+		return nil, ErrMismatch
+	}
+	if existing.Response == nil {
+		return nil, ErrInProgress
+	}
+	return existing.Response, nil
+}
+
+// Complete stores response of reserved key for replay.
+func (s *Service) Complete(ctx context.Context, scope, key string, resp Response) error {
+	return s.repo.Complete(ctx, scope, key, resp)
+}
+
+// Release forgets reserved key so that the request can be retried, used when
+// processing failed without a response worth replaying.
+func (s *Service) Release(ctx context.Context, scope, key string) error {
+	return s.repo.Release(ctx, scope, key)
+}
+
+// inMemoryRepository is prototyping repository with maps.
+type inMemoryRepository struct {
+	records   map[string]Record
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+func recordKey(scope, key string) string {
+	return scope + "\x00" + key
+}
+
+func (r *inMemoryRepository) Reserve(ctx context.Context, rec Record, now time.Time) (*Record, bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sweep(now)
+	id := recordKey(rec.Scope, rec.Key)
+	if existing, ok := r.records[id]; ok && now.Before(existing.ExpiresAt) {
+		return cloneRecord(existing), false, nil
+	}
+	r.records[id] = rec
+	return cloneRecord(rec), true, nil
+}
+
+func (r *inMemoryRepository) Complete(ctx context.Context, scope, key string, resp Response) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id := recordKey(scope, key)
+	rec, ok := r.records[id]
+	if !ok {
+		return ErrNotFound
+	}
+	rec.Response = cloneResponse(&resp)
+	r.records[id] = rec
+	return nil
+}
+
+func (r *inMemoryRepository) Release(ctx context.Context, scope, key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.records, recordKey(scope, key))
+	return nil
+}
+
+func (r *inMemoryRepository) sweep(now time.Time) {
+	if now.Sub(r.lastSweep) < sweepInterval {
+		return
+	}
+	r.lastSweep = now
+	for id, rec := range r.records {
+		if !now.Before(rec.ExpiresAt) {
+			delete(r.records, id)
+		}
+	}
+}
+
+func cloneRecord(rec Record) *Record {
+	rec.Response = cloneResponse(rec.Response)
+	return &rec
+}
+
+func cloneResponse(resp *Response) *Response {
+	if resp == nil {
+		return nil
+	}
+	return &Response{
+		Status: resp.Status,
+		Header: resp.Header.Clone(),
+		Body:   append([]byte(nil), resp.Body...),
+	}
+}
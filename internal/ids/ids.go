@@ -1,12 +1,13 @@
-package templates
+// Package ids generates identifiers of stored records.
+package ids
 
 import (
 	"crypto/rand"
 	"fmt"
 )
 
-// newID creates UUIDv4-like string without third party dependency.
-func newID() string {
+// New creates UUIDv4-like string without third party dependency.
+func New() string {
 	b := make([]byte, 16)
 	if _, err := rand.Read(b); err != nil {
 		panic(fmt.Sprintf("failed to generate id: %v", err))
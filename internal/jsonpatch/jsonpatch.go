@@ -0,0 +1,344 @@
+// Package jsonpatch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents without external deps.
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidPatch is returned for malformed patch documents.
+	ErrInvalidPatch = errors.New("jsonpatch: invalid patch")
+	// ErrConflict is returned when patch cannot be applied to the document,
+	// for example because a path does not exist or a test operation fails.
+	ErrConflict = errors.New("jsonpatch: patch does not apply")
+)
+
+// Operation is a single JSON Patch operation.
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// MergePatch applies RFC 7396 merge patch to doc: object members of patch
+// replace members of doc recursively and null members remove them.
+func MergePatch(doc, patch []byte) ([]byte, error) {
+	var target, p any
+	if err := decode(doc, &target); err != nil {
+		return nil, fmt.Errorf("decode document: %w", err)
+	}
+	if err := decode(patch, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	return json.Marshal(merge(target, p))
+}
+
+func merge(target, patch any) any {
+	patchObj, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]any)
+	if !ok {
+		targetObj = map[string]any{}
+	}
+	for key, value := range patchObj {
+		if value == nil {
+			delete(targetObj, key)
+			continue
+		}
+		targetObj[key] = merge(targetObj[key], value)
+	}
+	return targetObj
+}
+
+// Apply applies RFC 6902 patch, a JSON array of operations, to doc. The
+// operations are applied in order and the patch fails as a whole.
+func Apply(doc, patch []byte) ([]byte, error) {
+	var root any
+	if err := decode(doc, &root); err != nil {
+		return nil, fmt.Errorf("decode document: %w", err)
+	}
+	var ops []Operation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+	}
+	for i, op := range ops {
+		var err error
+		if root, err = applyOperation(root, op); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return json.Marshal(root)
+}
+
+func applyOperation(root any, op Operation) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case "add", "replace", "test":
+		if op.Value == nil {
+			return nil, fmt.Errorf("%w: value is required", ErrInvalidPatch)
+		}
+		var value any
+		if err := decode(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidPatch, err)
+		}
+		switch op.Op {
+		case "add":
+			return add(root, path, value)
+		case "replace":
+			if _, err := get(root, path); err != nil {
+				return nil, err
+			}
+			if len(path) == 0 {
+				return value, nil
+			}
+			if root, err = remove(root, path); err != nil {
+				return nil, err
+			}
+			return add(root, path, value)
+		default:
+			current, err := get(root, path)
+			if err != nil {
+				return nil, err
+			}
+			if !equal(current, value) {
+				return nil, fmt.Errorf("%w: test failed", ErrConflict)
+			}
+			return root, nil
+		}
+	case "remove":
+		return remove(root, path)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(root, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "copy" {
+			return add(root, path, clone(value))
+		}
+		if strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, fmt.Errorf("%w: cannot move value into its own child", ErrInvalidPatch)
+		}
+		if root, err = remove(root, from); err != nil {
+			return nil, err
+		}
+		return add(root, path, value)
+	default:
+		return nil, fmt.Errorf("%w: unknown op %q", ErrInvalidPatch, op.Op)
+	}
+}
+
+// parsePointer splits RFC 6901 JSON Pointer into unescaped reference tokens.
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("%w: path %q must start with /", ErrInvalidPatch, pointer)
+	}
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens, nil
+}
+
+func get(node any, path []string) (any, error) {
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]any:
+			value, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("%w: member %q not found", ErrConflict, token)
+			}
+			node = value
+		case []any:
+			i, err := index(token, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("%w: %q is not a container", ErrConflict, token)
+		}
+	}
+	return node, nil
+}
+
+// set replaces value at existing path; it is used to store resized arrays.
+func set(root any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parent, err := get(root, path[:len(path)-1])
+	if err != nil {
+		return nil, err
+	}
+	last := path[len(path)-1]
+	switch p := parent.(type) {
+	case map[string]any:
+		p[last] = value
+	case []any:
+		i, err := index(last, len(p)-1)
+		if err != nil {
+			return nil, err
+		}
+		p[i] = value
+	}
+	return root, nil
+}
+
+func add(root any, path []string, value any) (any, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	parentPath, last := path[:len(path)-1], path[len(path)-1]
+	parent, err := get(root, parentPath)
+	if err != nil {
+		return nil, err
+	}
+	switch p := parent.(type) {
+	case map[string]any:
+		p[last] = value
+		return root, nil
+	case []any:
+		i := len(p)
+		if last != "-" {
+			if i, err = index(last, len(p)); err != nil {
+				return nil, err
+			}
+		}
+		grown := make([]any, 0, len(p)+1)
+		grown = append(grown, p[:i]...)
+		grown = append(grown, value)
+		grown = append(grown, p[i:]...)
+		return set(root, parentPath, grown)
+	default:
+		return nil, fmt.Errorf("%w: parent of %q is not a container", ErrConflict, last)
+	}
+}
+
+func remove(root any, path []string) (any, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("%w: cannot remove document root", ErrInvalidPatch)
+	}
+	parentPath, last := path[:len(path)-1], path[len(path)-1]
+	parent, err := get(root, parentPath)
+	if err != nil {
+		return nil, err
+	}
+	switch p := parent.(type) {
+	case map[string]any:
+		if _, ok := p[last]; !ok {
+			return nil, fmt.Errorf("%w: member %q not found", ErrConflict, last)
+		}
+		delete(p, last)
+		return root, nil
+	case []any:
+		i, err := index(last, len(p)-1)
+		if err != nil {
+			return nil, err
+		}
+		shrunk := append(append(make([]any, 0, len(p)-1), p[:i]...), p[i+1:]...)
+		return set(root, parentPath, shrunk)
+	default:
+		return nil, fmt.Errorf("%w: parent of %q is not a container", ErrConflict, last)
+	}
+}
+
+// index parses array index token no greater than limit.
+func index(token string, limit int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.TrimLeft(token, "0123456789") != "" {
+		return 0, fmt.Errorf("%w: %q is not an array index", ErrConflict, token)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i > limit {
+		return 0, fmt.Errorf("%w: index %s out of range", ErrConflict, token)
+	}
+	return i, nil
+}
+
+func decode(data []byte, v *any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("unexpected data after JSON value")
+	}
+	return nil
+}
+
+func clone(v any) any {
+	switch n := v.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(n))
+		for k, val := range n {
+			c[k] = clone(val)
+		}
+		return c
+	case []any:
+		c := make([]any, len(n))
+		for i, val := range n {
+			c[i] = clone(val)
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+// equal compares decoded JSON values; numbers compare by value.
+func equal(a, b any) bool {
+	switch x := a.(type) {
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			w, ok := y[k]
+			if !ok || !equal(v, w) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		y, ok := b.([]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !equal(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fx, errX := x.Float64()
+		fy, errY := y.Float64()
+		return errX == nil && errY == nil && fx == fy
+	default:
+		return a == b
+	}
+}
@@ -0,0 +1,242 @@
+// Package locale handles BCP 47 language tags such as "kk-KZ" and formats
+// numbers, dates and currency amounts the way readers of a locale expect.
+// Conventions follow CLDR for the languages of Russia, Kazakhstan and the
+// EU; other languages are formatted neutrally.
+package locale
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidTag is returned for malformed language tags.
+var ErrInvalidTag = errors.New("locale: invalid language tag")
+
+// tagPattern matches language with optional script and region, the subset
+// of BCP 47 used for document locales.
+var tagPattern = regexp.MustCompile(`^(?i)([a-z]{2,3})(?:-([a-z]{4}))?(?:-([a-z]{2}|[0-9]{3}))?$`)
+
+// Canonical returns tag with canonical case, e.g. "kk-KZ" for "KK-kz".
+func Canonical(tag string) (string, error) {
+	m := tagPattern.FindStringSubmatch(tag)
+	if m == nil {
+		return "", ErrInvalidTag
+	}
+	canonical := strings.ToLower(m[1])
+	if m[2] != "" {
+		canonical += "-" + strings.ToUpper(m[2][:1]) + strings.ToLower(m[2][1:])
+	}
+	if m[3] != "" {
+		canonical += "-" + strings.ToUpper(m[3])
+	}
+	return canonical, nil
+}
+
+// Parent returns tag without its last subtag, empty for a bare language.
+func Parent(tag string) string {
+	if i := strings.LastIndexByte(tag, '-'); i >= 0 {
+		return tag[:i]
+	}
+	return ""
+}
+
+// Chain lists locales consulted for tag in order of preference: the tag,
+// its explicit fallbacks and then its parent, recursively, each locale once.
+// For "kk-KZ" with fallbacks {"kk": ["ru"]} it is kk-KZ, kk, ru.
+func Chain(tag string, fallbacks map[string][]string) []string {
+	var chain []string
+	seen := map[string]bool{}
+	var visit func(string)
+	visit = func(t string) {
+		if t == "" || seen[t] {
+			return
+		}
+		seen[t] = true
+		chain = append(chain, t)
+		for _, f := range fallbacks[t] {
+			visit(f)
+		}
+		visit(Parent(t))
+	}
+	visit(tag)
+	return chain
+}
+
+// Locale formats values by conventions of a language. The zero Locale is
+// neutral: numbers are written as in JSON and dates as ISO 8601.
+type Locale struct {
+	Tag        string
+	decimal    string
+	group      string
+	dateLayout string
+	// symbolFirst puts currency symbol before the amount; symbolSpace
+	// separates them by a no-break space.
+	symbolFirst bool
+	symbolSpace bool
+}
+
+// Separators used by CLDR: no-break space and narrow no-break space.
+const (
+	nbsp  = "\u00a0"
+	nnbsp = "\u202f"
+)
+
+// Common short date layouts: day.month.year and day/month/year.
+const (
+	dmy      = "02.01.2006"
+	dmySlash = "02/01/2006"
+)
+
+var locales = map[string]Locale{
+	"en":    {decimal: ".", group: ",", dateLayout: "01/02/2006", symbolFirst: true},
+	"en-GB": {decimal: ".", group: ",", dateLayout: dmySlash, symbolFirst: true},
+	"en-IE": {decimal: ".", group: ",", dateLayout: dmySlash, symbolFirst: true},
+	"ru":    {decimal: ",", group: nbsp, dateLayout: dmy, symbolSpace: true},
+	"kk":    {decimal: ",", group: nbsp, dateLayout: dmy, symbolSpace: true},
+	"uk":    {decimal: ",", group: nbsp, dateLayout: dmy, symbolSpace: true},
+	"be":    {decimal: ",", group: nbsp, dateLayout: dmy, symbolSpace: true},
+	"bg":    {decimal: ",", group: nbsp, dateLayout: dmy, symbolSpace: true},
+	"cs":    {decimal: ",", group: nbsp, dateLayout: dmy, symbolSpace: true},
+	"da":    {decimal: ",", group: ".", dateLayout: dmy, symbolSpace: true},
+	"de":    {decimal: ",", group: ".", dateLayout: dmy, symbolSpace: true},
+	"de-AT": {decimal: ",", group: nbsp, dateLayout: dmy, symbolFirst: true, symbolSpace: true},
+	"el":    {decimal: ",", group: ".", dateLayout: dmySlash, symbolSpace: true},
+	"es":    {decimal: ",", group: ".", dateLayout: dmySlash, symbolSpace: true},
+	"et":    {decimal: ",", group: nbsp, dateLayout: dmy, symbolSpace: true},
+	"fi":    {decimal: ",", group: nbsp, dateLayout: dmy, symbolSpace: true},
+	"fr":    {decimal: ",", group: nnbsp, dateLayout: dmySlash, symbolSpace: true},
+	"ga":    {decimal: ".", group: ",", dateLayout: dmySlash, symbolFirst: true},
+	"hr":    {decimal: ",", group: ".", dateLayout: dmy + ".", symbolSpace: true},
+	"hu":    {decimal: ",", group: nbsp, dateLayout: "2006. 01. 02.", symbolSpace: true},
+	"it":    {decimal: ",", group: ".", dateLayout: dmySlash, symbolSpace: true},
+	"lt":    {decimal: ",", group: nbsp, dateLayout: "2006-01-02", symbolSpace: true},
+	"lv":    {decimal: ",", group: nbsp, dateLayout: dmy, symbolSpace: true},
+	"mt":    {decimal: ".", group: ",", dateLayout: dmySlash, symbolFirst: true},
+	"nl":    {decimal: ",", group: ".", dateLayout: "02-01-2006", symbolFirst: true, symbolSpace: true},
+	"pl":    {decimal: ",", group: nbsp, dateLayout: dmy, symbolSpace: true},
+	"pt":    {decimal: ",", group: nbsp, dateLayout: dmySlash, symbolSpace: true},
+	"ro":    {decimal: ",", group: ".", dateLayout: dmy, symbolSpace: true},
+	"sk":    {decimal: ",", group: nbsp, dateLayout: dmy, symbolSpace: true},
+	"sl":    {decimal: ",", group: ".", dateLayout: dmy, symbolSpace: true},
+	"sv":    {decimal: ",", group: nbsp, dateLayout: "2006-01-02", symbolSpace: true},
+}
+
+// Lookup returns conventions of tag, or of its nearest parent with known
+// conventions. Unknown languages get neutral formatting.
+func Lookup(tag string) Locale {
+	for t := tag; t != ""; t = Parent(t) {
+		if l, ok := locales[t]; ok {
+			l.Tag = tag
+			return l
+		}
+	}
+	return Locale{Tag: tag}
+}
+
+// Number formats v with the decimal separator and digit grouping of the
+// locale, keeping every significant digit.
+func (l Locale) Number(v float64) string {
+	return l.digits(strconv.FormatFloat(v, 'f', -1, 64))
+}
+
+// Date formats t in the short date format of the locale.
+func (l Locale) Date(t time.Time) string {
+	if l.dateLayout == "" {
+		return t.Format(time.DateOnly)
+	}
+	return t.Format(l.dateLayout)
+}
+
+// Currency formats amount in ISO 4217 currency code with the minor units of
+// the currency. Neutral formatting writes the code after the amount.
+func (l Locale) Currency(amount float64, code string) string {
+	decimals := 2
+	if d, ok := minorUnits[code]; ok {
+		decimals = d
+	}
+	digits := l.digits(strconv.FormatFloat(amount, 'f', decimals, 64))
+	if l.decimal == "" {
+		return digits + " " + code
+	}
+	symbol, ok := symbols[code]
+	if !ok {
+		symbol = code
+	}
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	space := ""
+	if l.symbolSpace || !ok {
+		space = nbsp
+	}
+	if l.symbolFirst {
+		return sign + symbol + space + digits
+	}
+	return sign + digits + space + symbol
+}
+
+// digits rewrites number formatted by strconv with locale separators.
+func (l Locale) digits(s string) string {
+	if l.decimal == "" {
+		return s
+	}
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	integer, fraction, _ := strings.Cut(s, ".")
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, r := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(l.group)
+		}
+		b.WriteRune(r)
+	}
+	if fraction != "" {
+		b.WriteString(l.decimal)
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+// symbols of currencies common in supported locales; other currencies are
+// written as their code.
+var symbols = map[string]string{
+	"EUR": "€",
+	"USD": "$",
+	"GBP": "£",
+	"RUB": "₽",
+	"KZT": "₸",
+	"UAH": "₴",
+	"PLN": "zł",
+	"CZK": "Kč",
+	"HUF": "Ft",
+	"RON": "lei",
+	"BGN": "лв.",
+	"SEK": "kr",
+	"DKK": "kr.",
+	"JPY": "¥",
+}
+
+// minorUnits lists currencies without two decimal places.
+var minorUnits = map[string]int{
+	"JPY": 0,
+	"KRW": 0,
+	"ISK": 0,
+	"BHD": 3,
+	"KWD": 3,
+	"OMR": 3,
+}
+
+// currencyPattern matches ISO 4217 alphabetic codes.
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ValidCurrency reports whether code is syntactically an ISO 4217 code.
+func ValidCurrency(code string) bool {
+	return currencyPattern.MatchString(code)
+}
@@ -0,0 +1,328 @@
+package pdfsign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"fmt"
+	"slices"
+)
+
+// Object identifiers of CMS (RFC 5652) and ESS (RFC 5035) structures.
+var (
+	oidData                 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData           = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidContentType          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningTime          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 5}
+	oidSigningCertificateV2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+
+	oidRSA             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidSHA256WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidSHA384WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 12}
+	oidSHA512WithRSA   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 13}
+	oidECDSAWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+	oidECDSAWithSHA384 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 3}
+	oidECDSAWithSHA512 = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 4}
+)
+
+// digestAlgorithms maps digest algorithm identifiers to hashes.
+var digestAlgorithms = map[string]crypto.Hash{
+	"2.16.840.1.101.3.4.2.1": crypto.SHA256,
+	"2.16.840.1.101.3.4.2.2": crypto.SHA384,
+	"2.16.840.1.101.3.4.2.3": crypto.SHA512,
+}
+
+func digestOID(h crypto.Hash) asn1.ObjectIdentifier {
+	switch h {
+	case crypto.SHA384:
+		return asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	case crypto.SHA512:
+		return asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+	}
+	return asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+}
+
+// der builds DER elements. Marshalling raw values cannot fail, so errors
+// are dropped.
+func der(class, tag int, compound bool, content ...[]byte) []byte {
+	b, _ := asn1.Marshal(asn1.RawValue{Class: class, Tag: tag, IsCompound: compound, Bytes: bytes.Join(content, nil)})
+	return b
+}
+
+func sequence(content ...[]byte) []byte {
+	return der(asn1.ClassUniversal, asn1.TagSequence, true, content...)
+}
+
+// set encodes SET OF content, sorted as DER requires.
+func set(content ...[]byte) []byte {
+	sorted := slices.Clone(content)
+	slices.SortFunc(sorted, bytes.Compare)
+	return der(asn1.ClassUniversal, asn1.TagSet, true, sorted...)
+}
+
+func explicit(tag int, content ...[]byte) []byte {
+	return der(asn1.ClassContextSpecific, tag, true, content...)
+}
+
+func marshal(v any) []byte {
+	b, err := asn1.Marshal(v)
+	if err != nil {
+		panic(fmt.Sprintf("pdfsign: marshal %T: %v", v, err))
+	}
+	return b
+}
+
+func attribute(oid asn1.ObjectIdentifier, value []byte) []byte {
+	return sequence(marshal(oid), set(value))
+}
+
+// signatureAlgorithm returns the CMS signature algorithm identifier of key.
+func signatureAlgorithm(key crypto.PublicKey, h crypto.Hash) ([]byte, error) {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		oid := map[crypto.Hash]asn1.ObjectIdentifier{crypto.SHA256: oidSHA256WithRSA, crypto.SHA384: oidSHA384WithRSA, crypto.SHA512: oidSHA512WithRSA}[h]
+		return sequence(marshal(oid), asn1.NullBytes), nil
+	case *ecdsa.PublicKey:
+		oid := map[crypto.Hash]asn1.ObjectIdentifier{crypto.SHA256: oidECDSAWithSHA256, crypto.SHA384: oidECDSAWithSHA384, crypto.SHA512: oidECDSAWithSHA512}[h]
+		return sequence(marshal(oid)), nil
+	}
+	return nil, fmt.Errorf("%w: %T keys", ErrUnsupported, key)
+}
+
+// signedData returns a detached CMS SignedData of digest, the hash of the
+// signed byte ranges, as PAdES baseline B-B requires it: the content type,
+// message digest and ESS signing-certificate-v2 are signed attributes, and
+// signing time is left to the signature dictionary.
+func signedData(digest []byte, s Signer) ([]byte, error) {
+	const h = crypto.SHA256
+	cert := s.Certificate
+	signatureAlg, err := signatureAlgorithm(cert.PublicKey, h)
+	if err != nil {
+		return nil, err
+	}
+	digestAlg := sequence(marshal(digestOID(h)))
+	serial := marshal(cert.SerialNumber)
+	certHash := h.New()
+	certHash.Write(cert.Raw)
+	// ESSCertIDv2 with the default SHA-256 hash algorithm left out and
+	// issuer serial naming the issuer as directory name.
+	essCertID := sequence(marshal(certHash.Sum(nil)), sequence(sequence(explicit(4, cert.RawIssuer)), serial))
+	attrs := set(
+		attribute(oidContentType, marshal(oidData)),
+		attribute(oidMessageDigest, marshal(digest)),
+		attribute(oidSigningCertificateV2, sequence(sequence(essCertID))),
+	)
+	attrsHash := h.New()
+	attrsHash.Write(attrs)
+	signature, err := s.Key.Sign(rand.Reader, attrsHash.Sum(nil), h)
+	if err != nil {
+		return nil, fmt.Errorf("pdfsign: sign attributes: %w", err)
+	}
+	// Signed attributes are signed as SET but stored with implicit tag [0].
+	implicitAttrs := slices.Clone(attrs)
+	implicitAttrs[0] = 0xa0
+	signerInfo := sequence(
+		marshal(1),
+		sequence(cert.RawIssuer, serial),
+		digestAlg,
+		implicitAttrs,
+		signatureAlg,
+		marshal(signature),
+	)
+	certs := [][]byte{cert.Raw}
+	for _, c := range s.Chain {
+		certs = append(certs, c.Raw)
+	}
+	content := sequence(
+		marshal(1),
+		set(digestAlg),
+		sequence(marshal(oidData)),
+		der(asn1.ClassContextSpecific, 0, true, certs...),
+		set(signerInfo),
+	)
+	return sequence(marshal(oidSignedData), explicit(0, content)), nil
+}
+
+// signerInfo is a parsed CMS SignerInfo.
+type signerInfo struct {
+	issuer, serial, keyID []byte
+	digest                crypto.Hash
+	// attrs holds signed attributes encoded as SET for verification.
+	attrs     []byte
+	attrValue map[string][]byte
+	algorithm asn1.ObjectIdentifier
+	signature []byte
+}
+
+// parsedSignedData is the part of CMS SignedData signature validation
+// needs.
+type parsedSignedData struct {
+	certificates []*x509.Certificate
+	signer       signerInfo
+}
+
+// elements splits DER content into its elements.
+func elements(content []byte) ([]asn1.RawValue, error) {
+	var out []asn1.RawValue
+	for len(content) > 0 {
+		var v asn1.RawValue
+		rest, err := asn1.Unmarshal(content, &v)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, v)
+		content = rest
+	}
+	return out, nil
+}
+
+// parseSignedData parses ContentInfo with SignedData of one signer. Trailing
+// bytes, such as padding of the signature placeholder, are ignored.
+func parseSignedData(data []byte) (*parsedSignedData, error) {
+	var info struct {
+		ContentType asn1.ObjectIdentifier
+		Content     asn1.RawValue
+	}
+	if _, err := asn1.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("parse CMS: %w", err)
+	}
+	if !info.ContentType.Equal(oidSignedData) {
+		return nil, fmt.Errorf("CMS content type %s is not signed data", info.ContentType)
+	}
+	var content asn1.RawValue
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &content); err != nil {
+		return nil, fmt.Errorf("parse CMS signed data: %w", err)
+	}
+	fields, err := elements(content.Bytes)
+	if err != nil || len(fields) < 4 {
+		return nil, fmt.Errorf("parse CMS signed data: malformed")
+	}
+	sd := &parsedSignedData{}
+	for _, f := range fields[3 : len(fields)-1] {
+		if f.Class == asn1.ClassContextSpecific && f.Tag == 0 {
+			if sd.certificates, err = x509.ParseCertificates(f.Bytes); err != nil {
+				return nil, fmt.Errorf("parse CMS certificates: %w", err)
+			}
+		}
+	}
+	signers, err := elements(fields[len(fields)-1].Bytes)
+	if err != nil || len(signers) != 1 {
+		return nil, fmt.Errorf("CMS signed data must have exactly one signer")
+	}
+	if sd.signer, err = parseSignerInfo(signers[0].Bytes); err != nil {
+		return nil, err
+	}
+	return sd, nil
+}
+
+func parseSignerInfo(content []byte) (signerInfo, error) {
+	si := signerInfo{attrValue: map[string][]byte{}}
+	fields, err := elements(content)
+	if err != nil || len(fields) < 5 {
+		return si, fmt.Errorf("parse CMS signer info: malformed")
+	}
+	switch sid := fields[1]; {
+	case sid.Class == asn1.ClassUniversal && sid.Tag == asn1.TagSequence:
+		parts, err := elements(sid.Bytes)
+		if err != nil || len(parts) != 2 {
+			return si, fmt.Errorf("parse CMS signer identifier: malformed")
+		}
+		si.issuer, si.serial = parts[0].FullBytes, parts[1].FullBytes
+	case sid.Class == asn1.ClassContextSpecific && sid.Tag == 0:
+		si.keyID = sid.Bytes
+	default:
+		return si, fmt.Errorf("parse CMS signer identifier: unknown form")
+	}
+	var digestAlg struct {
+		Algorithm asn1.ObjectIdentifier
+		Params    asn1.RawValue `asn1:"optional"`
+	}
+	if _, err := asn1.Unmarshal(fields[2].FullBytes, &digestAlg); err != nil {
+		return si, fmt.Errorf("parse CMS digest algorithm: %w", err)
+	}
+	var ok bool
+	if si.digest, ok = digestAlgorithms[digestAlg.Algorithm.String()]; !ok {
+		return si, fmt.Errorf("digest algorithm %s is not supported", digestAlg.Algorithm)
+	}
+	rest := fields[3:]
+	if f := rest[0]; f.Class == asn1.ClassContextSpecific && f.Tag == 0 {
+		si.attrs = slices.Clone(f.FullBytes)
+		si.attrs[0] = 0x31
+		attrs, err := elements(f.Bytes)
+		if err != nil {
+			return si, fmt.Errorf("parse CMS signed attributes: %w", err)
+		}
+		for _, a := range attrs {
+			var attr struct {
+				Type   asn1.ObjectIdentifier
+				Values asn1.RawValue
+			}
+			if _, err := asn1.Unmarshal(a.FullBytes, &attr); err != nil {
+				return si, fmt.Errorf("parse CMS signed attribute: %w", err)
+			}
+			values, err := elements(attr.Values.Bytes)
+			if err != nil || len(values) != 1 {
+				return si, fmt.Errorf("CMS signed attribute %s must have one value", attr.Type)
+			}
+			si.attrValue[attr.Type.String()] = values[0].FullBytes
+		}
+		rest = rest[1:]
+	}
+	if len(rest) < 2 {
+		return si, fmt.Errorf("parse CMS signer info: malformed")
+	}
+	var sigAlg struct {
+		Algorithm asn1.ObjectIdentifier
+		Params    asn1.RawValue `asn1:"optional"`
+	}
+	if _, err := asn1.Unmarshal(rest[0].FullBytes, &sigAlg); err != nil {
+		return si, fmt.Errorf("parse CMS signature algorithm: %w", err)
+	}
+	si.algorithm = sigAlg.Algorithm
+	if _, err := asn1.Unmarshal(rest[1].FullBytes, &si.signature); err != nil {
+		return si, fmt.Errorf("parse CMS signature: %w", err)
+	}
+	return si, nil
+}
+
+// find returns the certificate of the signer among certs.
+func (si signerInfo) find(certs []*x509.Certificate) *x509.Certificate {
+	for _, c := range certs {
+		if si.keyID != nil && bytes.Equal(c.SubjectKeyId, si.keyID) {
+			return c
+		}
+		if si.issuer != nil && bytes.Equal(c.RawIssuer, si.issuer) && bytes.Equal(marshal(c.SerialNumber), si.serial) {
+			return c
+		}
+	}
+	return nil
+}
+
+// verifySignature checks the signature over signed attributes with cert.
+func (si signerInfo) verifySignature(cert *x509.Certificate) error {
+	h := si.digest.New()
+	h.Write(si.attrs)
+	hashed := h.Sum(nil)
+	switch key := cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		switch {
+		case si.algorithm.Equal(oidRSA), si.algorithm.Equal(oidSHA256WithRSA),
+			si.algorithm.Equal(oidSHA384WithRSA), si.algorithm.Equal(oidSHA512WithRSA):
+			return rsa.VerifyPKCS1v15(key, si.digest, hashed, si.signature)
+		}
+	case *ecdsa.PublicKey:
+		switch {
+		case si.algorithm.Equal(oidECDSAWithSHA256), si.algorithm.Equal(oidECDSAWithSHA384), si.algorithm.Equal(oidECDSAWithSHA512):
+			if !ecdsa.VerifyASN1(key, hashed, si.signature) {
+				return fmt.Errorf("ECDSA verification failure")
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("signature algorithm %s is not supported for the signer key", si.algorithm)
+}
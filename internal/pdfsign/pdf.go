@@ -0,0 +1,386 @@
+package pdfsign
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// document is a PDF with classic cross-reference tables, as written by the
+// renderer. Objects are located through the tables; dictionaries are read as
+// text, which suffices for the catalog and page objects signing updates.
+type document struct {
+	data []byte
+	// offsets maps object numbers to offsets of their latest revision.
+	offsets map[int]int
+	size    int
+	root    int
+	info    string
+	xref    int
+}
+
+var (
+	refPattern     = regexp.MustCompile(`(\d+)\s+(\d+)\s+R`)
+	rootPattern    = regexp.MustCompile(`/Root\s+(\d+)\s+\d+\s+R`)
+	infoPattern    = regexp.MustCompile(`/Info\s+(\d+\s+\d+\s+R)`)
+	sizePattern    = regexp.MustCompile(`/Size\s+(\d+)`)
+	prevPattern    = regexp.MustCompile(`/Prev\s+(\d+)`)
+	pagesPattern   = regexp.MustCompile(`/Pages\s+(\d+)\s+\d+\s+R`)
+	kidsPattern    = regexp.MustCompile(`/Kids\s*\[([^\]]*)\]`)
+	mediaPattern   = regexp.MustCompile(`/MediaBox\s*\[\s*([-\d.]+)\s+([-\d.]+)\s+([-\d.]+)\s+([-\d.]+)\s*\]`)
+	trimPattern    = regexp.MustCompile(`/TrimBox\s*\[\s*([-\d.]+)\s+([-\d.]+)\s+([-\d.]+)\s+([-\d.]+)\s*\]`)
+	pageTypeRegexp = regexp.MustCompile(`/Type\s*/Page\b`)
+)
+
+// parse reads cross-reference tables and trailers of data, latest first.
+func parse(data []byte) (*document, error) {
+	if !bytes.HasPrefix(data, []byte("%PDF-")) {
+		return nil, fmt.Errorf("%w: missing PDF header", ErrInvalidPDF)
+	}
+	i := bytes.LastIndex(data, []byte("startxref"))
+	if i < 0 {
+		return nil, fmt.Errorf("%w: missing startxref", ErrInvalidPDF)
+	}
+	fields := strings.Fields(string(data[i+len("startxref") : min(len(data), i+len("startxref")+32)]))
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("%w: missing startxref offset", ErrInvalidPDF)
+	}
+	xref, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid startxref offset", ErrInvalidPDF)
+	}
+	d := &document{data: data, offsets: map[int]int{}, xref: xref}
+	seen := map[int]bool{}
+	for offset, first := xref, true; ; first = false {
+		if offset < 0 || offset >= len(data) || seen[offset] {
+			return nil, fmt.Errorf("%w: invalid cross-reference offset %d", ErrInvalidPDF, offset)
+		}
+		seen[offset] = true
+		trailer, err := d.readXref(offset)
+		if err != nil {
+			return nil, err
+		}
+		if first {
+			m := rootPattern.FindStringSubmatch(trailer)
+			if m == nil {
+				return nil, fmt.Errorf("%w: trailer has no /Root", ErrInvalidPDF)
+			}
+			d.root, _ = strconv.Atoi(m[1])
+			if m := sizePattern.FindStringSubmatch(trailer); m != nil {
+				d.size, _ = strconv.Atoi(m[1])
+			}
+			if m := infoPattern.FindStringSubmatch(trailer); m != nil {
+				d.info = m[1]
+			}
+		}
+		m := prevPattern.FindStringSubmatch(trailer)
+		if m == nil {
+			break
+		}
+		offset, _ = strconv.Atoi(m[1])
+	}
+	for n := range d.offsets {
+		d.size = max(d.size, n+1)
+	}
+	return d, nil
+}
+
+// readXref reads the cross-reference table at offset and returns its
+// trailer dictionary. Entries of newer tables take precedence.
+func (d *document) readXref(offset int) (string, error) {
+	rest := bytes.TrimLeft(d.data[offset:], " \t\r\n")
+	if !bytes.HasPrefix(rest, []byte("xref")) {
+		return "", fmt.Errorf("%w: cross-reference streams", ErrUnsupported)
+	}
+	end := bytes.Index(rest, []byte("trailer"))
+	if end < 0 {
+		return "", fmt.Errorf("%w: missing trailer", ErrInvalidPDF)
+	}
+	fields := strings.Fields(string(rest[len("xref"):end]))
+	for len(fields) > 0 {
+		if len(fields) < 2 {
+			return "", fmt.Errorf("%w: truncated cross-reference table", ErrInvalidPDF)
+		}
+		start, err1 := strconv.Atoi(fields[0])
+		count, err2 := strconv.Atoi(fields[1])
+		if err1 != nil || err2 != nil || count < 0 || len(fields) < 2+3*count {
+			return "", fmt.Errorf("%w: malformed cross-reference table", ErrInvalidPDF)
+		}
+		for i := range count {
+			entry := fields[2+3*i : 5+3*i]
+			n := start + i
+			if _, ok := d.offsets[n]; ok || entry[2] != "n" {
+				continue
+			}
+			at, err := strconv.Atoi(entry[0])
+			if err != nil || at >= len(d.data) {
+				return "", fmt.Errorf("%w: malformed cross-reference entry of object %d", ErrInvalidPDF, n)
+			}
+			d.offsets[n] = at
+		}
+		fields = fields[2+3*count:]
+	}
+	trailer := rest[end:]
+	if i := bytes.Index(trailer, []byte("startxref")); i >= 0 {
+		trailer = trailer[:i]
+	}
+	return string(trailer), nil
+}
+
+// object returns the body of object n, between "obj" and "endobj". Stream
+// data is cut off.
+func (d *document) object(n int) (string, error) {
+	offset, ok := d.offsets[n]
+	if !ok {
+		return "", fmt.Errorf("%w: object %d not found", ErrInvalidPDF, n)
+	}
+	rest := d.data[offset:]
+	start := bytes.Index(rest, []byte("obj"))
+	end := bytes.Index(rest, []byte("endobj"))
+	if start < 0 || end < start {
+		return "", fmt.Errorf("%w: object %d is malformed", ErrInvalidPDF, n)
+	}
+	body := rest[start+len("obj") : end]
+	if i := bytes.Index(body, []byte("stream")); i >= 0 {
+		body = body[:i]
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// dictionary returns object n, which must be a dictionary.
+func (d *document) dictionary(n int) (string, error) {
+	body, err := d.object(n)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(body, "<<") || !strings.HasSuffix(body, ">>") {
+		return "", fmt.Errorf("%w: object %d is not a dictionary", ErrInvalidPDF, n)
+	}
+	return body, nil
+}
+
+// pages lists object numbers of pages in document order.
+func (d *document) pages() ([]int, error) {
+	catalog, err := d.dictionary(d.root)
+	if err != nil {
+		return nil, err
+	}
+	m := pagesPattern.FindStringSubmatch(catalog)
+	if m == nil {
+		return nil, fmt.Errorf("%w: catalog has no page tree", ErrInvalidPDF)
+	}
+	root, _ := strconv.Atoi(m[1])
+	var pages []int
+	visited := map[int]bool{}
+	var walk func(n int) error
+	walk = func(n int) error {
+		if visited[n] {
+			return fmt.Errorf("%w: page tree has a cycle", ErrInvalidPDF)
+		}
+		visited[n] = true
+		node, err := d.dictionary(n)
+		if err != nil {
+			return err
+		}
+		kids := kidsPattern.FindStringSubmatch(node)
+		if kids == nil {
+			if pageTypeRegexp.MatchString(node) {
+				pages = append(pages, n)
+			}
+			return nil
+		}
+		for _, ref := range refPattern.FindAllStringSubmatch(kids[1], -1) {
+			kid, _ := strconv.Atoi(ref[1])
+			if err := walk(kid); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := walk(root); err != nil {
+		return nil, err
+	}
+	return pages, nil
+}
+
+// pageBox returns the trim box of page n, which excludes bleed, or else its
+// media box, inherited from the page tree root when the page has none.
+func (d *document) pageBox(n int) ([4]float64, error) {
+	var box [4]float64
+	page, err := d.dictionary(n)
+	if err != nil {
+		return box, err
+	}
+	m := trimPattern.FindStringSubmatch(page)
+	if m == nil {
+		m = mediaPattern.FindStringSubmatch(page)
+	}
+	if m == nil {
+		catalog, err := d.dictionary(d.root)
+		if err != nil {
+			return box, err
+		}
+		if p := pagesPattern.FindStringSubmatch(catalog); p != nil {
+			root, _ := strconv.Atoi(p[1])
+			tree, err := d.dictionary(root)
+			if err != nil {
+				return box, err
+			}
+			m = mediaPattern.FindStringSubmatch(tree)
+		}
+	}
+	if m == nil {
+		return box, fmt.Errorf("%w: page %d has no media box", ErrInvalidPDF, n)
+	}
+	for i := range box {
+		box[i], _ = strconv.ParseFloat(m[i+1], 64)
+	}
+	return box, nil
+}
+
+// update appends objects to the document as an incremental update and
+// returns the result with offsets of the appended objects.
+func (d *document) update(objects map[int]string) ([]byte, map[int]int) {
+	var b bytes.Buffer
+	b.Write(d.data)
+	if !bytes.HasSuffix(d.data, []byte("\n")) {
+		b.WriteByte('\n')
+	}
+	numbers := slices.Sorted(maps.Keys(objects))
+	offsets := map[int]int{}
+	size := d.size
+	for _, n := range numbers {
+		offsets[n] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", n, objects[n])
+		size = max(size, n+1)
+	}
+	xref := b.Len()
+	b.WriteString("xref\n")
+	for i := 0; i < len(numbers); {
+		j := i + 1
+		for j < len(numbers) && numbers[j] == numbers[j-1]+1 {
+			j++
+		}
+		fmt.Fprintf(&b, "%d %d\n", numbers[i], j-i)
+		for _, n := range numbers[i:j] {
+			fmt.Fprintf(&b, "%010d 00000 n \n", offsets[n])
+		}
+		i = j
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root %d 0 R", size, d.root)
+	if d.info != "" {
+		fmt.Fprintf(&b, " /Info %s", d.info)
+	}
+	fmt.Fprintf(&b, " /Prev %d >>\nstartxref\n%d\n%%%%EOF\n", d.xref, xref)
+	return b.Bytes(), offsets
+}
+
+// extend inserts entries before the closing brackets of dictionary dict.
+func extend(dict, entries string) string {
+	return strings.TrimSuffix(dict, ">>") + " " + entries + " >>"
+}
+
+// textString returns s as UTF-16BE hex string, which carries any character.
+func textString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// literal returns s as literal string of single-byte text drawn with a
+// standard font. Characters outside Latin-1 are replaced.
+func literal(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r < 0x20 || r > 0xff || (r >= 0x7f && r < 0xa0):
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// decodeString decodes literal or hex string s, including the delimiters.
+// Strings with a byte order mark are UTF-16BE.
+func decodeString(s string) string {
+	var raw []byte
+	switch {
+	case strings.HasPrefix(s, "<"):
+		hex := strings.Map(func(r rune) rune {
+			if strings.ContainsRune("0123456789abcdefABCDEF", r) {
+				return r
+			}
+			return -1
+		}, s)
+		if len(hex)%2 == 1 {
+			hex += "0"
+		}
+		for i := 0; i < len(hex); i += 2 {
+			v, _ := strconv.ParseUint(hex[i:i+2], 16, 8)
+			raw = append(raw, byte(v))
+		}
+	case strings.HasPrefix(s, "("):
+		s = s[1 : len(s)-1]
+		for i := 0; i < len(s); i++ {
+			c := s[i]
+			if c != '\\' || i+1 == len(s) {
+				raw = append(raw, c)
+				continue
+			}
+			i++
+			switch s[i] {
+			case 'n':
+				raw = append(raw, '\n')
+			case 'r':
+				raw = append(raw, '\r')
+			case 't':
+				raw = append(raw, '\t')
+			case 'b':
+				raw = append(raw, '\b')
+			case 'f':
+				raw = append(raw, '\f')
+			case '\r', '\n':
+			default:
+				if s[i] >= '0' && s[i] <= '7' {
+					j := i
+					for j < len(s) && j < i+3 && s[j] >= '0' && s[j] <= '7' {
+						j++
+					}
+					v, _ := strconv.ParseUint(s[i:j], 8, 8)
+					raw = append(raw, byte(v))
+					i = j - 1
+				} else {
+					raw = append(raw, s[i])
+				}
+			}
+		}
+	}
+	if len(raw) >= 2 && raw[0] == 0xfe && raw[1] == 0xff {
+		units := make([]uint16, 0, len(raw)/2)
+		for i := 2; i+1 < len(raw); i += 2 {
+			units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	runes := make([]rune, len(raw))
+	for i, c := range raw {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
@@ -0,0 +1,217 @@
+// Package pdfsign applies and validates PAdES baseline B-B signatures of PDF
+// documents (ETSI EN 319 142-1) without external deps. Signatures are
+// detached CMS SignedData appended to the document as incremental update.
+package pdfsign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidPDF is returned for documents that cannot be parsed.
+	ErrInvalidPDF = errors.New("pdfsign: invalid PDF")
+	// ErrUnsupported is returned for valid documents or keys signing does
+	// not handle, such as PDFs with cross-reference streams.
+	ErrUnsupported = errors.New("pdfsign: unsupported")
+	// ErrPageNotFound is returned when the signature box refers to a page
+	// the document does not have.
+	ErrPageNotFound = errors.New("pdfsign: page not found")
+)
+
+// Signer is the certificate and private key signatures are made with. Chain
+// holds intermediate certificates embedded for validators.
+type Signer struct {
+	Key         crypto.Signer
+	Certificate *x509.Certificate
+	Chain       []*x509.Certificate
+}
+
+// Options describe a signature. Time is the claimed signing time; zero uses
+// the current time. Without Box the signature is invisible.
+type Options struct {
+	Reason   string
+	Location string
+	Time     time.Time
+	Box      *Box
+}
+
+// Box places the visible signature. Page is 1-based, negative pages count
+// from the last one. Position and size are in millimetres from the top-left
+// corner of the page, as in template layouts.
+type Box struct {
+	Page   int
+	X      float64
+	Y      float64
+	Width  float64
+	Height float64
+}
+
+const ptMM = 25.4 / 72
+
+// byteRangePlaceholder is replaced with the actual byte range once the
+// signed document is laid out; the padding keeps offsets unchanged.
+const byteRangePlaceholder = "/ByteRange [0 0000000000 0000000000 0000000000]"
+
+// Sign signs pdf and returns the signed document. The document must use
+// cross-reference tables and have no form fields, as PDFs of the renderer.
+func Sign(pdf []byte, s Signer, opt Options) ([]byte, error) {
+	if s.Key == nil || s.Certificate == nil {
+		return nil, errors.New("pdfsign: signer has no key or certificate")
+	}
+	d, err := parse(pdf)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := d.dictionary(d.root)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(catalog, "/AcroForm") {
+		return nil, fmt.Errorf("%w: documents with form fields", ErrUnsupported)
+	}
+	pages, err := d.pages()
+	if err != nil {
+		return nil, err
+	}
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("%w: document has no pages", ErrInvalidPDF)
+	}
+	pageIndex := 0
+	if opt.Box != nil {
+		pageIndex = opt.Box.Page - 1
+		if opt.Box.Page < 0 {
+			pageIndex = len(pages) + opt.Box.Page
+		}
+		if pageIndex < 0 || pageIndex >= len(pages) {
+			return nil, fmt.Errorf("%w: page %d of %d", ErrPageNotFound, opt.Box.Page, len(pages))
+		}
+	}
+	pageNum := pages[pageIndex]
+	page, err := d.dictionary(pageNum)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(page, "/Annots") {
+		return nil, fmt.Errorf("%w: pages with annotations", ErrUnsupported)
+	}
+	if opt.Time.IsZero() {
+		opt.Time = time.Now()
+	}
+
+	sigNum, fieldNum := d.size, d.size+1
+	reserved := 8192
+	for _, c := range append([]*x509.Certificate{s.Certificate}, s.Chain...) {
+		reserved += len(c.Raw)
+	}
+	name := s.Certificate.Subject.CommonName
+	if name == "" {
+		name = s.Certificate.Subject.String()
+	}
+	sig := fmt.Sprintf("<< /Type /Sig /Filter /Adobe.PPKLite /SubFilter /ETSI.CAdES.detached %s /Contents <%s> /M %s /Name %s",
+		byteRangePlaceholder, strings.Repeat("0", 2*reserved), literal(pdfDate(opt.Time)), textString(name))
+	if opt.Reason != "" {
+		sig += " /Reason " + textString(opt.Reason)
+	}
+	if opt.Location != "" {
+		sig += " /Location " + textString(opt.Location)
+	}
+	objects := map[int]string{sigNum: sig + " >>"}
+	rect := "0 0 0 0"
+	appearance := ""
+	if b := opt.Box; b != nil {
+		area, err := d.pageBox(pageNum)
+		if err != nil {
+			return nil, err
+		}
+		x, w, h := area[0]+b.X/ptMM, b.Width/ptMM, b.Height/ptMM
+		y := area[3] - (b.Y+b.Height)/ptMM
+		rect = fmt.Sprintf("%s %s %s %s", num(x), num(y), num(x+w), num(y+h))
+		apNum, fontNum := d.size+2, d.size+3
+		lines := []string{"Digitally signed by " + name, "Date: " + opt.Time.UTC().Format("2006-01-02 15:04:05 UTC")}
+		if opt.Reason != "" {
+			lines = append(lines, "Reason: "+opt.Reason)
+		}
+		if opt.Location != "" {
+			lines = append(lines, "Location: "+opt.Location)
+		}
+		stream := appearanceStream(w, h, lines)
+		objects[apNum] = fmt.Sprintf("<< /Type /XObject /Subtype /Form /BBox [0 0 %s %s] /Resources << /Font << /F1 %d 0 R >> >> /Length %d >>\nstream\n%s\nendstream",
+			num(w), num(h), fontNum, len(stream), stream)
+		objects[fontNum] = "<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"
+		appearance = fmt.Sprintf(" /AP << /N %d 0 R >>", apNum)
+	}
+	objects[fieldNum] = fmt.Sprintf("<< /Type /Annot /Subtype /Widget /FT /Sig /T (Signature1) /V %d 0 R /P %d 0 R /Rect [%s] /F 132%s >>",
+		sigNum, pageNum, rect, appearance)
+	objects[d.root] = extend(catalog, fmt.Sprintf("/AcroForm << /Fields [%d 0 R] /SigFlags 3 >>", fieldNum))
+	objects[pageNum] = extend(page, fmt.Sprintf("/Annots [%d 0 R]", fieldNum))
+
+	out, offsets := d.update(objects)
+	i := bytes.Index(out[offsets[sigNum]:], []byte("/Contents <"))
+	if i < 0 {
+		return nil, errors.New("pdfsign: signature contents not found")
+	}
+	start := offsets[sigNum] + i + len("/Contents ")
+	end := start + 2*reserved + 2
+	byteRange := fmt.Sprintf("/ByteRange [0 %d %d %d]", start, end, len(out)-end)
+	at := offsets[sigNum] + bytes.Index(out[offsets[sigNum]:], []byte(byteRangePlaceholder))
+	copy(out[at:], byteRange+strings.Repeat(" ", len(byteRangePlaceholder)-len(byteRange)))
+
+	digest := sha256.New()
+	digest.Write(out[:start])
+	digest.Write(out[end:])
+	cms, err := signedData(digest.Sum(nil), s)
+	if err != nil {
+		return nil, err
+	}
+	if len(cms) > reserved {
+		return nil, fmt.Errorf("pdfsign: signature of %d bytes exceeds reserved %d bytes", len(cms), reserved)
+	}
+	hex.Encode(out[start+1:], cms)
+	return out, nil
+}
+
+// appearanceStream draws the visible signature: a framed box listing the
+// signer and signing details, scaled down to fit.
+func appearanceStream(w, h float64, lines []string) string {
+	const pad = 3.0
+	longest := 0
+	for _, l := range lines {
+		longest = max(longest, len([]rune(l)))
+	}
+	// Helvetica glyphs average about half an em.
+	size := min(9, (h-2*pad)/(1.25*float64(len(lines))), (w-2*pad)/(0.5*float64(longest)))
+	size = max(size, 2)
+	var b strings.Builder
+	fmt.Fprintf(&b, "q 0.5 w 0.2 0.2 0.2 RG 0.25 0.25 %s %s re S Q\n", num(w-0.5), num(h-0.5))
+	b.WriteString("BT 0 0 0 rg\n")
+	for i, l := range lines {
+		y := h - pad - size*(1.25*float64(i)+1)
+		fmt.Fprintf(&b, "/F1 %s Tf 1 0 0 1 %s %s Tm %s Tj\n", num(size), num(pad), num(y), literal(l))
+	}
+	b.WriteString("ET")
+	return b.String()
+}
+
+// pdfDate formats t as PDF date string.
+func pdfDate(t time.Time) string {
+	t = t.UTC()
+	return "D:" + t.Format("20060102150405") + "Z"
+}
+
+// num formats number with at most two decimals.
+func num(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
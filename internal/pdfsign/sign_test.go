@@ -0,0 +1,205 @@
+package pdfsign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testPDF returns a one page A4 document with a cross-reference table, as
+// written by the renderer.
+func testPDF() []byte {
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 595.28 841.89] /Contents 4 0 R >>",
+		"<< /Length 0 >>\nstream\n\nendstream",
+	}
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.7\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = b.Len()
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return b.Bytes()
+}
+
+// testSigner returns a self-signed signer with key valid for two days until
+// notAfter.
+func testSigner(t *testing.T, key crypto.Signer, notAfter time.Time) Signer {
+	t.Helper()
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(42),
+		Subject:               pkix.Name{CommonName: "Test Signer", Organization: []string{"Acme"}},
+		NotBefore:             notAfter.Add(-48 * time.Hour),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		t.Fatalf("create certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("parse certificate: %v", err)
+	}
+	return Signer{Key: key, Certificate: cert}
+}
+
+func rsaKey(t *testing.T) crypto.Signer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func ecdsaKey(t *testing.T) crypto.Signer {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// signed signs the test document and returns it with options trusting the
+// signer.
+func signed(t *testing.T, s Signer, opt Options) ([]byte, VerifyOptions) {
+	t.Helper()
+	pdf, err := Sign(testPDF(), s, opt)
+	if err != nil {
+		t.Fatalf("Sign: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(s.Certificate)
+	return pdf, VerifyOptions{Roots: roots}
+}
+
+// verifyOne verifies pdf and returns its only signature.
+func verifyOne(t *testing.T, pdf []byte, opt VerifyOptions) Signature {
+	t.Helper()
+	signatures, err := Verify(pdf, opt)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if len(signatures) != 1 {
+		t.Fatalf("Verify found %d signatures, want 1", len(signatures))
+	}
+	return signatures[0]
+}
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		key  func(*testing.T) crypto.Signer
+	}{
+		{"RSA", rsaKey},
+		{"ECDSA", ecdsaKey},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := testSigner(t, tc.key(t), time.Now().Add(24*time.Hour))
+			pdf, opt := signed(t, s, Options{
+				Reason:   "Approved",
+				Location: "Almaty",
+				Box:      &Box{Page: -1, X: 10, Y: 10, Width: 60, Height: 20},
+			})
+			sig := verifyOne(t, pdf, opt)
+			if !sig.Valid || !sig.CoversDocument || !sig.Intact || !sig.Trusted || !sig.PAdESBaselineB {
+				t.Fatalf("signature is not valid: %+v", sig)
+			}
+			if len(sig.Problems) != 0 {
+				t.Errorf("problems %v, want none", sig.Problems)
+			}
+			if sig.SubFilter != "ETSI.CAdES.detached" || sig.Reason != "Approved" || sig.Location != "Almaty" {
+				t.Errorf("signature dictionary not reported: %+v", sig)
+			}
+			if sig.Signer != s.Certificate.Subject.String() || sig.SerialNumber != "2A" {
+				t.Errorf("signer %q serial %q, want %q 2A", sig.Signer, sig.SerialNumber, s.Certificate.Subject)
+			}
+			if sig.SigningTime == nil || time.Since(*sig.SigningTime) > time.Minute {
+				t.Errorf("signing time %v, want now", sig.SigningTime)
+			}
+		})
+	}
+}
+
+func TestVerifyUntrustedCertificate(t *testing.T) {
+	pdf, _ := signed(t, testSigner(t, ecdsaKey(t), time.Now().Add(time.Hour)), Options{})
+	sig := verifyOne(t, pdf, VerifyOptions{Roots: x509.NewCertPool()})
+	if sig.Valid || sig.Trusted || !sig.Intact {
+		t.Errorf("signature of unknown certificate: %+v", sig)
+	}
+}
+
+func TestVerifyDetectsTampering(t *testing.T) {
+	pdf, opt := signed(t, testSigner(t, ecdsaKey(t), time.Now().Add(time.Hour)), Options{})
+	i := bytes.Index(pdf, []byte("595.28"))
+	if i < 0 {
+		t.Fatal("media box not found")
+	}
+	pdf[i] = '6'
+	sig := verifyOne(t, pdf, opt)
+	if sig.Valid || sig.Intact {
+		t.Errorf("tampered document verified: %+v", sig)
+	}
+	if !sig.CoversDocument {
+		t.Error("tampering in place must not change the covered range")
+	}
+}
+
+func TestVerifyRejectsAppendedContent(t *testing.T) {
+	pdf, opt := signed(t, testSigner(t, rsaKey(t), time.Now().Add(time.Hour)), Options{})
+	pdf = append(pdf, "4 0 obj\n<< /Length 0 >>\nstream\n\nendstream\nendobj\n"...)
+	sig := verifyOne(t, pdf, opt)
+	if sig.CoversDocument {
+		t.Error("appended content reported as covered")
+	}
+	if !sig.Intact {
+		t.Errorf("signed range must stay intact: %v", sig.Problems)
+	}
+	if sig.Valid {
+		t.Error("signature not covering the document is valid")
+	}
+}
+
+func TestVerifyRejectsOverflowingByteRange(t *testing.T) {
+	// The contents start at offset 30 and the lengths overflow when added.
+	pdf := []byte("%PDF-1.7\n" + strings.Repeat(" ", 21) + "<00> /ByteRange [0 30 9223372036854775800 100]\n")
+	sig := verifyOne(t, pdf, VerifyOptions{Roots: x509.NewCertPool()})
+	if sig.Valid || len(sig.Problems) != 1 || sig.Problems[0] != "byte range is invalid" {
+		t.Errorf("crafted byte range: %+v", sig)
+	}
+}
+
+func TestVerifyIgnoresBackdatedSigningTime(t *testing.T) {
+	s := testSigner(t, ecdsaKey(t), time.Now().Add(-time.Hour))
+	pdf, opt := signed(t, s, Options{Time: time.Now().Add(-2 * time.Hour)})
+	sig := verifyOne(t, pdf, opt)
+	if sig.Valid || sig.Trusted {
+		t.Errorf("expired certificate trusted at backdated signing time: %+v", sig)
+	}
+	if !sig.Intact || !sig.CoversDocument {
+		t.Errorf("signature must stay intact: %v", sig.Problems)
+	}
+}
@@ -0,0 +1,272 @@
+package pdfsign
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Signature is the validation result of one signature of a document.
+// Valid signatures cover the whole file, have intact content, a correct
+// signature value and a certificate trusted now. The claimed signing time
+// is not a trusted timestamp, so certificates that have expired since are
+// not trusted. Problems explain every failed check.
+type Signature struct {
+	SubFilter    string     `json:"sub_filter"`
+	Signer       string     `json:"signer"`
+	Issuer       string     `json:"issuer"`
+	SerialNumber string     `json:"serial_number"`
+	Reason       string     `json:"reason,omitempty"`
+	Location     string     `json:"location,omitempty"`
+	SigningTime  *time.Time `json:"signing_time"`
+	// CoversDocument reports that the signature covers the whole file;
+	// content appended by later updates is not covered and makes the
+	// signature invalid.
+	CoversDocument bool `json:"covers_document"`
+	Intact         bool `json:"intact"`
+	// PAdESBaselineB reports conformance to PAdES baseline B-B.
+	PAdESBaselineB bool     `json:"pades_baseline_b"`
+	Trusted        bool     `json:"trusted"`
+	Valid          bool     `json:"valid"`
+	Problems       []string `json:"problems"`
+}
+
+// VerifyOptions configure validation. Roots are the trusted certificates;
+// nil uses the system roots.
+type VerifyOptions struct {
+	Roots *x509.CertPool
+}
+
+var (
+	byteRangePattern = regexp.MustCompile(`/ByteRange\s*\[\s*(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s*\]`)
+	subFilterPattern = regexp.MustCompile(`/SubFilter\s*/([^\s/<>\[\]()]+)`)
+	stringPattern    = `\s*(\((?:[^()\\]|\\.)*\)|<[0-9A-Fa-f\s]*>)`
+	datePattern      = regexp.MustCompile(`/M` + stringPattern)
+	reasonPattern    = regexp.MustCompile(`/Reason` + stringPattern)
+	locationPattern  = regexp.MustCompile(`/Location` + stringPattern)
+)
+
+// Verify validates every signature of pdf, in order of appearance. Documents
+// without signatures yield none.
+func Verify(pdf []byte, opt VerifyOptions) ([]Signature, error) {
+	if !bytes.HasPrefix(pdf, []byte("%PDF-")) {
+		return nil, fmt.Errorf("%w: missing PDF header", ErrInvalidPDF)
+	}
+	signatures := []Signature{}
+	for _, m := range byteRangePattern.FindAllSubmatchIndex(pdf, -1) {
+		var br [4]int
+		for i := range br {
+			br[i], _ = strconv.Atoi(string(pdf[m[2+2*i]:m[3+2*i]]))
+		}
+		signatures = append(signatures, verify(pdf, m[0], br, opt))
+	}
+	return signatures, nil
+}
+
+// verify validates the signature whose byte range is at offset at.
+func verify(pdf []byte, at int, br [4]int, opt VerifyOptions) Signature {
+	sig := Signature{Problems: []string{}}
+	fail := func(format string, args ...any) Signature {
+		sig.Problems = append(sig.Problems, fmt.Sprintf(format, args...))
+		return sig
+	}
+	dict := signatureDictionary(pdf, at)
+	if m := subFilterPattern.FindStringSubmatch(dict); m != nil {
+		sig.SubFilter = m[1]
+	}
+	if m := reasonPattern.FindStringSubmatch(dict); m != nil {
+		sig.Reason = decodeString(m[1])
+	}
+	if m := locationPattern.FindStringSubmatch(dict); m != nil {
+		sig.Location = decodeString(m[1])
+	}
+	if m := datePattern.FindStringSubmatch(dict); m != nil {
+		if t, ok := parseDate(decodeString(m[1])); ok {
+			sig.SigningTime = &t
+		}
+	}
+	// Lengths are compared without adding them, which could overflow.
+	if br[0] != 0 || br[1] < 0 || br[1] >= br[2] || br[2] > len(pdf) || br[3] < 0 || br[3] > len(pdf)-br[2] ||
+		pdf[br[1]] != '<' || pdf[br[2]-1] != '>' {
+		return fail("byte range is invalid")
+	}
+	sig.CoversDocument = br[2]+br[3] == len(pdf)
+	if !sig.CoversDocument {
+		sig.Problems = append(sig.Problems, "document was changed after signing")
+	}
+	contents, err := hex.DecodeString(string(bytes.Join(bytes.Fields(pdf[br[1]+1:br[2]-1]), nil)))
+	if err != nil {
+		return fail("signature contents are not hex encoded")
+	}
+	sd, err := parseSignedData(contents)
+	if err != nil {
+		return fail("%v", err)
+	}
+	si := sd.signer
+	cert := si.find(sd.certificates)
+	if cert == nil {
+		return fail("signer certificate is not embedded")
+	}
+	sig.Signer = cert.Subject.String()
+	sig.Issuer = cert.Issuer.String()
+	sig.SerialNumber = strings.ToUpper(cert.SerialNumber.Text(16))
+
+	if si.attrs == nil {
+		return fail("signed attributes are missing")
+	}
+	var digest []byte
+	if _, err := asn1.Unmarshal(si.attrValue[oidMessageDigest.String()], &digest); err != nil {
+		return fail("message digest attribute is missing")
+	}
+	h := si.digest.New()
+	h.Write(pdf[br[0] : br[0]+br[1]])
+	h.Write(pdf[br[2] : br[2]+br[3]])
+	if !bytes.Equal(h.Sum(nil), digest) {
+		return fail("signed content was modified")
+	}
+	if err := si.verifySignature(cert); err != nil {
+		return fail("signature value is invalid: %v", err)
+	}
+	sig.Intact = true
+
+	sig.PAdESBaselineB = true
+	if sig.SubFilter != "ETSI.CAdES.detached" {
+		sig.PAdESBaselineB = false
+		sig.Problems = append(sig.Problems, fmt.Sprintf("sub-filter %q is not ETSI.CAdES.detached", sig.SubFilter))
+	}
+	if err := checkSigningCertificate(si.attrValue[oidSigningCertificateV2.String()], cert); err != nil {
+		sig.PAdESBaselineB = false
+		sig.Problems = append(sig.Problems, err.Error())
+	}
+	if _, ok := si.attrValue[oidSigningTime.String()]; ok {
+		sig.PAdESBaselineB = false
+		sig.Problems = append(sig.Problems, "signing-time attribute is present; PAdES claims signing time in the signature dictionary")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range sd.certificates {
+		if c != cert {
+			intermediates.AddCert(c)
+		}
+	}
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots:         opt.Roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	if err != nil {
+		sig.Problems = append(sig.Problems, fmt.Sprintf("certificate is not trusted: %v", err))
+	} else {
+		sig.Trusted = true
+	}
+	sig.Valid = sig.CoversDocument && sig.Intact && sig.Trusted
+	return sig
+}
+
+// checkSigningCertificate checks that ESS signing-certificate-v2 value v
+// refers to cert.
+func checkSigningCertificate(v []byte, cert *x509.Certificate) error {
+	if v == nil {
+		return fmt.Errorf("signing-certificate-v2 attribute is missing")
+	}
+	invalid := fmt.Errorf("signing-certificate-v2 attribute is malformed")
+	var signingCert asn1.RawValue
+	if _, err := asn1.Unmarshal(v, &signingCert); err != nil {
+		return invalid
+	}
+	fields, err := elements(signingCert.Bytes)
+	if err != nil || len(fields) == 0 {
+		return invalid
+	}
+	ids, err := elements(fields[0].Bytes)
+	if err != nil || len(ids) == 0 {
+		return invalid
+	}
+	id, err := elements(ids[0].Bytes)
+	if err != nil || len(id) == 0 {
+		return invalid
+	}
+	hash := crypto.SHA256
+	if id[0].Tag == asn1.TagSequence {
+		var alg struct {
+			Algorithm asn1.ObjectIdentifier
+			Params    asn1.RawValue `asn1:"optional"`
+		}
+		if _, err := asn1.Unmarshal(id[0].FullBytes, &alg); err != nil {
+			return invalid
+		}
+		var ok bool
+		if hash, ok = digestAlgorithms[alg.Algorithm.String()]; !ok {
+			return fmt.Errorf("signing-certificate-v2 hash algorithm %s is not supported", alg.Algorithm)
+		}
+		id = id[1:]
+	}
+	if len(id) == 0 || id[0].Tag != asn1.TagOctetString {
+		return invalid
+	}
+	h := hash.New()
+	h.Write(cert.Raw)
+	if !bytes.Equal(h.Sum(nil), id[0].Bytes) {
+		return fmt.Errorf("signing-certificate-v2 attribute does not match the signer certificate")
+	}
+	return nil
+}
+
+// signatureDictionary returns the text of the object around offset at,
+// which holds the signature dictionary.
+func signatureDictionary(pdf []byte, at int) string {
+	start := bytes.LastIndex(pdf[:at], []byte(" obj"))
+	end := bytes.Index(pdf[at:], []byte("endobj"))
+	if start < 0 || end < 0 {
+		return ""
+	}
+	return string(pdf[start : at+end])
+}
+
+// parseDate parses PDF date string such as D:20260101120000+03'00'.
+func parseDate(s string) (time.Time, bool) {
+	s = strings.TrimPrefix(s, "D:")
+	digits := len(s)
+	for i, r := range s {
+		if r < '0' || r > '9' {
+			digits = i
+			break
+		}
+	}
+	if digits < 4 {
+		return time.Time{}, false
+	}
+	// Missing month, day and time default to the earliest value.
+	value := s[:digits] + "0101000000"[max(0, digits-4):]
+	if digits > 14 {
+		value = s[:14]
+	}
+	t, err := time.Parse("20060102150405", value[:14])
+	if err != nil {
+		return time.Time{}, false
+	}
+	zone := strings.ReplaceAll(s[min(digits, 14):], "'", "")
+	if zone == "" || zone[0] == 'Z' {
+		return t, true
+	}
+	if len(zone) < 3 || (zone[0] != '+' && zone[0] != '-') {
+		return t, true
+	}
+	hours, _ := strconv.Atoi(zone[1:3])
+	minutes := 0
+	if len(zone) >= 5 {
+		minutes, _ = strconv.Atoi(zone[3:5])
+	}
+	offset := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+	if zone[0] == '+' {
+		offset = -offset
+	}
+	return t.Add(offset), true
+}
@@ -0,0 +1,97 @@
+package quotas
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/tenants"
+)
+
+// Unlimited disables a plan limit.
+const Unlimited = 0
+
+// Plan defines usage limits attached to a subscription.
+type Plan struct {
+	Name                   tenants.Subscription `json:"name"`
+	MaxTemplates           int                  `json:"max_templates"`
+	MaxVersionsPerTemplate int                  `json:"max_versions_per_template"`
+	MaxStorageBytes        int64                `json:"max_storage_bytes"`
+	MaxDocumentsPerMonth   int                  `json:"max_documents_per_month"`
+	MaxBulkBatchSize       int                  `json:"max_bulk_batch_size"`
+}
+
+// DefaultPlans returns limits of built-in subscriptions.
+func DefaultPlans() map[tenants.Subscription]Plan {
+	return map[tenants.Subscription]Plan{
+		tenants.SubscriptionFree: {
+			Name:                   tenants.SubscriptionFree,
+			MaxTemplates:           10,
+			MaxVersionsPerTemplate: 20,
+			MaxStorageBytes:        100 << 20,
+			MaxDocumentsPerMonth:   100,
+			MaxBulkBatchSize:       10,
+		},
+		tenants.SubscriptionPro: {
+			Name:                   tenants.SubscriptionPro,
+			MaxTemplates:           500,
+			MaxVersionsPerTemplate: 200,
+			MaxStorageBytes:        10 << 30,
+			MaxDocumentsPerMonth:   10_000,
+			MaxBulkBatchSize:       500,
+		},
+		tenants.SubscriptionEnterprise: {
+			Name:                   tenants.SubscriptionEnterprise,
+			MaxTemplates:           Unlimited,
+			MaxVersionsPerTemplate: Unlimited,
+			MaxStorageBytes:        Unlimited,
+			MaxDocumentsPerMonth:   Unlimited,
+			MaxBulkBatchSize:       5_000,
+		},
+	}
+}
+
+// Usage is the metered consumption of a tenant in a billing period.
+type Usage struct {
+	TenantID     string `json:"tenant_id"`
+	Period       string `json:"period"`
+	Documents    int    `json:"documents"`
+	StorageBytes int64  `json:"storage_bytes"`
+}
+
+// Report combines plan limits with current usage.
+type Report struct {
+	Plan         Plan   `json:"plan"`
+	Period       string `json:"period"`
+	Templates    int    `json:"templates"`
+	Documents    int    `json:"documents"`
+	StorageBytes int64  `json:"storage_bytes"`
+}
+
+var (
+	// ErrLimitExceeded is returned when plan limit blocks the operation.
+	ErrLimitExceeded = errors.New("quotas: plan limit exceeded")
+	// ErrRateExceeded is returned when monthly allowance is exhausted.
+	ErrRateExceeded = errors.New("quotas: monthly allowance exhausted")
+)
+
+// LimitError reports which limit blocked the operation.
+type LimitError struct {
+	Limit     string
+	Max       int64
+	Requested int64
+	Err       error
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s limit of %d exceeded (requested %d): %v", e.Limit, e.Max, e.Requested, e.Err)
+}
+
+func (e *LimitError) Unwrap() error {
+	return e.Err
+}
+
+// period returns billing period key for time t.
+func period(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
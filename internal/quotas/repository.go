@@ -0,0 +1,17 @@
+package quotas
+
+import (
+	"context"
+)
+
+// Repository defines persistence layer for metered usage.
+type Repository interface {
+	GetUsage(ctx context.Context, tenantID, period string) (*Usage, error)
+	// AddDocuments atomically increments documents counter unless the result
+	// would exceed limit; limit of Unlimited disables the check. Negative n
+	// releases documents.
+	AddDocuments(ctx context.Context, tenantID, period string, n, limit int) (*Usage, error)
+	// AddStorage atomically adjusts stored bytes unless a positive delta would
+	// exceed limit; limit of Unlimited disables the check.
+	AddStorage(ctx context.Context, tenantID string, delta, limit int64) (*Usage, error)
+}
@@ -0,0 +1,186 @@
+package quotas
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/tenants"
+)
+
+// TemplateCounter reports number of live templates of a tenant.
+type TemplateCounter interface {
+	CountTemplates(ctx context.Context, tenantID string) (int, error)
+}
+
+// NewInMemoryRepository creates thread-safe repository for prototyping.
+func NewInMemoryRepository() Repository {
+	return &inMemoryRepository{
+		documents: make(map[string]int),
+		storage:   make(map[string]int64),
+	}
+}
+
+// QuotaService resolves tenant plans and enforces their limits.
+type QuotaService struct {
+	repo      Repository
+	tenants   *tenants.TenantService
+	plans     map[tenants.Subscription]Plan
+	templates TemplateCounter
+}
+
+// NewQuotaService creates service instance using given plan catalogue.
+func NewQuotaService(repo Repository, tenantService *tenants.TenantService, plans map[tenants.Subscription]Plan) *QuotaService {
+	return &QuotaService{repo: repo, tenants: tenantService, plans: plans}
+}
+
+// SetTemplateCounter wires template counting used by usage reports. It is set
+// after construction because the template service depends on quotas too.
+func (s *QuotaService) SetTemplateCounter(counter TemplateCounter) {
+	s.templates = counter
+}
+
+// PlanFor returns plan of tenant subscription.
+func (s *QuotaService) PlanFor(ctx context.Context, tenantID string) (Plan, error) {
+	tenant, err := s.tenants.GetTenant(ctx, tenantID)
+	if err != nil {
+		return Plan{}, err
+	}
+	plan, ok := s.plans[tenant.Subscription]
+	if !ok {
+		return Plan{}, fmt.Errorf("no plan configured for subscription %q", tenant.Subscription)
+	}
+	return plan, nil
+}
+
+// CheckTemplates verifies one more template fits the plan.
+func (s *QuotaService) CheckTemplates(ctx context.Context, tenantID string, current int) error {
+	plan, err := s.PlanFor(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	return checkLimit("templates", plan.MaxTemplates, current+1)
+}
+
+// CheckVersions verifies one more version of a template fits the plan.
+func (s *QuotaService) CheckVersions(ctx context.Context, tenantID string, current int) error {
+	plan, err := s.PlanFor(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	return checkLimit("versions_per_template", plan.MaxVersionsPerTemplate, current+1)
+}
+
+// CheckBatch verifies bulk request size fits the plan.
+func (s *QuotaService) CheckBatch(ctx context.Context, tenantID string, size int) error {
+	plan, err := s.PlanFor(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	return checkLimit("bulk_batch_size", plan.MaxBulkBatchSize, size)
+}
+
+// ReserveDocuments consumes n documents from the monthly allowance.
+func (s *QuotaService) ReserveDocuments(ctx context.Context, tenantID string, n int) error {
+	plan, err := s.PlanFor(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	_, err = s.repo.AddDocuments(ctx, tenantID, period(time.Now()), n, plan.MaxDocumentsPerMonth)
+	return err
+}
+
+// ReleaseDocuments returns n documents reserved by ReserveDocuments to the
+// allowance, used when generation fails after the reservation.
+func (s *QuotaService) ReleaseDocuments(ctx context.Context, tenantID string, n int) error {
+	_, err := s.repo.AddDocuments(ctx, tenantID, period(time.Now()), -n, Unlimited)
+	return err
+}
+
+// AddStorage accounts stored bytes; negative delta releases storage.
+func (s *QuotaService) AddStorage(ctx context.Context, tenantID string, delta int64) error {
+	plan, err := s.PlanFor(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	_, err = s.repo.AddStorage(ctx, tenantID, delta, plan.MaxStorageBytes)
+	return err
+}
+
+// Usage reports current consumption against plan limits.
+func (s *QuotaService) Usage(ctx context.Context, tenantID string) (*Report, error) {
+	plan, err := s.PlanFor(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	current := period(time.Now())
+	usage, err := s.repo.GetUsage(ctx, tenantID, current)
+	if err != nil {
+		return nil, err
+	}
+	report := &Report{
+		Plan:         plan,
+		Period:       current,
+		Documents:    usage.Documents,
+		StorageBytes: usage.StorageBytes,
+	}
+	if s.templates != nil {
+		if report.Templates, err = s.templates.CountTemplates(ctx, tenantID); err != nil {
+			return nil, err
+		}
+	}
+	return report, nil
+}
+
+func checkLimit(name string, limit, wanted int) error {
+	if limit == Unlimited || wanted <= limit {
+		return nil
+	}
+	return &LimitError{Limit: name, Max: int64(limit), Requested: int64(wanted), Err: ErrLimitExceeded}
+}
+
+// inMemoryRepository is prototyping repository with maps.
+type inMemoryRepository struct {
+	documents map[string]int
+	storage   map[string]int64
+	mu        sync.Mutex
+}
+
+func (r *inMemoryRepository) GetUsage(ctx context.Context, tenantID, period string) (*Usage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.usage(tenantID, period), nil
+}
+
+func (r *inMemoryRepository) AddDocuments(ctx context.Context, tenantID, period string, n, limit int) (*Usage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := tenantID + "/" + period
+	current := r.documents[key]
+	if limit != Unlimited && current+n > limit {
+		return nil, &LimitError{Limit: "documents_per_month", Max: int64(limit), Requested: int64(current + n), Err: ErrRateExceeded}
+	}
+	r.documents[key] = max(current+n, 0)
+	return r.usage(tenantID, period), nil
+}
+
+func (r *inMemoryRepository) AddStorage(ctx context.Context, tenantID string, delta, limit int64) (*Usage, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	current := r.storage[tenantID]
+	if delta > 0 && limit != Unlimited && current+delta > limit {
+		return nil, &LimitError{Limit: "storage_bytes", Max: limit, Requested: current + delta, Err: ErrLimitExceeded}
+	}
+	r.storage[tenantID] = max(current+delta, 0)
+	return r.usage(tenantID, period(time.Now())), nil
+}
+
+func (r *inMemoryRepository) usage(tenantID, period string) *Usage {
+	return &Usage{
+		TenantID:     tenantID,
+		Period:       period,
+		Documents:    r.documents[tenantID+"/"+period],
+		StorageBytes: r.storage[tenantID],
+	}
+}
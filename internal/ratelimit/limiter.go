@@ -0,0 +1,153 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Config defines default limits per route class for whole tenants and for
+// individual principals (users or API keys) within a tenant.
+type Config struct {
+	Tenant    map[Class]Limit
+	Principal map[Class]Limit
+}
+
+// DefaultConfig returns limits suitable for interactive and integration use.
+func DefaultConfig() Config {
+	return Config{
+		Tenant: map[Class]Limit{
+			ClassRead:  PerMinute(1200),
+			ClassWrite: PerMinute(300),
+			ClassBulk:  PerMinute(30),
+		},
+		Principal: map[Class]Limit{
+			ClassRead:  PerMinute(300),
+			ClassWrite: PerMinute(120),
+			ClassBulk:  PerMinute(10),
+		},
+	}
+}
+
+// PublicConfig returns limits of unauthenticated endpoints, whose callers
+// are told apart by network address: the tenant limit applies to all
+// callers together and the principal limit to each address. Writes, such as
+// sign-in, hash a password each and are limited more tightly than reads.
+func PublicConfig() Config {
+	return Config{
+		Tenant:    map[Class]Limit{ClassRead: PerMinute(600), ClassWrite: PerMinute(300)},
+		Principal: map[Class]Limit{ClassRead: PerMinute(30), ClassWrite: PerMinute(10)},
+	}
+}
+
+// TenantLimits overrides defaults for a single tenant. Nil maps keep defaults.
+type TenantLimits struct {
+	Tenant    map[Class]Limit `json:"tenant"`
+	Principal map[Class]Limit `json:"principal"`
+}
+
+// Validate ensures every limit is of a known class, refills and admits at
+// least one request.
+func (t TenantLimits) Validate() error {
+	for _, limits := range []map[Class]Limit{t.Tenant, t.Principal} {
+		for class, limit := range limits {
+			if !slices.Contains(Classes(), class) {
+				return fmt.Errorf("unknown class %q", class)
+			}
+			if limit.Rate <= 0 || limit.Burst < 1 {
+				return fmt.Errorf("limit for %s must have positive rate and burst", class)
+			}
+		}
+	}
+	return nil
+}
+
+// Limiter applies tenant-wide and per-principal buckets to every request.
+type Limiter struct {
+	store     Store
+	cfg       Config
+	overrides map[string]TenantLimits
+	mu        sync.RWMutex
+}
+
+// NewLimiter creates limiter backed by store.
+func NewLimiter(store Store, cfg Config) *Limiter {
+	return &Limiter{store: store, cfg: cfg, overrides: make(map[string]TenantLimits)}
+}
+
+// SetTenantLimits replaces overrides of tenant.
+func (l *Limiter) SetTenantLimits(tenantID string, limits TenantLimits) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.overrides[tenantID] = limits
+}
+
+// TenantLimits returns effective limits of tenant.
+func (l *Limiter) TenantLimits(tenantID string) TenantLimits {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	effective := TenantLimits{Tenant: map[Class]Limit{}, Principal: map[Class]Limit{}}
+	override := l.overrides[tenantID]
+	for class, limit := range l.cfg.Tenant {
+		effective.Tenant[class] = limit
+	}
+	for class, limit := range override.Tenant {
+		effective.Tenant[class] = limit
+	}
+	for class, limit := range l.cfg.Principal {
+		effective.Principal[class] = limit
+	}
+	for class, limit := range override.Principal {
+		effective.Principal[class] = limit
+	}
+	return effective
+}
+
+// Allow consumes a token from principal and tenant buckets of class and
+// returns the more restrictive result. The tenant bucket is only charged for
+// requests the principal bucket admits, so that a throttled principal does
+// not starve the rest of the tenant. Classes without limits are allowed.
+func (l *Limiter) Allow(ctx context.Context, tenantID, principalID string, class Class) (Result, error) {
+	limits := l.TenantLimits(tenantID)
+	now := time.Now()
+	result := Result{Allowed: true, Remaining: -1}
+	if limit, ok := limits.Principal[class]; ok && principalID != "" {
+		res, err := l.store.Take(ctx, "principal:"+tenantID+":"+principalID+":"+string(class), limit, now)
+		if err != nil {
+			return Result{}, err
+		}
+		if !res.Allowed {
+			return res, nil
+		}
+		result = res
+	}
+	if limit, ok := limits.Tenant[class]; ok {
+		res, err := l.store.Take(ctx, "tenant:"+tenantID+":"+string(class), limit, now)
+		if err != nil {
+			return Result{}, err
+		}
+		result = stricter(result, res)
+	}
+	return result, nil
+}
+
+func stricter(a, b Result) Result {
+	switch {
+	case a.Allowed != b.Allowed:
+		if !a.Allowed {
+			return a
+		}
+		return b
+	case !a.Allowed:
+		if a.RetryAfter >= b.RetryAfter {
+			return a
+		}
+		return b
+	case a.Remaining < 0 || b.Remaining < a.Remaining:
+		return b
+	default:
+		return a
+	}
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// idleEviction controls how long untouched buckets are retained.
+const idleEviction = 10 * time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// MemoryStore is in-process token bucket store.
+type MemoryStore struct {
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+// NewMemoryStore creates empty store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: make(map[string]*bucket)}
+}
+
+// Take consumes one token from bucket identified by key.
+func (s *MemoryStore) Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now}
+		s.buckets[key] = b
+	}
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(float64(limit.Burst), b.tokens+elapsed*limit.Rate)
+		b.last = now
+	}
+	res := Result{Limit: limit.Burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsToDuration((1 - b.tokens) / limit.Rate)
+	}
+	res.Remaining = int(math.Floor(b.tokens))
+	res.Reset = secondsToDuration((float64(limit.Burst) - b.tokens) / limit.Rate)
+	return res, nil
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < idleEviction {
+		return
+	}
+	s.lastSweep = now
+	for key, b := range s.buckets {
+		if now.Sub(b.last) > idleEviction {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(math.Ceil(seconds * float64(time.Second)))
+}
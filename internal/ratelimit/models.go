@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Class groups routes with similar cost.
+type Class string
+
+const (
+	ClassRead  Class = "read"
+	ClassWrite Class = "write"
+	ClassBulk  Class = "bulk"
+)
+
+// Classes lists route classes.
+func Classes() []Class {
+	return []Class{ClassRead, ClassWrite, ClassBulk}
+}
+
+// Limit is a token bucket refilled at Rate tokens per second up to Burst.
+type Limit struct {
+	Rate  float64 `json:"rate"`
+	Burst int     `json:"burst"`
+}
+
+// PerMinute builds limit allowing n requests per minute with burst of n.
+func PerMinute(n int) Limit {
+	return Limit{Rate: float64(n) / 60, Burst: n}
+}
+
+// Result describes bucket state after a request.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// Store keeps bucket state. The in-process MemoryStore suits single instance
+// deployments; multi-instance deployments plug in a shared implementation.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit, now time.Time) (Result, error)
+}
@@ -0,0 +1,111 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+
+	"github.com/lumiforge/docfactory-backend/internal/barcode"
+	"github.com/lumiforge/docfactory-backend/internal/templates"
+)
+
+// rect is an axis-aligned box; units depend on the caller.
+type rect struct {
+	x, y, w, h float64
+}
+
+// barcode encodes value of barcode element, or the verification URL of
+// verification element, and returns the encoded text. It returns nil when
+// there is nothing to encode, in which case nothing is drawn.
+func (in Input) barcode(el templates.Element) (*barcode.Code, string, error) {
+	if el.Type == templates.ElementVerification {
+		if in.Verification == "" {
+			return nil, "", nil
+		}
+		code, err := barcode.Encode(barcode.QR, in.Verification, el.ErrorCorrection)
+		if err != nil {
+			return nil, "", fmt.Errorf("render: verification code: %w", err)
+		}
+		return code, in.Verification, nil
+	}
+	content := el.BarcodeContent(in.Data)
+	if content == "" {
+		return nil, "", nil
+	}
+	code, err := barcode.Encode(el.Symbology, content, el.ErrorCorrection)
+	if err != nil {
+		return nil, "", fmt.Errorf("render: barcode of field %s: %w", el.Field, err)
+	}
+	return code, content, nil
+}
+
+// barcodeRects returns dark areas of code drawn into box, quiet zone
+// included. Adjacent dark modules of a row are merged into one rect. Bars of
+// linear codes span the box height and their quiet zone is horizontal only.
+func barcodeRects(code *barcode.Code, box rect) []rect {
+	cols := float64(code.Width() + 2*code.QuietZone)
+	rows := float64(code.Height() + 2*code.QuietZone)
+	top := code.QuietZone
+	if code.Linear() {
+		rows, top = 1, 0
+	}
+	mw, mh := box.w/cols, box.h/rows
+	var rects []rect
+	for y, row := range code.Modules {
+		for x := 0; x < len(row); x++ {
+			if !row[x] {
+				continue
+			}
+			start := x
+			for x < len(row) && row[x] {
+				x++
+			}
+			rects = append(rects, rect{
+				x: box.x + float64(start+code.QuietZone)*mw,
+				y: box.y + float64(y+top)*mh,
+				w: float64(x-start) * mw,
+				h: mh,
+			})
+		}
+	}
+	return rects
+}
+
+// barcodePNG rasters code for formats that embed barcodes as pictures.
+// Matrix codes get pixelsPerModule square pixels per module; linear codes
+// keep the aspect ratio of width x height.
+func barcodePNG(code *barcode.Code, width, height float64) ([]byte, error) {
+	const pixelsPerModule = 8
+	w := (code.Width() + 2*code.QuietZone) * pixelsPerModule
+	h := (code.Height() + 2*code.QuietZone) * pixelsPerModule
+	if code.Linear() {
+		h = int(float64(w)*height/width + 0.5)
+	}
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for _, r := range barcodeRects(code, rect{0, 0, float64(w), float64(h)}) {
+		for y := int(r.y + 0.5); y < int(r.y+r.h+0.5); y++ {
+			for x := int(r.x + 0.5); x < int(r.x+r.w+0.5); x++ {
+				img.SetGray(x, y, color.Gray{})
+			}
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// barcodeSize returns size of barcode element shrunk to maxWidth.
+func barcodeSize(el templates.Element, maxWidth float64) (float64, float64) {
+	w, h := el.BarcodeSize()
+	if w > maxWidth {
+		h, w = h*maxWidth/w, maxWidth
+	}
+	return w, h
+}
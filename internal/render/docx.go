@@ -0,0 +1,439 @@
+package render
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/lumiforge/docfactory-backend/internal/templates"
+)
+
+// OOXML units: page geometry is in twentieths of a point, drawings in EMU.
+const (
+	twipsPerMM = 1440 / 25.4
+	emuPerMM   = 36000
+)
+
+const (
+	nsW   = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	nsR   = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	nsWP  = "http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing"
+	nsA   = "http://schemas.openxmlformats.org/drawingml/2006/main"
+	nsPic = "http://schemas.openxmlformats.org/drawingml/2006/picture"
+	nsRel = "http://schemas.openxmlformats.org/package/2006/relationships"
+)
+
+// docx accumulates document body, header, footer and media of a DOCX file.
+type docx struct {
+	in Input
+	// story is the part being written; the others are kept in main, header
+	// and footer, the latter two nil when the layout has no such region.
+	story                *docxStory
+	main, header, footer *docxStory
+	media                []docxPart
+	// pageW and pageH are the page size of the current section.
+	pageW, pageH float64
+	margins      templates.Margins
+	width        float64 // content width in mm
+	sections     int
+	// drawings numbers drawings; docPr ids must be unique in the document.
+	drawings int
+}
+
+// docxStory is the content of a part with its own relationships: the
+// document body, the header or the footer.
+type docxStory struct {
+	body   strings.Builder
+	rels   []docxRel
+	images map[string]string // asset ID -> relationship ID
+}
+
+func newStory() *docxStory {
+	return &docxStory{images: map[string]string{}}
+}
+
+type docxPart struct {
+	name string
+	data []byte
+}
+
+type docxRel struct {
+	id, typ, target string
+}
+
+// renderDOCX writes Office Open XML word processing document. Word has no
+// notion of bleed, so the page is the trimmed size. Header and footer have
+// their own parts, and page breaks with orientation end a section.
+func renderDOCX(in Input) ([]byte, error) {
+	d := &docx{in: in, main: newStory(), margins: in.Template.MarginsMM()}
+	d.resize(in.Template.PageDimensions())
+	d.main.rels = append(d.main.rels, docxRel{"rIdStyles", "http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles", "styles.xml"})
+	layout := in.Template.Layout
+	for _, r := range []struct {
+		region   *templates.Region
+		story    **docxStory
+		id, kind string
+	}{{layout.Header, &d.header, "rIdHeader", "header"}, {layout.Footer, &d.footer, "rIdFooter", "footer"}} {
+		if r.region == nil {
+			continue
+		}
+		*r.story = newStory()
+		d.story = *r.story
+		d.main.rels = append(d.main.rels, docxRel{r.id, "http://schemas.openxmlformats.org/officeDocument/2006/relationships/" + r.kind, r.kind + "1.xml"})
+		for _, el := range r.region.Elements {
+			if err := d.element(el); err != nil {
+				return nil, err
+			}
+		}
+		if len(r.region.Elements) == 0 {
+			d.story.body.WriteString("<w:p/>")
+		}
+	}
+	d.story = d.main
+	for _, el := range layout.Elements {
+		if err := d.element(el); err != nil {
+			return nil, err
+		}
+	}
+	d.story.body.WriteString(d.sectPr())
+	return d.pack()
+}
+
+func (d *docx) resize(width, height float64) {
+	d.pageW, d.pageH = width, height
+	d.width = width - d.margins.Left - d.margins.Right
+}
+
+func (d *docx) element(el templates.Element) error {
+	switch el.Type {
+	case templates.ElementText:
+		if d.story != d.main {
+			d.paragraph(el.Style, d.in.pageText(el.Text, string(pageMark), string(pagesMark)))
+		} else {
+			d.paragraph(el.Style, d.in.text(el.Text))
+		}
+	case templates.ElementImage:
+		return d.image(el)
+	case templates.ElementTable:
+		d.table(el)
+	case templates.ElementBarcode, templates.ElementVerification:
+		return d.barcode(el)
+	case templates.ElementPageBreak:
+		d.pageBreak(el.Orientation)
+	}
+	return nil
+}
+
+// pageBreak starts a new page. A change of orientation ends the section
+// in a paragraph carrying its properties, as Word does.
+func (d *docx) pageBreak(orientation templates.Orientation) {
+	if orientation == "" || (orientation == templates.OrientationLandscape) == (d.pageW > d.pageH) {
+		d.story.body.WriteString(`<w:p><w:r><w:br w:type="page"/></w:r></w:p>`)
+		return
+	}
+	d.story.body.WriteString("<w:p><w:pPr>" + d.sectPr() + "</w:pPr></w:p>")
+	d.resize(d.pageH, d.pageW)
+}
+
+// sectPr returns properties of the section ending here. Regions skipped on
+// the first page use a title page, which blanks both regions there, so the
+// other region is referenced as first page region too.
+func (d *docx) sectPr() string {
+	d.sections++
+	layout := d.in.Template.Layout
+	titlePg := d.sections == 1 && (layout.Header != nil && layout.Header.SkipFirstPage || layout.Footer != nil && layout.Footer.SkipFirstPage)
+	var b strings.Builder
+	b.WriteString("<w:sectPr>")
+	if layout.Header != nil {
+		b.WriteString(`<w:headerReference w:type="default" r:id="rIdHeader"/>`)
+		if titlePg && !layout.Header.SkipFirstPage {
+			b.WriteString(`<w:headerReference w:type="first" r:id="rIdHeader"/>`)
+		}
+	}
+	if layout.Footer != nil {
+		b.WriteString(`<w:footerReference w:type="default" r:id="rIdFooter"/>`)
+		if titlePg && !layout.Footer.SkipFirstPage {
+			b.WriteString(`<w:footerReference w:type="first" r:id="rIdFooter"/>`)
+		}
+	}
+	orient := ""
+	if d.pageW > d.pageH {
+		orient = ` w:orient="landscape"`
+	}
+	m := d.margins
+	fmt.Fprintf(&b, `<w:pgSz w:w="%d" w:h="%d"%s/><w:pgMar w:top="%d" w:right="%d" w:bottom="%d" w:left="%d" w:header="%d" w:footer="%d" w:gutter="0"/>`,
+		twips(d.pageW), twips(d.pageH), orient, twips(m.Top), twips(m.Right), twips(m.Bottom), twips(m.Left), twips(regionMM), twips(regionMM))
+	if titlePg {
+		b.WriteString("<w:titlePg/>")
+	}
+	b.WriteString("</w:sectPr>")
+	return b.String()
+}
+
+func (d *docx) paragraph(style templates.Style, text string) {
+	d.story.body.WriteString("<w:p>")
+	d.paragraphProps(style)
+	d.run(style, text)
+	d.story.body.WriteString("</w:p>")
+}
+
+func (d *docx) paragraphProps(style templates.Style) {
+	switch style.Align {
+	case templates.AlignCenter:
+		d.story.body.WriteString(`<w:pPr><w:jc w:val="center"/></w:pPr>`)
+	case templates.AlignRight:
+		d.story.body.WriteString(`<w:pPr><w:jc w:val="right"/></w:pPr>`)
+	}
+}
+
+// run writes text with style; line breaks become w:br and page number marks
+// become PAGE and NUMPAGES fields.
+func (d *docx) run(style templates.Style, text string) {
+	var props strings.Builder
+	if style.Bold || style.Italic || style.FontSize != 0 {
+		props.WriteString("<w:rPr>")
+		if style.Bold {
+			props.WriteString("<w:b/>")
+		}
+		if style.Italic {
+			props.WriteString("<w:i/>")
+		}
+		if style.FontSize != 0 {
+			fmt.Fprintf(&props, `<w:sz w:val="%d"/>`, int(style.FontSize*2+0.5))
+		}
+		props.WriteString("</w:rPr>")
+	}
+	rPr := props.String()
+	body := &d.story.body
+	body.WriteString("<w:r>" + rPr)
+	for i, line := range strings.Split(text, "\n") {
+		if i > 0 {
+			body.WriteString("<w:br/>")
+		}
+		start := 0
+		for k, r := range line {
+			if r != pageMark && r != pagesMark {
+				continue
+			}
+			d.text(line[start:k])
+			instr := "PAGE"
+			if r == pagesMark {
+				instr = "NUMPAGES"
+			}
+			fmt.Fprintf(body, `</w:r><w:fldSimple w:instr="%s"><w:r>%s<w:t>1</w:t></w:r></w:fldSimple><w:r>%s`, instr, rPr, rPr)
+			start = k + len(string(r))
+		}
+		d.text(line[start:])
+	}
+	body.WriteString("</w:r>")
+}
+
+func (d *docx) text(s string) {
+	if s == "" {
+		return
+	}
+	d.story.body.WriteString(`<w:t xml:space="preserve">`)
+	d.story.body.WriteString(escape(s))
+	d.story.body.WriteString("</w:t>")
+}
+
+func (d *docx) image(el templates.Element) error {
+	img, ok := d.in.Images[el.AssetID]
+	if !ok {
+		return fmt.Errorf("render: image asset %s is missing", el.AssetID)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(img.Data))
+	if err != nil {
+		return fmt.Errorf("render: decode image asset %s: %w", el.AssetID, err)
+	}
+	w, h := imageSize(el, cfg, d.width)
+	relID, ok := d.story.images[el.AssetID]
+	if !ok {
+		relID = d.embed(format, img.Data)
+		d.story.images[el.AssetID] = relID
+	}
+	d.drawing(el.Style, relID, w, h)
+	return nil
+}
+
+// barcode embeds the barcode as PNG picture; Word has no vector shapes
+// that would survive all consumers of the format.
+func (d *docx) barcode(el templates.Element) error {
+	code, _, err := d.in.barcode(el)
+	if err != nil || code == nil {
+		return err
+	}
+	w, h := barcodeSize(el, d.width)
+	data, err := barcodePNG(code, w, h)
+	if err != nil {
+		return err
+	}
+	d.drawing(el.Style, d.embed("png", data), w, h)
+	return nil
+}
+
+// embed adds picture part and returns its relationship ID.
+func (d *docx) embed(format string, data []byte) string {
+	n := len(d.media) + 1
+	relID := fmt.Sprintf("rIdImage%d", n)
+	name := fmt.Sprintf("media/image%d.%s", n, format)
+	d.media = append(d.media, docxPart{"word/" + name, data})
+	d.story.rels = append(d.story.rels, docxRel{relID, "http://schemas.openxmlformats.org/officeDocument/2006/relationships/image", name})
+	return relID
+}
+
+// drawing writes paragraph with inline picture of w x h mm.
+func (d *docx) drawing(style templates.Style, relID string, w, h float64) {
+	d.drawings++
+	id := d.drawings
+	cx, cy := int(w*emuPerMM), int(h*emuPerMM)
+	d.story.body.WriteString("<w:p>")
+	d.paragraphProps(style)
+	fmt.Fprintf(&d.story.body, `<w:r><w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0"><wp:extent cx="%d" cy="%d"/><wp:docPr id="%d" name="Picture %d"/>`+
+		`<wp:cNvGraphicFramePr><a:graphicFrameLocks noChangeAspect="1"/></wp:cNvGraphicFramePr>`+
+		`<a:graphic><a:graphicData uri="%s"><pic:pic><pic:nvPicPr><pic:cNvPr id="%d" name="Picture %d"/><pic:cNvPicPr/></pic:nvPicPr>`+
+		`<pic:blipFill><a:blip r:embed="%s"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>`+
+		`<pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr>`+
+		`</pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing></w:r></w:p>`,
+		cx, cy, id, id, nsPic, id, id, relID, cx, cy)
+}
+
+// imageSize returns element size in mm, keeping the aspect ratio of the
+// image when a dimension is missing and shrinking it to maxWidth.
+func imageSize(el templates.Element, cfg image.Config, maxWidth float64) (float64, float64) {
+	w, h := el.WidthMM, el.HeightMM
+	natW, natH := float64(cfg.Width)/pxPerMM, float64(cfg.Height)/pxPerMM
+	switch {
+	case w == 0 && h == 0:
+		w, h = natW, natH
+	case w == 0:
+		w = h * natW / natH
+	case h == 0:
+		h = w * natH / natW
+	}
+	if w > maxWidth {
+		h, w = h*maxWidth/w, maxWidth
+	}
+	return w, h
+}
+
+func (d *docx) table(el templates.Element) {
+	colW := twips(d.width) / len(el.Columns)
+	d.story.body.WriteString(`<w:tbl><w:tblPr><w:tblW w:w="5000" w:type="pct"/><w:tblBorders>`)
+	for _, side := range []string{"top", "left", "bottom", "right", "insideH", "insideV"} {
+		fmt.Fprintf(&d.story.body, `<w:%s w:val="single" w:sz="4" w:space="0" w:color="000000"/>`, side)
+	}
+	d.story.body.WriteString(`</w:tblBorders></w:tblPr><w:tblGrid>`)
+	for range el.Columns {
+		fmt.Fprintf(&d.story.body, `<w:gridCol w:w="%d"/>`, colW)
+	}
+	d.story.body.WriteString(`</w:tblGrid><w:tr><w:trPr><w:tblHeader/></w:trPr>`)
+	header := el.Style
+	header.Bold = true
+	for _, col := range el.Columns {
+		d.cell(colW, header, col.Header)
+	}
+	d.story.body.WriteString("</w:tr>")
+	for _, row := range d.in.rows(el.Field) {
+		d.story.body.WriteString("<w:tr>")
+		for _, col := range el.Columns {
+			d.cell(colW, el.Style, d.in.cell(el, col, row))
+		}
+		d.story.body.WriteString("</w:tr>")
+	}
+	// Word expects a paragraph between a table and what follows it.
+	d.story.body.WriteString("</w:tbl><w:p/>")
+}
+
+func (d *docx) cell(width int, style templates.Style, text string) {
+	fmt.Fprintf(&d.story.body, `<w:tc><w:tcPr><w:tcW w:w="%d" w:type="dxa"/></w:tcPr>`, width)
+	d.paragraph(style, text)
+	d.story.body.WriteString("</w:tc>")
+}
+
+// pack writes OPC package parts into a zip archive.
+func (d *docx) pack() ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	namespaces := `xmlns:w="` + nsW + `" xmlns:r="` + nsR + `" xmlns:wp="` + nsWP + `" xmlns:a="` + nsA + `" xmlns:pic="` + nsPic + `"`
+	types := docxContentTypes
+	parts := []docxPart{
+		{"_rels/.rels", []byte(xml.Header + `<Relationships xmlns="` + nsRel + `">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+			`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>` +
+			`</Relationships>`)},
+		{"docProps/core.xml", []byte(xml.Header + `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/">` +
+			`<dc:title>` + escape(d.in.Template.Name) + `</dc:title></cp:coreProperties>`)},
+		{"word/styles.xml", []byte(fmt.Sprintf(docxStyles, fontSizePt*2))},
+		{"word/document.xml", []byte(xml.Header + `<w:document ` + namespaces + `><w:body>` +
+			d.main.body.String() + `</w:body></w:document>`)},
+	}
+	for _, region := range []struct {
+		story      *docxStory
+		name, root string
+	}{{d.header, "header1.xml", "hdr"}, {d.footer, "footer1.xml", "ftr"}} {
+		if region.story == nil {
+			continue
+		}
+		parts = append(parts,
+			docxPart{"word/" + region.name, []byte(xml.Header + `<w:` + region.root + ` ` + namespaces + `>` +
+				region.story.body.String() + `</w:` + region.root + `>`)},
+			docxPart{"word/_rels/" + region.name + ".rels", region.story.relationships()})
+		kind := strings.TrimSuffix(region.name, "1.xml")
+		types += `<Override PartName="/word/` + region.name + `" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.` + kind + `+xml"/>`
+	}
+	parts = append(parts,
+		docxPart{"word/_rels/document.xml.rels", d.main.relationships()},
+		docxPart{"[Content_Types].xml", []byte(types + `</Types>`)})
+	for _, part := range append(parts, d.media...) {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: part.name, Method: zip.Deflate})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(part.data); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *docxStory) relationships() []byte {
+	var b strings.Builder
+	b.WriteString(xml.Header + `<Relationships xmlns="` + nsRel + `">`)
+	for _, rel := range s.rels {
+		fmt.Fprintf(&b, `<Relationship Id="%s" Type="%s" Target="%s"/>`, rel.id, rel.typ, rel.target)
+	}
+	b.WriteString("</Relationships>")
+	return []byte(b.String())
+}
+
+func twips(mm float64) int {
+	return int(mm*twipsPerMM + 0.5)
+}
+
+func escape(s string) string {
+	var b strings.Builder
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// docxContentTypes is left open for pack to add overrides of header and footer.
+const docxContentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Default Extension="png" ContentType="image/png"/>` +
+	`<Default Extension="jpeg" ContentType="image/jpeg"/>` +
+	`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>` +
+	`<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>` +
+	`<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>`
+
+const docxStyles = xml.Header + `<w:styles xmlns:w="` + nsW + `"><w:docDefaults>` +
+	`<w:rPrDefault><w:rPr><w:rFonts w:ascii="Arial" w:hAnsi="Arial" w:cs="Arial" w:eastAsia="Arial"/><w:sz w:val="%d"/></w:rPr></w:rPrDefault>` +
+	`<w:pPrDefault><w:pPr><w:spacing w:after="120"/></w:pPr></w:pPrDefault>` +
+	`</w:docDefaults></w:styles>`
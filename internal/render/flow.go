@@ -0,0 +1,297 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"strconv"
+
+	"github.com/lumiforge/docfactory-backend/internal/barcode"
+	"github.com/lumiforge/docfactory-backend/internal/templates"
+)
+
+// surface receives output of the page layout shared by fixed-layout
+// formats. Coordinates are millimetres from the top-left corner of the
+// page; text is positioned by its baseline.
+type surface interface {
+	page(width, height float64)
+	text(x, y float64, s string, f font, size float64)
+	fill(r rect)
+	stroke(r rect)
+	// image draws picture; key identifies the same picture drawn again.
+	image(r rect, img image.Image, key string)
+}
+
+// barcodeSurface is implemented by surfaces that draw barcodes with native
+// commands of the output device instead of filled modules.
+type barcodeSurface interface {
+	barcode(r rect, code *barcode.Code, el templates.Element, content string)
+}
+
+// cellPaddingMM is the space between table borders and cell text.
+const cellPaddingMM = 1.5
+
+// regionMM is the distance of header and footer from the page edge, the
+// same as in DOCX output.
+const regionMM = 12.5
+
+// flow places layout elements top to bottom, starting a new page when an
+// element or table row does not fit. Every page gets the header and footer
+// of the layout.
+type flow struct {
+	in Input
+	s  surface
+	// trimW and trimH are the page size without bleed; page breaks with
+	// orientation swap them.
+	trimW, trimH float64
+	bleed        float64
+	margins      templates.Margins
+	area         rect // content area inside margins
+	y            float64
+	page, pages  int
+	// region is set while header or footer is drawn; regions never break
+	// pages.
+	region bool
+	images map[string]image.Image
+	err    error
+}
+
+// layout runs the flow twice: the first pass counts pages, so that the
+// second can print "page X of Y".
+func layout(in Input, s surface) error {
+	images, err := decodeImages(in)
+	if err != nil {
+		return err
+	}
+	counter := &pageCounter{}
+	if err := newFlow(in, counter, images).run(); err != nil {
+		return err
+	}
+	f := newFlow(in, s, images)
+	f.pages = counter.pages
+	return f.run()
+}
+
+func newFlow(in Input, s surface, images map[string]image.Image) *flow {
+	f := &flow{in: in, s: s, bleed: in.Template.BleedMM(), margins: in.Template.MarginsMM(), images: images}
+	f.resize(in.Template.PageDimensions())
+	return f
+}
+
+func decodeImages(in Input) (map[string]image.Image, error) {
+	images := map[string]image.Image{}
+	for _, id := range in.Template.Layout.AssetIDs() {
+		asset, ok := in.Images[id]
+		if !ok {
+			return nil, fmt.Errorf("render: image asset %s is missing", id)
+		}
+		img, _, err := image.Decode(bytes.NewReader(asset.Data))
+		if err != nil {
+			return nil, fmt.Errorf("render: decode image asset %s: %w", id, err)
+		}
+		images[id] = img
+	}
+	return images, nil
+}
+
+func (f *flow) resize(width, height float64) {
+	f.trimW, f.trimH = width, height
+	m := f.margins
+	f.area = rect{f.bleed + m.Left, f.bleed + m.Top, width - m.Left - m.Right, height - m.Top - m.Bottom}
+}
+
+func (f *flow) run() error {
+	f.newPage()
+	for _, el := range f.in.Template.Layout.Elements {
+		if err := f.element(el); err != nil {
+			return err
+		}
+	}
+	return f.err
+}
+
+func (f *flow) element(el templates.Element) error {
+	switch el.Type {
+	case templates.ElementText:
+		if f.region {
+			f.paragraph(el.Style, f.in.pageText(el.Text, strconv.Itoa(f.page), strconv.Itoa(f.pages)))
+		} else {
+			f.paragraph(el.Style, f.in.text(el.Text))
+		}
+	case templates.ElementImage:
+		f.image(el)
+	case templates.ElementTable:
+		f.table(el)
+	case templates.ElementBarcode, templates.ElementVerification:
+		return f.barcode(el)
+	case templates.ElementPageBreak:
+		if o := el.Orientation; o != "" && (o == templates.OrientationLandscape) != (f.trimW > f.trimH) {
+			f.resize(f.trimH, f.trimW)
+		}
+		f.newPage()
+	}
+	return nil
+}
+
+func (f *flow) newPage() {
+	f.page++
+	f.s.page(f.trimW+2*f.bleed, f.trimH+2*f.bleed)
+	f.y = f.area.y
+	layout := f.in.Template.Layout
+	if r := layout.Header; r != nil && !(r.SkipFirstPage && f.page == 1) {
+		f.drawRegion(r, f.bleed+regionMM)
+	}
+	if r := layout.Footer; r != nil && !(r.SkipFirstPage && f.page == 1) {
+		// Measure on a blank surface first to align the footer bottom.
+		s := f.s
+		f.s = &pageCounter{}
+		h := f.drawRegion(r, 0)
+		f.s = s
+		f.drawRegion(r, f.bleed+f.trimH-regionMM-h)
+	}
+}
+
+// drawRegion draws header or footer starting at y and returns its height.
+func (f *flow) drawRegion(r *templates.Region, y float64) float64 {
+	body := f.y
+	f.y, f.region = y, true
+	for _, el := range r.Elements {
+		if err := f.element(el); err != nil && f.err == nil {
+			f.err = err
+		}
+	}
+	h := f.y - y - spacingPt*ptMM
+	f.y, f.region = body, false
+	return h
+}
+
+// contentWidth is the width between page margins.
+func (f *flow) contentWidth() float64 {
+	return f.area.w
+}
+
+// reserve starts a new page unless h fits above the bottom margin. Content
+// taller than a page is placed at the top of a fresh one and overflows.
+func (f *flow) reserve(h float64) {
+	if !f.region && f.y+h > f.area.y+f.area.h && f.y > f.area.y {
+		f.newPage()
+	}
+}
+
+// pageCounter is a surface that only counts pages.
+type pageCounter struct {
+	pages int
+}
+
+func (c *pageCounter) page(width, height float64)                        { c.pages++ }
+func (c *pageCounter) text(x, y float64, s string, f font, size float64) {}
+func (c *pageCounter) fill(r rect)                                       {}
+func (c *pageCounter) stroke(r rect)                                     {}
+func (c *pageCounter) image(r rect, img image.Image, key string)         {}
+
+// left returns x of box of width w aligned within [x, x+avail].
+func left(align templates.Align, x, w, avail float64) float64 {
+	switch align {
+	case templates.AlignCenter:
+		return x + (avail-w)/2
+	case templates.AlignRight:
+		return x + avail - w
+	}
+	return x
+}
+
+func fontOf(style templates.Style) (font, float64) {
+	size := style.FontSize
+	if size == 0 {
+		size = fontSizePt
+	}
+	return styleFont(style.Bold, style.Italic), size
+}
+
+func (f *flow) paragraph(style templates.Style, text string) {
+	fnt, size := fontOf(style)
+	lh := size * lineHeight * ptMM
+	for _, line := range wrap(text, fnt, size, f.contentWidth()) {
+		f.reserve(lh)
+		x := left(style.Align, f.area.x, textWidth(line, fnt, size), f.contentWidth())
+		f.s.text(x, f.y+size*ascent*ptMM, line, fnt, size)
+		f.y += lh
+	}
+	f.y += spacingPt * ptMM
+}
+
+func (f *flow) image(el templates.Element) {
+	img := f.images[el.AssetID]
+	b := img.Bounds()
+	w, h := imageSize(el, image.Config{Width: b.Dx(), Height: b.Dy()}, f.contentWidth())
+	f.reserve(h)
+	f.s.image(rect{left(el.Style.Align, f.area.x, w, f.contentWidth()), f.y, w, h}, img, el.AssetID)
+	f.y += h + spacingPt*ptMM
+}
+
+func (f *flow) barcode(el templates.Element) error {
+	code, content, err := f.in.barcode(el)
+	if err != nil || code == nil {
+		return err
+	}
+	w, h := barcodeSize(el, f.contentWidth())
+	f.reserve(h)
+	box := rect{left(el.Style.Align, f.area.x, w, f.contentWidth()), f.y, w, h}
+	if bs, ok := f.s.(barcodeSurface); ok {
+		bs.barcode(box, code, el, content)
+	} else {
+		for _, r := range barcodeRects(code, box) {
+			f.s.fill(r)
+		}
+	}
+	f.y += h + spacingPt*ptMM
+	return nil
+}
+
+// table draws bordered grid of equal-width columns with a bold header row,
+// repeated at the top of every page the table continues on.
+func (f *flow) table(el templates.Element) {
+	header := el.Style
+	header.Bold = true
+	headers := make([]string, len(el.Columns))
+	for i, col := range el.Columns {
+		headers[i] = col.Header
+	}
+	repeat := func() { f.row(header, headers, nil) }
+	repeat()
+	cells := make([]string, len(el.Columns))
+	for _, item := range f.in.rows(el.Field) {
+		for i, col := range el.Columns {
+			cells[i] = f.in.cell(el, col, item)
+		}
+		f.row(el.Style, cells, repeat)
+	}
+	f.y += spacingPt * ptMM
+}
+
+// row draws table row; onBreak runs when the row moves to a new page.
+func (f *flow) row(style templates.Style, cells []string, onBreak func()) {
+	fnt, size := fontOf(style)
+	lh := size * lineHeight * ptMM
+	colW := f.contentWidth() / float64(len(cells))
+	lines := make([][]string, len(cells))
+	h := 0.0
+	for i, text := range cells {
+		lines[i] = wrap(text, fnt, size, colW-2*cellPaddingMM)
+		h = max(h, float64(len(lines[i]))*lh+2*cellPaddingMM)
+	}
+	page := f.page
+	f.reserve(h)
+	if f.page != page && onBreak != nil {
+		onBreak()
+	}
+	for i := range cells {
+		x := f.area.x + float64(i)*colW
+		f.s.stroke(rect{x, f.y, colW, h})
+		for j, line := range lines[i] {
+			lx := left(style.Align, x+cellPaddingMM, textWidth(line, fnt, size), colW-2*cellPaddingMM)
+			f.s.text(lx, f.y+cellPaddingMM+float64(j)*lh+size*ascent*ptMM, line, fnt, size)
+		}
+	}
+	f.y += h
+}
@@ -0,0 +1,249 @@
+package render
+
+import "strings"
+
+// font selects one of the standard Helvetica faces, which PDF viewers provide
+// without embedding.
+type font int
+
+const (
+	fontRegular font = iota
+	fontBold
+	fontOblique
+	fontBoldOblique
+)
+
+func styleFont(bold, italic bool) font {
+	f := fontRegular
+	if bold {
+		f |= fontBold
+	}
+	if italic {
+		f |= fontOblique
+	}
+	return f
+}
+
+// helveticaWidths holds advance widths of characters 32-126 in 1/1000 em for
+// regular and bold Helvetica; oblique faces share them.
+var helveticaWidths = [2][95]int{
+	{
+		278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+		1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+		333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+		556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+	},
+	{
+		278, 333, 474, 556, 556, 889, 722, 238, 333, 333, 389, 584, 278, 333, 278, 278,
+		556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 333, 333, 584, 584, 584, 611,
+		975, 722, 722, 722, 722, 667, 611, 778, 722, 278, 556, 722, 611, 833, 722, 778,
+		667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 333, 278, 333, 584, 556,
+		333, 556, 611, 556, 611, 556, 333, 611, 611, 278, 278, 556, 278, 889, 611, 611,
+		611, 611, 389, 556, 333, 611, 556, 778, 556, 556, 500, 389, 280, 389, 584,
+	},
+}
+
+// ptMM converts points to millimetres.
+const ptMM = 25.4 / 72
+
+// Text metrics relative to font size.
+const (
+	lineHeight = 1.2
+	ascent     = 0.8
+	capHeight  = 0.72
+)
+
+// width returns advance width of r in 1/1000 em. Characters outside ASCII
+// use the width of a digit.
+func (f font) width(r rune) int {
+	if r >= 32 && r <= 126 {
+		return helveticaWidths[f&fontBold][r-32]
+	}
+	return 556
+}
+
+// textWidth returns width of s in millimetres.
+func textWidth(s string, f font, size float64) float64 {
+	w := 0
+	for _, r := range s {
+		w += f.width(r)
+	}
+	return float64(w) * size / 1000 * ptMM
+}
+
+// wrap breaks text into lines no wider than width; explicit newlines always
+// break. Words longer than a line are split between characters.
+func wrap(text string, f font, size, width float64) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			candidate := word
+			if line != "" {
+				candidate = line + " " + word
+			}
+			if textWidth(candidate, f, size) <= width {
+				line = candidate
+				continue
+			}
+			if line != "" {
+				lines = append(lines, line)
+			}
+			line = ""
+			for _, r := range word {
+				if line != "" && textWidth(line+string(r), f, size) > width {
+					lines = append(lines, line)
+					line = ""
+				}
+				line += string(r)
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// winAnsi returns WinAnsiEncoding byte of r; characters the standard fonts
+// cannot show become '?'.
+func winAnsi(r rune) byte {
+	switch {
+	case r >= 32 && r <= 126, r >= 0xa0 && r <= 0xff:
+		return byte(r)
+	}
+	switch r {
+	case '\u202f': // narrow no-break space groups digits in French
+		return 0xa0
+	case '€':
+		return 0x80
+	case '‘':
+		return 0x91
+	case '’':
+		return 0x92
+	case '“':
+		return 0x93
+	case '”':
+		return 0x94
+	case '•':
+		return 0x95
+	case '–':
+		return 0x96
+	case '—':
+		return 0x97
+	case '…':
+		return 0x85
+	}
+	return '?'
+}
+
+// glyphs is a 5x7 bitmap font used to raster text. Rows run top to bottom;
+// rows past the seventh hang below the baseline.
+var glyphs = map[rune]string{
+	' ':  "..... ..... ..... ..... ..... ..... .....",
+	'!':  "..#.. ..#.. ..#.. ..#.. ..#.. ..... ..#..",
+	'"':  ".#.#. .#.#. ..... ..... ..... ..... .....",
+	'#':  ".#.#. .#.#. ##### .#.#. ##### .#.#. .#.#.",
+	'$':  "..#.. .#### #.#.. .###. ..#.# ####. ..#..",
+	'%':  "##... ##..# ...#. ..#.. .#... #..## ...##",
+	'&':  ".##.. #..#. #.#.. .#... #.#.# #..#. .##.#",
+	'\'': "..#.. ..#.. ..... ..... ..... ..... .....",
+	'(':  "...#. ..#.. .#... .#... .#... ..#.. ...#.",
+	')':  ".#... ..#.. ...#. ...#. ...#. ..#.. .#...",
+	'*':  "..... ..#.. #.#.# .###. #.#.# ..#.. .....",
+	'+':  "..... ..#.. ..#.. ##### ..#.. ..#.. .....",
+	',':  "..... ..... ..... ..... ..... ..##. ..##. ...#. ..#..",
+	'-':  "..... ..... ..... ##### ..... ..... .....",
+	'.':  "..... ..... ..... ..... ..... .##.. .##..",
+	'/':  "..... ....# ...#. ..#.. .#... #.... .....",
+	'0':  ".###. #...# #..## #.#.# ##..# #...# .###.",
+	'1':  "..#.. .##.. ..#.. ..#.. ..#.. ..#.. .###.",
+	'2':  ".###. #...# ....# ...#. ..#.. .#... #####",
+	'3':  "##### ...#. ..#.. ...#. ....# #...# .###.",
+	'4':  "...#. ..##. .#.#. #..#. ##### ...#. ...#.",
+	'5':  "##### #.... ####. ....# ....# #...# .###.",
+	'6':  "..##. .#... #.... ####. #...# #...# .###.",
+	'7':  "##### ....# ...#. ..#.. .#... .#... .#...",
+	'8':  ".###. #...# #...# .###. #...# #...# .###.",
+	'9':  ".###. #...# #...# .#### ....# ...#. .##..",
+	':':  "..... .##.. .##.. ..... .##.. .##.. .....",
+	';':  "..... .##.. .##.. ..... .##.. .##.. ..#.. .#...",
+	'<':  "...#. ..#.. .#... #.... .#... ..#.. ...#.",
+	'=':  "..... ..... ##### ..... ##### ..... .....",
+	'>':  ".#... ..#.. ...#. ....# ...#. ..#.. .#...",
+	'?':  ".###. #...# ....# ...#. ..#.. ..... ..#..",
+	'@':  ".###. #...# ....# .##.# #.#.# #.#.# .###.",
+	'A':  ".###. #...# #...# ##### #...# #...# #...#",
+	'B':  "####. #...# #...# ####. #...# #...# ####.",
+	'C':  ".###. #...# #.... #.... #.... #...# .###.",
+	'D':  "###.. #..#. #...# #...# #...# #..#. ###..",
+	'E':  "##### #.... #.... ####. #.... #.... #####",
+	'F':  "##### #.... #.... ####. #.... #.... #....",
+	'G':  ".###. #...# #.... #.### #...# #...# .####",
+	'H':  "#...# #...# #...# ##### #...# #...# #...#",
+	'I':  ".###. ..#.. ..#.. ..#.. ..#.. ..#.. .###.",
+	'J':  "..### ...#. ...#. ...#. ...#. #..#. .##..",
+	'K':  "#...# #..#. #.#.. ##... #.#.. #..#. #...#",
+	'L':  "#.... #.... #.... #.... #.... #.... #####",
+	'M':  "#...# ##.## #.#.# #.#.# #...# #...# #...#",
+	'N':  "#...# #...# ##..# #.#.# #..## #...# #...#",
+	'O':  ".###. #...# #...# #...# #...# #...# .###.",
+	'P':  "####. #...# #...# ####. #.... #.... #....",
+	'Q':  ".###. #...# #...# #...# #.#.# #..#. .##.#",
+	'R':  "####. #...# #...# ####. #.#.. #..#. #...#",
+	'S':  ".#### #.... #.... .###. ....# ....# ####.",
+	'T':  "##### ..#.. ..#.. ..#.. ..#.. ..#.. ..#..",
+	'U':  "#...# #...# #...# #...# #...# #...# .###.",
+	'V':  "#...# #...# #...# #...# #...# .#.#. ..#..",
+	'W':  "#...# #...# #...# #.#.# #.#.# #.#.# .#.#.",
+	'X':  "#...# #...# .#.#. ..#.. .#.#. #...# #...#",
+	'Y':  "#...# #...# .#.#. ..#.. ..#.. ..#.. ..#..",
+	'Z':  "##### ....# ...#. ..#.. .#... #.... #####",
+	'[':  ".###. .#... .#... .#... .#... .#... .###.",
+	'\\': "..... #.... .#... ..#.. ...#. ....# .....",
+	']':  ".###. ...#. ...#. ...#. ...#. ...#. .###.",
+	'^':  "..#.. .#.#. #...# ..... ..... ..... .....",
+	'_':  "..... ..... ..... ..... ..... ..... #####",
+	'`':  ".#... ..#.. ..... ..... ..... ..... .....",
+	'a':  "..... ..... .###. ....# .#### #...# .####",
+	'b':  "#.... #.... #.##. ##..# #...# #...# ####.",
+	'c':  "..... ..... .###. #.... #.... #...# .###.",
+	'd':  "....# ....# .##.# #..## #...# #...# .####",
+	'e':  "..... ..... .###. #...# ##### #.... .###.",
+	'f':  "..##. .#..# .#... ###.. .#... .#... .#...",
+	'g':  "..... ..... .#### #...# #...# #...# .#### ....# .###.",
+	'h':  "#.... #.... #.##. ##..# #...# #...# #...#",
+	'i':  "..#.. ..... .##.. ..#.. ..#.. ..#.. .###.",
+	'j':  "...#. ..... ..##. ...#. ...#. ...#. ...#. #..#. .##..",
+	'k':  "#.... #.... #..#. #.#.. ##... #.#.. #..#.",
+	'l':  ".##.. ..#.. ..#.. ..#.. ..#.. ..#.. .###.",
+	'm':  "..... ..... ##.#. #.#.# #.#.# #...# #...#",
+	'n':  "..... ..... #.##. ##..# #...# #...# #...#",
+	'o':  "..... ..... .###. #...# #...# #...# .###.",
+	'p':  "..... ..... ####. #...# #...# #...# ####. #.... #....",
+	'q':  "..... ..... .#### #...# #...# #...# .#### ....# ....#",
+	'r':  "..... ..... #.##. ##..# #.... #.... #....",
+	's':  "..... ..... .###. #.... .###. ....# ####.",
+	't':  ".#... .#... ###.. .#... .#... .#..# ..##.",
+	'u':  "..... ..... #...# #...# #...# #..## .##.#",
+	'v':  "..... ..... #...# #...# #...# .#.#. ..#..",
+	'w':  "..... ..... #...# #...# #.#.# #.#.# .#.#.",
+	'x':  "..... ..... #...# .#.#. ..#.. .#.#. #...#",
+	'y':  "..... ..... #...# #...# #...# #...# .#### ....# .###.",
+	'z':  "..... ..... ##### ...#. ..#.. .#... #####",
+	'{':  "...#. ..#.. ..#.. .#... ..#.. ..#.. ...#.",
+	'|':  "..#.. ..#.. ..#.. ..#.. ..#.. ..#.. ..#..",
+	'}':  ".#... ..#.. ..#.. ...#. ..#.. ..#.. .#...",
+	'~':  "..... ..... .#... #.#.# ...#. ..... .....",
+}
+
+// missingGlyph is drawn for characters without bitmap.
+const missingGlyph = "##### #...# #...# #...# #...# #...# #####"
+
+func glyph(r rune) []string {
+	g, ok := glyphs[r]
+	if !ok {
+		g = missingGlyph
+	}
+	return strings.Fields(g)
+}
@@ -0,0 +1,214 @@
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html"
+	"image"
+	"strings"
+
+	"github.com/lumiforge/docfactory-backend/internal/templates"
+)
+
+// htmlDoc accumulates self-contained HTML page: styles are inline and
+// images are data URIs, so the file renders without network access.
+type htmlDoc struct {
+	in           Input
+	body         strings.Builder
+	pageW, pageH float64
+	margins      templates.Margins
+	width        float64 // content width in mm
+	rotated      bool    // inside section of the other orientation
+}
+
+// renderHTML writes HTML5 document sized for printing on the template page.
+// Sections of the other orientation use a named page. Header and footer are
+// fixed to the page edges, which print engines repeat on every page; page
+// numbers are CSS counters that only paged media engines fill in, and the
+// header is not skipped on the first page.
+func renderHTML(in Input) ([]byte, error) {
+	pageW, pageH := in.Template.PageDimensions()
+	m := in.Template.MarginsMM()
+	h := &htmlDoc{in: in, pageW: pageW, pageH: pageH, margins: m, width: pageW - m.Left - m.Right}
+	layout := in.Template.Layout
+	if err := h.region("header", layout.Header); err != nil {
+		return nil, err
+	}
+	for _, el := range layout.Elements {
+		if err := h.element(el); err != nil {
+			return nil, err
+		}
+	}
+	if h.rotated {
+		h.body.WriteString("</section>\n")
+	}
+	if err := h.region("footer", layout.Footer); err != nil {
+		return nil, err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>%s</title>
+<style>
+@page { size: %smm %smm; margin: %smm %smm %smm %smm; bleed: %smm; }
+@page rotated { size: %smm %smm; }
+section.rotated { page: rotated; width: %smm; }
+header, footer { position: fixed; left: 0; right: 0; }
+header { top: 0; }
+footer { bottom: 0; }
+.page::after { content: counter(page); }
+.pages::after { content: counter(pages); }
+@media screen { header, footer { position: static; } }
+body { width: %smm; margin: %smm auto %smm; font-family: Helvetica, Arial, sans-serif; font-size: %dpt; }
+@media print { body { margin: 0; } }
+p { margin: 0 0 %dpt; }
+table { width: 100%%; border-collapse: collapse; margin: 0 0 %dpt; }
+th, td { border: 0.5pt solid #000; padding: %smm; text-align: inherit; vertical-align: top; }
+svg { display: inline-block; }
+</style>
+</head>
+<body>
+`, html.EscapeString(in.Template.Name), num(pageW), num(pageH),
+		num(m.Top), num(m.Right), num(m.Bottom), num(m.Left), num(in.Template.BleedMM()),
+		num(pageH), num(pageW), num(pageH-m.Left-m.Right), num(h.width), num(m.Top), num(m.Bottom), fontSizePt, spacingPt, spacingPt, num(cellPaddingMM))
+	b.WriteString(h.body.String())
+	b.WriteString("</body>\n</html>\n")
+	return []byte(b.String()), nil
+}
+
+func (h *htmlDoc) element(el templates.Element) error {
+	switch el.Type {
+	case templates.ElementText:
+		text := html.EscapeString(h.in.text(el.Text))
+		fmt.Fprintf(&h.body, "<p%s>%s</p>\n", styleAttr(el.Style), strings.ReplaceAll(text, "\n", "<br>"))
+	case templates.ElementPageBreak:
+		h.pageBreak(el.Orientation)
+	case templates.ElementImage:
+		return h.image(el)
+	case templates.ElementTable:
+		h.table(el)
+	case templates.ElementBarcode, templates.ElementVerification:
+		return h.barcode(el)
+	}
+	return nil
+}
+
+// region writes header or footer. Text of regions may refer to page numbers.
+func (h *htmlDoc) region(tag string, region *templates.Region) error {
+	if region == nil {
+		return nil
+	}
+	fmt.Fprintf(&h.body, "<%s>\n", tag)
+	for _, el := range region.Elements {
+		if el.Type != templates.ElementText {
+			if err := h.element(el); err != nil {
+				return err
+			}
+			continue
+		}
+		text := html.EscapeString(h.in.pageText(el.Text, string(pageMark), string(pagesMark)))
+		text = strings.NewReplacer("\n", "<br>", string(pageMark), `<span class="page"></span>`, string(pagesMark), `<span class="pages"></span>`).Replace(text)
+		fmt.Fprintf(&h.body, "<p%s>%s</p>\n", styleAttr(el.Style), text)
+	}
+	fmt.Fprintf(&h.body, "</%s>\n", tag)
+	return nil
+}
+
+// pageBreak starts a new page; a change of orientation opens or closes the
+// rotated section, which breaks the page by itself.
+func (h *htmlDoc) pageBreak(orientation templates.Orientation) {
+	landscape := h.pageW > h.pageH
+	if orientation == "" || (orientation == templates.OrientationLandscape) == (landscape != h.rotated) {
+		h.body.WriteString(`<div style="break-after: page"></div>` + "\n")
+		return
+	}
+	if h.rotated {
+		h.body.WriteString("</section>\n")
+	} else {
+		h.body.WriteString(`<section class="rotated">` + "\n")
+	}
+	h.rotated = !h.rotated
+	width := h.pageW
+	if h.rotated {
+		width = h.pageH
+	}
+	h.width = width - h.margins.Left - h.margins.Right
+}
+
+// styleAttr returns style attribute of text style, empty for defaults.
+func styleAttr(style templates.Style) string {
+	var css []string
+	if style.FontSize != 0 {
+		css = append(css, "font-size: "+num(style.FontSize)+"pt")
+	}
+	if style.Bold {
+		css = append(css, "font-weight: bold")
+	}
+	if style.Italic {
+		css = append(css, "font-style: italic")
+	}
+	if style.Align != "" {
+		css = append(css, "text-align: "+string(style.Align))
+	}
+	if len(css) == 0 {
+		return ""
+	}
+	return ` style="` + strings.Join(css, "; ") + `"`
+}
+
+func (h *htmlDoc) image(el templates.Element) error {
+	img, ok := h.in.Images[el.AssetID]
+	if !ok {
+		return fmt.Errorf("render: image asset %s is missing", el.AssetID)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(img.Data))
+	if err != nil {
+		return fmt.Errorf("render: decode image asset %s: %w", el.AssetID, err)
+	}
+	w, hgt := imageSize(el, cfg, h.width)
+	fmt.Fprintf(&h.body, `<p%s><img src="data:%s;base64,%s" alt="" style="width: %smm; height: %smm"></p>`+"\n",
+		styleAttr(templates.Style{Align: el.Style.Align}), img.ContentType, base64.StdEncoding.EncodeToString(img.Data), num(w), num(hgt))
+	return nil
+}
+
+// barcode writes the symbol as inline SVG whose user units are millimetres.
+func (h *htmlDoc) barcode(el templates.Element) error {
+	code, content, err := h.in.barcode(el)
+	if err != nil || code == nil {
+		return err
+	}
+	w, hgt := barcodeSize(el, h.width)
+	var path strings.Builder
+	for _, r := range barcodeRects(code, rect{0, 0, w, hgt}) {
+		fmt.Fprintf(&path, "M%s %sh%sv%sh-%sz", svgNum(r.x), svgNum(r.y), svgNum(r.w), svgNum(r.h), svgNum(r.w))
+	}
+	fmt.Fprintf(&h.body, `<p%s><svg xmlns="http://www.w3.org/2000/svg" width="%smm" height="%smm" viewBox="0 0 %s %s" shape-rendering="crispEdges"><title>%s</title><path d="%s"/></svg></p>`+"\n",
+		styleAttr(templates.Style{Align: el.Style.Align}), num(w), num(hgt), svgNum(w), svgNum(hgt),
+		html.EscapeString(content), path.String())
+	return nil
+}
+
+// svgNum formats coordinate precisely enough for narrow barcode modules.
+func svgNum(v float64) string {
+	s := fmt.Sprintf("%.4f", v)
+	return strings.TrimRight(strings.TrimRight(s, "0"), ".")
+}
+
+func (h *htmlDoc) table(el templates.Element) {
+	fmt.Fprintf(&h.body, "<table%s>\n<thead><tr>", styleAttr(el.Style))
+	for _, col := range el.Columns {
+		fmt.Fprintf(&h.body, "<th>%s</th>", html.EscapeString(col.Header))
+	}
+	h.body.WriteString("</tr></thead>\n<tbody>\n")
+	for _, row := range h.in.rows(el.Field) {
+		h.body.WriteString("<tr>")
+		for _, col := range el.Columns {
+			fmt.Fprintf(&h.body, "<td>%s</td>", html.EscapeString(h.in.cell(el, col, row)))
+		}
+		h.body.WriteString("</tr>\n")
+	}
+	h.body.WriteString("</tbody>\n</table>\n")
+}
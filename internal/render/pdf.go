@@ -0,0 +1,271 @@
+package render
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+// pdfFonts are the standard Type 1 faces indexed by font.
+var pdfFonts = [...]string{"Helvetica", "Helvetica-Bold", "Helvetica-Oblique", "Helvetica-BoldOblique"}
+
+type pdfPage struct {
+	width, height float64
+	content       bytes.Buffer
+}
+
+type pdfImage struct {
+	width, height int
+	rgb           []byte
+}
+
+// pdf collects pages drawn by the layout. Text uses the standard Helvetica
+// fonts with WinAnsiEncoding, so characters outside Latin-1 print as '?'.
+type pdf struct {
+	pages  []*pdfPage
+	images []pdfImage
+	keys   map[string]int // image key -> index in images
+	// bleed surrounds the trimmed page; the media box includes it.
+	bleed float64
+}
+
+// renderPDF writes PDF 1.7 document.
+func renderPDF(in Input) ([]byte, error) {
+	p := &pdf{keys: map[string]int{}, bleed: in.Template.BleedMM()}
+	if err := layout(in, p); err != nil {
+		return nil, err
+	}
+	return p.write(in.Template.Name)
+}
+
+// MergePDF lays documents out one after another in a single PDF, as for
+// printing a batch. Page numbers restart with every document and images of
+// the same asset are embedded once.
+func MergePDF(ins []Input) ([]byte, error) {
+	var m PDFMerger
+	for _, in := range ins {
+		if err := m.Add(in); err != nil {
+			return nil, err
+		}
+	}
+	return m.Bytes()
+}
+
+// PDFMerger builds the document of MergePDF incrementally, so that callers
+// need not keep the inputs of every document until the end. The laid out
+// pages are still kept in memory.
+type PDFMerger struct {
+	p    *pdf
+	name string
+}
+
+// Add lays out document after those added before. The merger must not be
+// used after Add fails.
+func (m *PDFMerger) Add(in Input) error {
+	if m.p == nil {
+		m.p = &pdf{keys: map[string]int{}, bleed: in.Template.BleedMM()}
+		m.name = in.Template.Name
+	}
+	return layout(in, m.p)
+}
+
+// Bytes writes the merged document.
+func (m *PDFMerger) Bytes() ([]byte, error) {
+	if m.p == nil {
+		return nil, errors.New("render: no documents to merge")
+	}
+	return m.p.write(m.name)
+}
+
+func (p *pdf) page(width, height float64) {
+	p.pages = append(p.pages, &pdfPage{width: width, height: height})
+}
+
+func (p *pdf) current() *pdfPage {
+	return p.pages[len(p.pages)-1]
+}
+
+func (p *pdf) text(x, y float64, s string, f font, size float64) {
+	if s == "" {
+		return
+	}
+	pg := p.current()
+	fmt.Fprintf(&pg.content, "BT /F%d %s Tf %s %s Td %s Tj ET\n", f, num(size), pt(x), pt(pg.height-y), pdfText(s))
+}
+
+func (p *pdf) fill(r rect) {
+	pg := p.current()
+	fmt.Fprintf(&pg.content, "%s %s %s %s re f\n", pt(r.x), pt(pg.height-r.y-r.h), pt(r.w), pt(r.h))
+}
+
+func (p *pdf) stroke(r rect) {
+	pg := p.current()
+	fmt.Fprintf(&pg.content, "0.5 w %s %s %s %s re S\n", pt(r.x), pt(pg.height-r.y-r.h), pt(r.w), pt(r.h))
+}
+
+func (p *pdf) image(r rect, img image.Image, key string) {
+	i, ok := p.keys[key]
+	if !ok {
+		i = len(p.images)
+		p.keys[key] = i
+		p.images = append(p.images, flatten(img))
+	}
+	pg := p.current()
+	fmt.Fprintf(&pg.content, "q %s 0 0 %s %s %s cm /Im%d Do Q\n", pt(r.w), pt(r.h), pt(r.x), pt(pg.height-r.y-r.h), i)
+}
+
+// flatten converts img to 8-bit RGB composed over white, dropping alpha.
+func flatten(img image.Image) pdfImage {
+	b := img.Bounds()
+	rgb := make([]byte, 0, b.Dx()*b.Dy()*3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			a := int(c.A)
+			over := func(v uint8) byte { return byte((int(v)*a + 255*(255-a)) / 255) }
+			rgb = append(rgb, over(c.R), over(c.G), over(c.B))
+		}
+	}
+	return pdfImage{b.Dx(), b.Dy(), rgb}
+}
+
+// write serializes the document. Objects are numbered as catalog, page
+// tree, shared resources, info, fonts, images and then page and content
+// stream pairs.
+func (p *pdf) write(title string) ([]byte, error) {
+	const catalog, pages, resources, info, firstFont = 1, 2, 3, 4, 5
+	firstImage := firstFont + len(pdfFonts)
+	firstPage := firstImage + len(p.images)
+	var objects [][]byte
+
+	kids := make([]string, len(p.pages))
+	for i := range p.pages {
+		kids[i] = fmt.Sprintf("%d 0 R", firstPage+2*i)
+	}
+	objects = append(objects,
+		[]byte(fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pages)),
+		[]byte(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(p.pages))))
+
+	var res strings.Builder
+	res.WriteString("<< /ProcSet [/PDF /Text /ImageC] /Font <<")
+	for i := range pdfFonts {
+		fmt.Fprintf(&res, " /F%d %d 0 R", i, firstFont+i)
+	}
+	res.WriteString(" >> /XObject <<")
+	for i := range p.images {
+		fmt.Fprintf(&res, " /Im%d %d 0 R", i, firstImage+i)
+	}
+	res.WriteString(" >> >>")
+	objects = append(objects, []byte(res.String()),
+		[]byte(fmt.Sprintf("<< /Title %s /Producer (docfactory) >>", pdfTextString(title))))
+
+	for _, name := range pdfFonts {
+		objects = append(objects, []byte("<< /Type /Font /Subtype /Type1 /BaseFont /"+name+" /Encoding /WinAnsiEncoding >>"))
+	}
+	for _, img := range p.images {
+		obj, err := pdfStream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8", img.width, img.height), img.rgb)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+	for i, pg := range p.pages {
+		boxes := ""
+		if p.bleed > 0 {
+			boxes = fmt.Sprintf(" /BleedBox [0 0 %s %s] /TrimBox [%s %s %s %s]", pt(pg.width), pt(pg.height),
+				pt(p.bleed), pt(p.bleed), pt(pg.width-p.bleed), pt(pg.height-p.bleed))
+		}
+		objects = append(objects, []byte(fmt.Sprintf("<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %s %s]%s /Resources %d 0 R /Contents %d 0 R >>",
+			pages, pt(pg.width), pt(pg.height), boxes, resources, firstPage+2*i+1)))
+		obj, err := pdfStream("", pg.content.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, obj)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = buf.Len()
+		fmt.Fprintf(&buf, "%d 0 obj\n", i+1)
+		buf.Write(obj)
+		buf.WriteString("\nendobj\n")
+	}
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, catalog, info, xref)
+	return buf.Bytes(), nil
+}
+
+// pdfStream returns stream object with Flate-compressed data.
+func pdfStream(dict string, data []byte) ([]byte, error) {
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	if dict != "" {
+		dict += " "
+	}
+	var obj bytes.Buffer
+	fmt.Fprintf(&obj, "<< %s/Filter /FlateDecode /Length %d >>\nstream\n", dict, z.Len())
+	obj.Write(z.Bytes())
+	obj.WriteString("\nendstream")
+	return obj.Bytes(), nil
+}
+
+// pdfText returns literal string of s in WinAnsiEncoding.
+func pdfText(s string) string {
+	var b strings.Builder
+	b.WriteByte('(')
+	for _, r := range s {
+		c := winAnsi(r)
+		if c == '(' || c == ')' || c == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(c)
+	}
+	b.WriteByte(')')
+	return b.String()
+}
+
+// pdfTextString returns s as UTF-16BE hex string, the form of document
+// metadata that carries any character.
+func pdfTextString(s string) string {
+	var b strings.Builder
+	b.WriteString("<FEFF")
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	b.WriteByte('>')
+	return b.String()
+}
+
+// pt converts millimetres to points.
+func pt(mm float64) string {
+	return num(mm / ptMM)
+}
+
+// num formats number with at most two decimals.
+func num(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 2, 64)
+	s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
@@ -0,0 +1,160 @@
+package render
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+
+	"github.com/lumiforge/docfactory-backend/internal/barcode"
+	"github.com/lumiforge/docfactory-backend/internal/templates"
+)
+
+// pngDPI is the resolution of PNG output. Labels use the DPI of their
+// printer so the image previews the printed dots.
+const pngDPI = 150
+
+// raster draws pages as bitmaps. Text uses a built-in bitmap font placed at
+// Helvetica advance widths, so line breaks match the PDF output.
+type raster struct {
+	dpi   float64
+	pages []*image.RGBA
+}
+
+// renderPNG writes the document as one image; pages, bleed included, are
+// stacked vertically and separated by a grey gap.
+func renderPNG(in Input) ([]byte, error) {
+	r := &raster{dpi: pngDPI}
+	if label := in.Template.Label; label.Set() {
+		r.dpi = float64(label.DPI)
+	}
+	if err := layout(in, r); err != nil {
+		return nil, err
+	}
+	return r.encode()
+}
+
+func (r *raster) px(mm float64) int {
+	return int(math.Round(mm * r.dpi / 25.4))
+}
+
+func (r *raster) current() *image.RGBA {
+	return r.pages[len(r.pages)-1]
+}
+
+func (r *raster) page(width, height float64) {
+	img := image.NewRGBA(image.Rect(0, 0, r.px(width), r.px(height)))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	r.pages = append(r.pages, img)
+}
+
+// box fills pixels covered by rect given in pixels; edges are rounded so
+// adjacent rects tile without gaps and every rect is at least a pixel.
+func (r *raster) box(x, y, w, h float64) {
+	x0, y0 := int(math.Round(x)), int(math.Round(y))
+	x1, y1 := max(int(math.Round(x+w)), x0+1), max(int(math.Round(y+h)), y0+1)
+	draw.Draw(r.current(), image.Rect(x0, y0, x1, y1), image.Black, image.Point{}, draw.Src)
+}
+
+func (r *raster) fill(rc rect) {
+	k := r.dpi / 25.4
+	r.box(rc.x*k, rc.y*k, rc.w*k, rc.h*k)
+}
+
+func (r *raster) stroke(rc rect) {
+	k := r.dpi / 25.4
+	line := max(1, math.Round(0.5*ptMM*k))
+	x, y, w, h := rc.x*k, rc.y*k, rc.w*k, rc.h*k
+	r.box(x, y, w, line)
+	r.box(x, y+h-line, w, line)
+	r.box(x, y, line, h)
+	r.box(x+w-line, y, line, h)
+}
+
+// barcode snaps modules to whole pixels, as printers snap them to dots, so
+// bars keep equal widths. The symbol is shrunk to fit the box when needed.
+func (r *raster) barcode(rc rect, code *barcode.Code, _ templates.Element, _ string) {
+	k := r.dpi / 25.4
+	cols := code.Width() + 2*code.QuietZone
+	module := max(1, int(rc.w*k)/cols)
+	box := rect{math.Round(rc.x * k), math.Round(rc.y * k), float64(module * cols), math.Round(rc.h * k)}
+	if !code.Linear() {
+		box.h = float64(module * (code.Height() + 2*code.QuietZone))
+	}
+	for _, b := range barcodeRects(code, box) {
+		r.box(b.x, b.y, b.w, b.h)
+	}
+}
+
+// image scales img to rc with nearest-neighbour sampling.
+func (r *raster) image(rc rect, img image.Image, _ string) {
+	dst := image.Rect(r.px(rc.x), r.px(rc.y), r.px(rc.x+rc.w), r.px(rc.y+rc.h))
+	if dst.Empty() {
+		return
+	}
+	src := img.Bounds()
+	scaled := image.NewRGBA(image.Rect(0, 0, dst.Dx(), dst.Dy()))
+	for y := range dst.Dy() {
+		sy := src.Min.Y + y*src.Dy()/dst.Dy()
+		for x := range dst.Dx() {
+			scaled.Set(x, y, img.At(src.Min.X+x*src.Dx()/dst.Dx(), sy))
+		}
+	}
+	draw.Draw(r.current(), dst, scaled, image.Point{}, draw.Over)
+}
+
+// text draws glyphs scaled so that seven rows span the cap height. Bold
+// widens strokes and oblique shears glyphs to the right.
+func (r *raster) text(x, y float64, s string, f font, size float64) {
+	k := r.dpi / 25.4
+	dot := size * capHeight * ptMM * k / 7
+	pen, base := x*k, y*k
+	for _, ch := range s {
+		advance := float64(f.width(ch)) * size / 1000 * ptMM * k
+		gx := pen + (advance-5*dot)/2
+		for row, bits := range glyph(ch) {
+			top := base - float64(7-row)*dot
+			shift := 0.0
+			if f&fontOblique != 0 {
+				shift = float64(7-row) * dot * 0.2
+			}
+			for col, bit := range bits {
+				if bit != '#' {
+					continue
+				}
+				w := dot
+				if f&fontBold != 0 {
+					w += dot / 2
+				}
+				r.box(gx+float64(col)*dot+shift, top, w, dot)
+			}
+		}
+		pen += advance
+	}
+}
+
+func (r *raster) encode() ([]byte, error) {
+	out := r.pages[0]
+	if len(r.pages) > 1 {
+		gap := r.px(5)
+		width, height := 0, gap*(len(r.pages)-1)
+		for _, pg := range r.pages {
+			width = max(width, pg.Bounds().Dx())
+			height += pg.Bounds().Dy()
+		}
+		out = image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(out, out.Bounds(), image.NewUniform(color.Gray{Y: 0xcc}), image.Point{}, draw.Src)
+		y := 0
+		for _, pg := range r.pages {
+			draw.Draw(out, pg.Bounds().Add(image.Pt(0, y)), pg, image.Point{}, draw.Src)
+			y += pg.Bounds().Dy() + gap
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, out); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,199 @@
+// Package render turns a template layout and document data into output
+// files. Renderers are pure Go and deterministic: the same input always
+// produces the same bytes.
+package render
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	_ "image/jpeg" // decode JPEG assets
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/locale"
+	"github.com/lumiforge/docfactory-backend/internal/templates"
+)
+
+// Format enumerates output formats.
+type Format string
+
+const (
+	FormatDOCX Format = "docx"
+	FormatPDF  Format = "pdf"
+	FormatHTML Format = "html"
+	FormatPNG  Format = "png"
+	// FormatZPL is ZPL II for thermal printers; it requires label templates.
+	FormatZPL Format = "zpl"
+)
+
+// Page defaults shared by renderers.
+const (
+	fontSizePt = 11
+	// spacingPt separates consecutive elements.
+	spacingPt = 6
+	// pxPerMM converts image pixels to millimetres at 96 DPI.
+	pxPerMM = 96 / 25.4
+)
+
+// ErrUnsupportedFormat is returned for formats without renderer.
+var ErrUnsupportedFormat = errors.New("render: unsupported format")
+
+// Image is a raster asset embedded into documents.
+type Image struct {
+	ContentType string
+	Data        []byte
+}
+
+// Input is a template with the data of one document. Images holds content of
+// assets referenced by image elements, keyed by asset ID. Locale selects
+// formatting of numbers, dates and amounts; the template is expected to be
+// localized already. Verification is the URL encoded by verification
+// elements, which are left out when it is empty.
+type Input struct {
+	Template     templates.Template
+	Data         map[string]any
+	Images       map[string]Image
+	Locale       string
+	Verification string
+}
+
+var renderers = map[Format]struct {
+	contentType string
+	render      func(Input) ([]byte, error)
+}{
+	FormatDOCX: {"application/vnd.openxmlformats-officedocument.wordprocessingml.document", renderDOCX},
+	FormatPDF:  {"application/pdf", renderPDF},
+	FormatHTML: {"text/html; charset=utf-8", renderHTML},
+	FormatPNG:  {"image/png", renderPNG},
+	FormatZPL:  {"application/x-zpl", renderZPL},
+}
+
+// Render produces document in format.
+func Render(format Format, in Input) ([]byte, error) {
+	r, ok := renderers[format]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedFormat, format)
+	}
+	return r.render(in)
+}
+
+// Supported reports whether format has a renderer.
+func Supported(format Format) bool {
+	_, ok := renderers[format]
+	return ok
+}
+
+// Formats lists supported formats in alphabetical order.
+func Formats() []Format {
+	return slices.Sorted(maps.Keys(renderers))
+}
+
+// ContentType returns media type of files in format.
+func ContentType(format Format) string {
+	return renderers[format].contentType
+}
+
+// text resolves {{name}} placeholders of s against document data.
+func (in Input) text(s string) string {
+	return templates.ReplacePlaceholders(s, in.field)
+}
+
+// field formats value of data field name.
+func (in Input) field(name string) string {
+	f, _ := in.Template.Layout.Field(name)
+	return in.value(f, in.Data[name])
+}
+
+// cell formats value of table column in row.
+func (in Input) cell(el templates.Element, col templates.Column, row map[string]any) string {
+	var item templates.Field
+	if f, ok := in.Template.Layout.Field(el.Field); ok {
+		for _, it := range f.Items {
+			if it.Name == col.Field {
+				item = it
+			}
+		}
+	}
+	return in.value(item, row[col.Field])
+}
+
+// value formats v of field f for the locale of the document. Dates and
+// numbers are left as they are without a locale.
+func (in Input) value(f templates.Field, v any) string {
+	loc := locale.Lookup(in.Locale)
+	switch f.Type {
+	case templates.FieldDate:
+		if s, ok := v.(string); ok {
+			if t, err := time.Parse(templates.DateLayout, s); err == nil {
+				return loc.Date(t)
+			}
+		}
+	case templates.FieldNumber:
+		var n float64
+		switch v := v.(type) {
+		case float64:
+			n = v
+		case json.Number:
+			var err error
+			if n, err = v.Float64(); err != nil {
+				return v.String()
+			}
+		default:
+			return formatValue(v)
+		}
+		if f.Currency != "" {
+			return loc.Currency(n, f.Currency)
+		}
+		return loc.Number(n)
+	}
+	return formatValue(v)
+}
+
+// Formats that number pages themselves mark page numbers in header and
+// footer text by characters of the private use area.
+const (
+	pageMark  = '\uE000'
+	pagesMark = '\uE001'
+)
+
+// pageText resolves placeholders of header and footer text, where {{page}}
+// and {{pages}} stand for page numbers.
+func (in Input) pageText(s, page, pages string) string {
+	return templates.ReplacePlaceholders(s, func(name string) string {
+		switch name {
+		case templates.PlaceholderPage:
+			return page
+		case templates.PlaceholderPages:
+			return pages
+		}
+		return in.field(name)
+	})
+}
+
+// rows returns items of array field.
+func (in Input) rows(field string) []map[string]any {
+	items, _ := in.Data[field].([]any)
+	rows := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		if row, ok := item.(map[string]any); ok {
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	default:
+		return fmt.Sprint(v)
+	}
+}
@@ -0,0 +1,129 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/lumiforge/docfactory-backend/internal/barcode"
+	"github.com/lumiforge/docfactory-backend/internal/templates"
+)
+
+// zpl writes ZPL II commands for Zebra thermal printers. Each page of the
+// layout becomes one label; barcodes use the printer's own symbologies so
+// modules land on whole dots.
+type zpl struct {
+	dpi  float64
+	buf  bytes.Buffer
+	open bool
+}
+
+// renderZPL writes label templates as ZPL II.
+func renderZPL(in Input) ([]byte, error) {
+	label := in.Template.Label
+	if !label.Set() {
+		return nil, fmt.Errorf("%w: zpl requires label settings", ErrUnsupportedFormat)
+	}
+	z := &zpl{dpi: float64(label.DPI)}
+	if err := layout(in, z); err != nil {
+		return nil, err
+	}
+	z.end()
+	return z.buf.Bytes(), nil
+}
+
+func (z *zpl) dots(mm float64) int {
+	return int(math.Round(mm * z.dpi / 25.4))
+}
+
+func (z *zpl) page(width, height float64) {
+	z.end()
+	// ^CI28 selects UTF-8 field data.
+	fmt.Fprintf(&z.buf, "^XA\n^CI28\n^PW%d\n^LL%d\n^LH0,0\n", z.dots(width), z.dots(height))
+	z.open = true
+}
+
+func (z *zpl) end() {
+	if z.open {
+		z.buf.WriteString("^XZ\n")
+		z.open = false
+	}
+}
+
+// text uses the scalable font 0. It has a single weight, so bold and
+// oblique styles are not reproduced.
+func (z *zpl) text(x, y float64, s string, _ font, size float64) {
+	if s == "" {
+		return
+	}
+	top := y - size*ascent*ptMM
+	fmt.Fprintf(&z.buf, "^FO%d,%d^A0N,%d,0^FH^FD%s^FS\n", z.dots(x), z.dots(top), z.dots(size*ptMM), zplText(s))
+}
+
+func (z *zpl) fill(r rect) {
+	w, h := max(1, z.dots(r.w)), max(1, z.dots(r.h))
+	fmt.Fprintf(&z.buf, "^FO%d,%d^GB%d,%d,%d^FS\n", z.dots(r.x), z.dots(r.y), w, h, min(w, h))
+}
+
+func (z *zpl) stroke(r rect) {
+	fmt.Fprintf(&z.buf, "^FO%d,%d^GB%d,%d,%d^FS\n", z.dots(r.x), z.dots(r.y), max(1, z.dots(r.w)), max(1, z.dots(r.h)), max(1, z.dots(0.5*ptMM)))
+}
+
+// image thresholds img to black and white dots and sends it as graphic
+// field in ASCII hex.
+func (z *zpl) image(r rect, img image.Image, _ string) {
+	w, h := z.dots(r.w), z.dots(r.h)
+	if w <= 0 || h <= 0 {
+		return
+	}
+	src := img.Bounds()
+	stride := (w + 7) / 8
+	var data strings.Builder
+	row := make([]byte, stride)
+	for y := range h {
+		clear(row)
+		sy := src.Min.Y + y*src.Dy()/h
+		for x := range w {
+			c := color.NRGBAModel.Convert(img.At(src.Min.X+x*src.Dx()/w, sy)).(color.NRGBA)
+			luma := (299*int(c.R) + 587*int(c.G) + 114*int(c.B)) / 1000
+			// Transparent pixels print as paper.
+			if luma*int(c.A)/255+255-int(c.A) < 128 {
+				row[x/8] |= 0x80 >> (x % 8)
+			}
+		}
+		fmt.Fprintf(&data, "%X", row)
+	}
+	fmt.Fprintf(&z.buf, "^FO%d,%d^GFA,%d,%d,%d,%s^FS\n", z.dots(r.x), z.dots(r.y), stride*h, stride*h, stride, data.String())
+}
+
+// barcode sizes modules to whole dots that fit the box, leaving the quiet
+// zone around the symbol.
+func (z *zpl) barcode(r rect, code *barcode.Code, el templates.Element, content string) {
+	module := max(1, z.dots(r.w)/(code.Width()+2*code.QuietZone))
+	x := z.dots(r.x) + module*code.QuietZone
+	y := z.dots(r.y)
+	switch code.Symbology {
+	case barcode.QR:
+		level := el.ErrorCorrection
+		if level == "" {
+			level = barcode.DefaultLevel
+		}
+		fmt.Fprintf(&z.buf, "^FO%d,%d^BQN,2,%d^FH^FD%sA,%s^FS\n", x, y+module*code.QuietZone, min(module, 10), level, zplText(content))
+	case barcode.DataMatrix:
+		fmt.Fprintf(&z.buf, "^FO%d,%d^BXN,%d,200^FH^FD%s^FS\n", x, y+module*code.QuietZone, module, zplText(content))
+	case barcode.EAN13:
+		// The printer appends the check digit itself.
+		fmt.Fprintf(&z.buf, "^BY%d^FO%d,%d^BEN,%d,N,N^FD%s^FS\n", module, x, y, z.dots(r.h), content[:12])
+	case barcode.Code128:
+		fmt.Fprintf(&z.buf, "^BY%d^FO%d,%d^BCN,%d,N,N,N,A^FH^FD%s^FS\n", module, x, y, z.dots(r.h), zplText(content))
+	}
+}
+
+// zplText escapes field data for ^FH: the escape character and the command
+// prefixes become hexadecimal escapes.
+func zplText(s string) string {
+	return strings.NewReplacer("_", "_5F", "^", "_5E", "~", "_7E").Replace(s)
+}
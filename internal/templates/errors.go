@@ -0,0 +1,72 @@
+package templates
+
+// Kind classifies domain errors. Transport layers map kinds to their own
+// status codes.
+type Kind string
+
+const (
+	KindNotFound     Kind = "not_found"
+	KindConflict     Kind = "conflict"
+	KindInvalidInput Kind = "invalid_input"
+	KindGone         Kind = "gone"
+)
+
+// Error is a domain error with a stable machine-readable code. Errors form a
+// hierarchy: errors.Is matches an error against each of its ancestors, so
+// ErrTemplateNotFound is also ErrNotFound.
+type Error struct {
+	Code    string
+	Kind    Kind
+	Message string
+	parent  *Error
+}
+
+func (e *Error) Error() string {
+	return "templates: " + e.Message
+}
+
+// Is reports whether target is an ancestor of e.
+func (e *Error) Is(target error) bool {
+	for p := e.parent; p != nil; p = p.parent {
+		if p == target {
+			return true
+		}
+	}
+	return false
+}
+
+func newError(parent *Error, code, message string) *Error {
+	return &Error{Code: code, Kind: parent.Kind, Message: message, parent: parent}
+}
+
+var (
+	// ErrNotFound is returned when template or version does not exist.
+	ErrNotFound = &Error{Code: "not_found", Kind: KindNotFound, Message: "resource not found"}
+	// ErrConflict is returned when duplicated operations conflict.
+	ErrConflict = &Error{Code: "conflict", Kind: KindConflict, Message: "conflict detected"}
+	// ErrInvalidInput indicates validation error.
+	ErrInvalidInput = &Error{Code: "invalid_input", Kind: KindInvalidInput, Message: "invalid input"}
+	// ErrGone is returned for resources removed on purpose.
+	ErrGone = &Error{Code: "gone", Kind: KindGone, Message: "resource no longer exists"}
+
+	// ErrTemplateNotFound is returned when template does not exist in tenant.
+	ErrTemplateNotFound = newError(ErrNotFound, "template_not_found", "template not found")
+	// ErrVersionNotFound is returned when template has no such version.
+	ErrVersionNotFound = newError(ErrNotFound, "version_not_found", "template version not found")
+	// ErrLabelNotFound is returned when no version of template has the label.
+	ErrLabelNotFound = newError(ErrNotFound, "version_label_not_found", "template version label not found")
+	// ErrVersionPruned is returned for versions removed by retention policy
+	// or deleted explicitly.
+	ErrVersionPruned = newError(ErrGone, "version_pruned", "template version was pruned")
+	// ErrVersionPinned is returned when deleting pinned or labelled version.
+	ErrVersionPinned = newError(ErrConflict, "version_pinned", "template version is pinned or labelled")
+	// ErrVersionReferenced is returned when deleting version that generated
+	// documents refer to.
+	ErrVersionReferenced = newError(ErrConflict, "version_referenced", "template version is referenced by generated documents")
+	// ErrVersionCurrent is returned when deleting current version.
+	ErrVersionCurrent = newError(ErrConflict, "version_current", "current template version cannot be deleted")
+	// ErrTemplateDeleted is returned when modifying soft-deleted template.
+	ErrTemplateDeleted = newError(ErrConflict, "template_deleted", "template is deleted")
+	// ErrDuplicateID is returned when generated identifier already exists.
+	ErrDuplicateID = newError(ErrConflict, "duplicate_id", "identifier already exists")
+)
@@ -0,0 +1,92 @@
+package templates
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+// ResolveVersion finds version of template by reference, which is either a
+// version number or a label. Document generation and API consumers use it to
+// pin to a version instead of following the current one.
+func (s *TemplateService) ResolveVersion(ctx context.Context, tenantID, templateID, ref string) (*TemplateVersion, error) {
+	versions, err := s.repo.ListVersions(ctx, tenantID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	number, err := strconv.Atoi(ref)
+	if err != nil {
+		for i := range versions {
+			if slices.Contains(versions[i].Labels, ref) {
+				return &versions[i], nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %q", ErrLabelNotFound, ref)
+	}
+	for i := range versions {
+		if versions[i].VersionNumber == number {
+			return &versions[i], nil
+		}
+	}
+	return nil, s.missingVersion(ctx, tenantID, templateID, number)
+}
+
+// SetLabel attaches label to the version referenced by ref, moving it from
+// the version that had it before.
+func (s *TemplateService) SetLabel(ctx context.Context, tenantID, templateID, ref, label string) (*TemplateVersion, error) {
+	if err := ValidateLabel(label); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	version, err := s.ResolveVersion(ctx, tenantID, templateID, ref)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.SetLabel(ctx, tenantID, templateID, label, version.VersionNumber)
+}
+
+// RemoveLabel detaches label from the version referenced by ref.
+func (s *TemplateService) RemoveLabel(ctx context.Context, tenantID, templateID, ref, label string) error {
+	version, err := s.ResolveVersion(ctx, tenantID, templateID, ref)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(version.Labels, label) {
+		return fmt.Errorf("%w: %q", ErrLabelNotFound, label)
+	}
+	return s.repo.RemoveLabel(ctx, tenantID, templateID, label)
+}
+
+// PinVersion protects the version referenced by ref from deletion and
+// pruning, or lifts the protection when pinned is false.
+func (s *TemplateService) PinVersion(ctx context.Context, tenantID, templateID, ref string, pinned bool) (*TemplateVersion, error) {
+	version, err := s.ResolveVersion(ctx, tenantID, templateID, ref)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.SetPinned(ctx, tenantID, templateID, version.VersionNumber, pinned)
+}
+
+// DeleteVersion prunes the version referenced by ref. Current, pinned and
+// labelled versions cannot be deleted, nor versions that generated documents
+// refer to, so that those documents can still be regenerated and audited.
+func (s *TemplateService) DeleteVersion(ctx context.Context, tenantID, templateID, ref string) error {
+	version, err := s.ResolveVersion(ctx, tenantID, templateID, ref)
+	if err != nil {
+		return err
+	}
+	switch {
+	case version.IsCurrent:
+		return ErrVersionCurrent
+	case version.Protected():
+		return ErrVersionPinned
+	}
+	referenced, err := s.references.ReferencedVersions(ctx, tenantID, templateID)
+	if err != nil {
+		return err
+	}
+	if slices.Contains(referenced, version.VersionNumber) {
+		return ErrVersionReferenced
+	}
+	return s.repo.DeleteVersions(ctx, tenantID, templateID, []int{version.VersionNumber})
+}
@@ -0,0 +1,475 @@
+package templates
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/barcode"
+	"github.com/lumiforge/docfactory-backend/internal/locale"
+	"github.com/lumiforge/docfactory-backend/internal/validation"
+)
+
+// FieldType enumerates types of document data fields.
+type FieldType string
+
+const (
+	FieldString FieldType = "string"
+	FieldNumber FieldType = "number"
+	FieldDate   FieldType = "date"
+	FieldArray  FieldType = "array"
+)
+
+// ElementType enumerates layout elements.
+type ElementType string
+
+const (
+	ElementText    ElementType = "text"
+	ElementImage   ElementType = "image"
+	ElementTable   ElementType = "table"
+	ElementBarcode ElementType = "barcode"
+	// ElementPageBreak starts a new page; with orientation it also starts a
+	// section whose pages have that orientation.
+	ElementPageBreak ElementType = "page_break"
+	// ElementVerification is a QR code of the public URL verifying that the
+	// generated document is authentic and not revoked.
+	ElementVerification ElementType = "verification"
+)
+
+// Align enumerates horizontal alignment of elements.
+type Align string
+
+const (
+	AlignLeft   Align = "left"
+	AlignCenter Align = "center"
+	AlignRight  Align = "right"
+)
+
+// Layout is the printable content of a template: the data fields documents
+// are generated from and the elements rendered top to bottom on the page,
+// flowing onto further pages as needed.
+type Layout struct {
+	Fields   []Field   `json:"fields,omitempty"`
+	Elements []Element `json:"elements,omitempty"`
+	// Header and Footer repeat in the top and bottom margins of pages.
+	Header *Region `json:"header,omitempty"`
+	Footer *Region `json:"footer,omitempty"`
+	// VerificationFields name data fields, such as a serial number, that
+	// document verification discloses, so that verifiers can compare them
+	// with the printed document.
+	VerificationFields []string `json:"verification_fields,omitempty"`
+}
+
+// Region is a page header or footer. Its text elements may show page
+// numbers as {{page}} and {{pages}}, e.g. "Page {{page}} of {{pages}}".
+type Region struct {
+	Elements []Element `json:"elements"`
+	// SkipFirstPage leaves the first page, such as a cover, without region.
+	SkipFirstPage bool `json:"skip_first_page,omitempty"`
+}
+
+// Page number placeholders of header and footer text.
+const (
+	PlaceholderPage  = "page"
+	PlaceholderPages = "pages"
+)
+
+// Field declares a data field of generated documents. Array fields hold
+// rows whose columns are declared by Items. Number fields with Currency, an
+// ISO 4217 code, are amounts of money.
+type Field struct {
+	Name     string    `json:"name"`
+	Type     FieldType `json:"type"`
+	Required bool      `json:"required,omitempty"`
+	Currency string    `json:"currency,omitempty"`
+	Items    []Field   `json:"items,omitempty"`
+}
+
+// Element is a block of the layout. Text elements may reference data fields
+// as {{name}}; image elements show a tenant asset such as a logo; table
+// elements render one row per item of an array field; barcode elements
+// encode the value of a string or number field; verification elements
+// encode the verification URL of the document.
+type Element struct {
+	Type    ElementType `json:"type"`
+	Text    string      `json:"text,omitempty"`
+	AssetID string      `json:"asset_id,omitempty"`
+	Field   string      `json:"field,omitempty"`
+	Columns []Column    `json:"columns,omitempty"`
+	// Symbology and ErrorCorrection configure barcodes; error correction
+	// applies to QR codes only and defaults to M.
+	Symbology       barcode.Symbology `json:"symbology,omitempty"`
+	ErrorCorrection barcode.Level     `json:"error_correction,omitempty"`
+	// WidthMM and HeightMM size images and barcodes; zero keeps the aspect
+	// ratio of images and uses a default barcode size.
+	WidthMM  float64 `json:"width_mm,omitempty"`
+	HeightMM float64 `json:"height_mm,omitempty"`
+	// Orientation of page break switches orientation of following pages.
+	Orientation Orientation `json:"orientation,omitempty"`
+	Style       Style       `json:"style,omitzero"`
+}
+
+// Default barcode sizes in millimetres.
+const (
+	DefaultMatrixSizeMM   = 25
+	DefaultLinearWidthMM  = 40
+	DefaultLinearHeightMM = 15
+)
+
+// BarcodeSize returns size of barcode element in millimetres. Matrix codes
+// are square unless both dimensions are given.
+func (el Element) BarcodeSize() (width, height float64) {
+	width, height = el.WidthMM, el.HeightMM
+	if el.Symbology == barcode.QR || el.Symbology == barcode.DataMatrix || el.Type == ElementVerification {
+		switch {
+		case width == 0 && height == 0:
+			return DefaultMatrixSizeMM, DefaultMatrixSizeMM
+		case width == 0:
+			return height, height
+		case height == 0:
+			return width, width
+		}
+		return width, height
+	}
+	if width == 0 {
+		width = DefaultLinearWidthMM
+	}
+	if height == 0 {
+		height = DefaultLinearHeightMM
+	}
+	return width, height
+}
+
+// BarcodeContent returns text encoded by barcode element: the bound field
+// value, with numbers written without exponent or locale formatting.
+func (el Element) BarcodeContent(data map[string]any) string {
+	switch v := data[el.Field].(type) {
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case json.Number:
+		return v.String()
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Column is a table column showing an item field of the bound array.
+type Column struct {
+	Header string `json:"header"`
+	Field  string `json:"field"`
+}
+
+// Style controls text appearance. Zero values use renderer defaults.
+type Style struct {
+	FontSize float64 `json:"font_size,omitempty"`
+	Bold     bool    `json:"bold,omitempty"`
+	Italic   bool    `json:"italic,omitempty"`
+	Align    Align   `json:"align,omitempty"`
+}
+
+// DateLayout is the format of date field values.
+const DateLayout = "2006-01-02"
+
+var (
+	fieldNamePattern   = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,63}$`)
+	placeholderPattern = regexp.MustCompile(`{{\s*([A-Za-z_][A-Za-z0-9_]*)\s*}}`)
+)
+
+// Placeholders returns field names referenced as {{name}} in text.
+func Placeholders(text string) []string {
+	var names []string
+	for _, m := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// ReplacePlaceholders substitutes {{name}} references in text with value(name).
+func ReplacePlaceholders(text string, value func(name string) string) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(m string) string {
+		return value(placeholderPattern.FindStringSubmatch(m)[1])
+	})
+}
+
+// Field returns declared field by name.
+func (l Layout) Field(name string) (Field, bool) {
+	for _, f := range l.Fields {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return Field{}, false
+}
+
+// AssetIDs lists assets referenced by image elements.
+func (l Layout) AssetIDs() []string {
+	var ids []string
+	for _, el := range l.allElements() {
+		if el.Type == ElementImage {
+			ids = append(ids, el.AssetID)
+		}
+	}
+	return ids
+}
+
+// allElements returns elements of the body, header and footer.
+func (l Layout) allElements() []Element {
+	all := slices.Clone(l.Elements)
+	for _, r := range []*Region{l.Header, l.Footer} {
+		if r != nil {
+			all = append(all, r.Elements...)
+		}
+	}
+	return all
+}
+
+// Equal reports whether layouts have the same content; nil and empty lists
+// are equal.
+func (l Layout) Equal(other Layout) bool {
+	a, errA := json.Marshal(l)
+	b, errB := json.Marshal(other)
+	return errA == nil && errB == nil && string(a) == string(b)
+}
+
+// Validate checks field declarations and that elements and verification
+// fields reference declared fields. Field names of errors are prefixed with
+// prefix.
+func (l Layout) Validate(prefix string) error {
+	var errs validation.Errors
+	validateFields(&errs, prefix+"fields", l.Fields, true)
+	for i, el := range l.Elements {
+		l.validateElement(&errs, fmt.Sprintf("%selements[%d]", prefix, i), el, false)
+	}
+	for _, r := range []struct {
+		name   string
+		region *Region
+	}{{"header", l.Header}, {"footer", l.Footer}} {
+		if r.region == nil {
+			continue
+		}
+		for i, el := range r.region.Elements {
+			l.validateElement(&errs, fmt.Sprintf("%s%s.elements[%d]", prefix, r.name, i), el, true)
+		}
+	}
+	for i, name := range l.VerificationFields {
+		path := fmt.Sprintf("%sverification_fields[%d]", prefix, i)
+		if f, ok := l.Field(name); !ok || f.Type == FieldArray {
+			errs.Add(path, validation.CodeNotFound, "must reference a string, number or date field")
+		} else if slices.Index(l.VerificationFields, name) != i {
+			errs.Add(path, validation.CodeInvalidValue, "is duplicated")
+		}
+	}
+	return errs.Err()
+}
+
+// validateElement checks element at path. Header and footer elements are
+// limited to text, images, barcodes and verification codes and may
+// reference page numbers.
+func (l Layout) validateElement(errs *validation.Errors, path string, el Element, region bool) {
+	switch el.Type {
+	case ElementText:
+		errs.Required(path+".text", el.Text)
+		for _, name := range Placeholders(el.Text) {
+			if region && (name == PlaceholderPage || name == PlaceholderPages) {
+				continue
+			}
+			if f, ok := l.Field(name); !ok || f.Type == FieldArray {
+				errs.Add(path+".text", validation.CodeNotFound, fmt.Sprintf("references unknown field %q", name))
+			}
+		}
+	case ElementImage:
+		errs.Required(path+".asset_id", el.AssetID)
+	case ElementBarcode:
+		if !slices.Contains(barcode.Symbologies(), el.Symbology) {
+			errs.Add(path+".symbology", validation.CodeInvalidValue, "is invalid")
+		}
+		if f, ok := l.Field(el.Field); !ok || f.Type != FieldString && f.Type != FieldNumber {
+			errs.Add(path+".field", validation.CodeNotFound, "must reference a string or number field")
+		}
+		switch {
+		case el.ErrorCorrection == "":
+		case el.Symbology != barcode.QR:
+			errs.Add(path+".error_correction", validation.CodeInvalidValue, "is only supported by QR codes")
+		case !slices.Contains(barcode.Levels(), el.ErrorCorrection):
+			errs.Add(path+".error_correction", validation.CodeInvalidValue, "must be L, M, Q or H")
+		}
+	case ElementVerification:
+		if el.Symbology != "" {
+			errs.Add(path+".symbology", validation.CodeInvalidValue, "is not supported; verification elements are QR codes")
+		}
+		if el.ErrorCorrection != "" && !slices.Contains(barcode.Levels(), el.ErrorCorrection) {
+			errs.Add(path+".error_correction", validation.CodeInvalidValue, "must be L, M, Q or H")
+		}
+	case ElementTable, ElementPageBreak:
+		if region {
+			errs.Add(path+".type", validation.CodeInvalidValue, "is not allowed in header or footer")
+			return
+		}
+		if el.Type == ElementPageBreak {
+			break
+		}
+		f, ok := l.Field(el.Field)
+		if !ok || f.Type != FieldArray {
+			errs.Add(path+".field", validation.CodeNotFound, "must reference an array field")
+		}
+		if len(el.Columns) == 0 {
+			errs.Add(path+".columns", validation.CodeRequired, "must contain at least one column")
+		}
+		for j, col := range el.Columns {
+			if !hasField(f.Items, col.Field) {
+				errs.Add(fmt.Sprintf("%s.columns[%d].field", path, j), validation.CodeNotFound, "must reference an item field")
+			}
+		}
+	default:
+		errs.Add(path+".type", validation.CodeInvalidValue, "is invalid")
+	}
+	switch {
+	case el.Orientation == "":
+	case el.Type != ElementPageBreak:
+		errs.Add(path+".orientation", validation.CodeInvalidValue, "is only supported by page breaks")
+	case el.Orientation != OrientationPortrait && el.Orientation != OrientationLandscape:
+		errs.Add(path+".orientation", validation.CodeInvalidValue, "is invalid")
+	}
+	if el.WidthMM < 0 || el.HeightMM < 0 {
+		errs.Add(path, validation.CodeInvalidValue, "sizes must not be negative")
+	}
+	if s := el.Style.FontSize; s != 0 && (s < 4 || s > 96) {
+		errs.Add(path+".style.font_size", validation.CodeInvalidValue, "must be between 4 and 96")
+	}
+	switch el.Style.Align {
+	case "", AlignLeft, AlignCenter, AlignRight:
+	default:
+		errs.Add(path+".style.align", validation.CodeInvalidValue, "is invalid")
+	}
+}
+
+func validateFields(errs *validation.Errors, path string, fields []Field, allowArrays bool) {
+	seen := map[string]bool{}
+	for i, f := range fields {
+		p := fmt.Sprintf("%s[%d]", path, i)
+		switch {
+		case !fieldNamePattern.MatchString(f.Name):
+			errs.Add(p+".name", validation.CodeInvalidValue, "must be an identifier")
+		case seen[f.Name]:
+			errs.Add(p+".name", validation.CodeInvalidValue, "is declared twice")
+		}
+		seen[f.Name] = true
+		switch {
+		case f.Currency == "":
+		case f.Type != FieldNumber:
+			errs.Add(p+".currency", validation.CodeInvalidValue, "is only allowed for numbers")
+		case !locale.ValidCurrency(f.Currency):
+			errs.Add(p+".currency", validation.CodeInvalidValue, "must be an ISO 4217 code such as EUR")
+		}
+		switch f.Type {
+		case FieldString, FieldNumber, FieldDate:
+			if len(f.Items) > 0 {
+				errs.Add(p+".items", validation.CodeInvalidValue, "is only allowed for arrays")
+			}
+		case FieldArray:
+			if !allowArrays {
+				errs.Add(p+".type", validation.CodeInvalidValue, "arrays cannot be nested")
+				continue
+			}
+			if len(f.Items) == 0 {
+				errs.Add(p+".items", validation.CodeRequired, "must declare at least one item field")
+			}
+			validateFields(errs, p+".items", f.Items, false)
+		default:
+			errs.Add(p+".type", validation.CodeInvalidValue, "is invalid")
+		}
+	}
+}
+
+func hasField(fields []Field, name string) bool {
+	for _, f := range fields {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateData checks document data against declared fields. Every declared
+// field is checked and undeclared keys are rejected; errors name fields as
+// data.<name> or data.<array>[i].<name>. Values bound to barcodes must be
+// encodable, so that EAN-13 codes need a valid check digit.
+func (l Layout) ValidateData(data map[string]any) error {
+	var errs validation.Errors
+	validateValues(&errs, "data", l.Fields, data)
+	if len(errs) == 0 {
+		for _, el := range l.allElements() {
+			if el.Type != ElementBarcode {
+				continue
+			}
+			content := el.BarcodeContent(data)
+			if content == "" {
+				continue
+			}
+			if err := barcode.Validate(el.Symbology, content, el.ErrorCorrection); err != nil {
+				errs.Add("data."+el.Field, validation.CodeInvalidValue, strings.TrimPrefix(err.Error(), barcode.ErrUnencodable.Error()+": "))
+			}
+		}
+	}
+	return errs.Err()
+}
+
+func validateValues(errs *validation.Errors, path string, fields []Field, data map[string]any) {
+	for _, f := range fields {
+		p := path + "." + f.Name
+		value, ok := data[f.Name]
+		if !ok || value == nil {
+			if f.Required {
+				errs.Add(p, validation.CodeRequired, "is required")
+			}
+			continue
+		}
+		switch f.Type {
+		case FieldString:
+			if _, ok := value.(string); !ok {
+				errs.Add(p, validation.CodeInvalidType, "must be a string")
+			}
+		case FieldNumber:
+			switch value.(type) {
+			case float64, json.Number, int, int64:
+			default:
+				errs.Add(p, validation.CodeInvalidType, "must be a number")
+			}
+		case FieldDate:
+			s, ok := value.(string)
+			if !ok {
+				errs.Add(p, validation.CodeInvalidType, "must be a string")
+			} else if _, err := time.Parse(DateLayout, s); err != nil {
+				errs.Add(p, validation.CodeInvalidValue, "must be a date formatted as YYYY-MM-DD")
+			}
+		case FieldArray:
+			items, ok := value.([]any)
+			if !ok {
+				errs.Add(p, validation.CodeInvalidType, "must be an array")
+				continue
+			}
+			for i, item := range items {
+				row, ok := item.(map[string]any)
+				if !ok {
+					errs.Add(fmt.Sprintf("%s[%d]", p, i), validation.CodeInvalidType, "must be an object")
+					continue
+				}
+				validateValues(errs, fmt.Sprintf("%s[%d]", p, i), f.Items, row)
+			}
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(data)) {
+		if !hasField(fields, key) {
+			errs.Add(path+"."+key, validation.CodeUnknownField, "is not declared by the template")
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package templates
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/lumiforge/docfactory-backend/internal/locale"
+	"github.com/lumiforge/docfactory-backend/internal/validation"
+)
+
+// Localization holds translations of the static text of a layout: text
+// elements and table column headers. The layout is written in Locale and
+// Translations maps other locales to translations keyed by source text, so
+// reordering elements keeps translations. Fallbacks name locales consulted
+// when a locale lacks a translation, e.g. {"kk": ["ru"]}.
+type Localization struct {
+	Locale       string                       `json:"locale,omitempty"`
+	Fallbacks    map[string][]string          `json:"fallbacks,omitempty"`
+	Translations map[string]map[string]string `json:"translations,omitempty"`
+}
+
+// Equal reports whether localizations have the same content.
+func (l Localization) Equal(other Localization) bool {
+	return l.Locale == other.Locale &&
+		maps.EqualFunc(l.Fallbacks, other.Fallbacks, slices.Equal) &&
+		maps.EqualFunc(l.Translations, other.Translations, maps.Equal)
+}
+
+// chain lists locales whose translations are used for tag. It ends before
+// the source locale, whose text is the layout itself.
+func (l Localization) chain(tag string) []string {
+	chain := locale.Chain(tag, l.Fallbacks)
+	if i := slices.Index(chain, l.Locale); i >= 0 {
+		chain = chain[:i]
+	}
+	return chain
+}
+
+// Strings lists translatable text of the layout in order of appearance,
+// each once. Text consisting of placeholders only is not translatable.
+func (l Layout) Strings() []string {
+	var texts []string
+	for _, el := range l.allElements() {
+		switch el.Type {
+		case ElementText:
+			texts = append(texts, el.Text)
+		case ElementTable:
+			for _, col := range el.Columns {
+				texts = append(texts, col.Header)
+			}
+		}
+	}
+	var strs []string
+	for _, s := range texts {
+		if translatable(s) && !slices.Contains(strs, s) {
+			strs = append(strs, s)
+		}
+	}
+	return strs
+}
+
+func translatable(s string) bool {
+	return strings.ContainsFunc(ReplacePlaceholders(s, func(string) string { return "" }), unicode.IsLetter)
+}
+
+// Localized returns t with static text translated for locale tag. Text
+// without translation in tag or its fallbacks keeps the source text.
+func (t Template) Localized(tag string) Template {
+	chain := t.Localization.chain(tag)
+	if len(chain) == 0 {
+		return t
+	}
+	translate := func(s string) string {
+		for _, loc := range chain {
+			if tr := t.Localization.Translations[loc][s]; tr != "" {
+				return tr
+			}
+		}
+		return s
+	}
+	elements := func(els []Element) []Element {
+		out := make([]Element, len(els))
+		for i, el := range els {
+			el.Text = translate(el.Text)
+			if len(el.Columns) > 0 {
+				el.Columns = slices.Clone(el.Columns)
+				for j := range el.Columns {
+					el.Columns[j].Header = translate(el.Columns[j].Header)
+				}
+			}
+			out[i] = el
+		}
+		return out
+	}
+	t.Layout.Elements = elements(t.Layout.Elements)
+	for _, r := range []**Region{&t.Layout.Header, &t.Layout.Footer} {
+		if *r != nil {
+			region := **r
+			region.Elements = elements(region.Elements)
+			*r = &region
+		}
+	}
+	return t
+}
+
+// TranslationReport lists static text of a template without translation.
+type TranslationReport struct {
+	TemplateID string `json:"template_id"`
+	Version    int    `json:"version"`
+	// Locale is the locale of the layout and Strings its translatable text.
+	Locale  string              `json:"locale"`
+	Strings []string            `json:"strings"`
+	Locales []LocaleTranslation `json:"locales"`
+}
+
+// LocaleTranslation reports translation progress of one locale. Missing
+// strings have no translation of their own, though fallbacks may cover
+// them; unused translations belong to text no longer in the layout.
+type LocaleTranslation struct {
+	Locale     string   `json:"locale"`
+	Translated int      `json:"translated"`
+	Missing    []string `json:"missing"`
+	Unused     []string `json:"unused"`
+}
+
+// TranslationReport reports translations of locales, by default of every
+// locale the template has translations for.
+func (t Template) TranslationReport(locales ...string) TranslationReport {
+	strs := t.Layout.Strings()
+	report := TranslationReport{
+		TemplateID: t.TemplateID,
+		Version:    t.Version,
+		Locale:     t.Localization.Locale,
+		Strings:    strs,
+		Locales:    []LocaleTranslation{},
+	}
+	if len(locales) == 0 {
+		locales = slices.Sorted(maps.Keys(t.Localization.Translations))
+	}
+	for _, loc := range locales {
+		translations := t.Localization.Translations[loc]
+		lt := LocaleTranslation{Locale: loc, Missing: []string{}, Unused: []string{}}
+		for _, s := range strs {
+			if translations[s] != "" {
+				lt.Translated++
+			} else {
+				lt.Missing = append(lt.Missing, s)
+			}
+		}
+		for _, src := range slices.Sorted(maps.Keys(translations)) {
+			if !slices.Contains(strs, src) {
+				lt.Unused = append(lt.Unused, src)
+			}
+		}
+		report.Locales = append(report.Locales, lt)
+	}
+	return report
+}
+
+// TranslationReport reports untranslated text of the current template
+// version in tag, or in every locale it has translations for when tag is
+// empty.
+func (s *TemplateService) TranslationReport(ctx context.Context, tenantID, templateID, tag string) (*TranslationReport, error) {
+	var errs validation.Errors
+	validateTag(&errs, "locale", tag)
+	if err := errs.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	tpl, err := s.repo.GetTemplate(ctx, tenantID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	var locales []string
+	if tag != "" {
+		locales = append(locales, tag)
+	}
+	report := tpl.TranslationReport(locales...)
+	return &report, nil
+}
+
+// validate checks locale tags and that translations reference only fields
+// their source text references.
+func (l Localization) validate(errs *validation.Errors) {
+	validateTag(errs, "localization.locale", l.Locale)
+	if l.Locale == "" && (len(l.Translations) > 0 || len(l.Fallbacks) > 0) {
+		errs.Add("localization.locale", validation.CodeRequired, "is required with translations")
+	}
+	for _, loc := range slices.Sorted(maps.Keys(l.Translations)) {
+		path := "localization.translations." + loc
+		if validateTag(errs, path, loc) && loc == l.Locale {
+			errs.Add(path, validation.CodeInvalidValue, "must differ from the template locale")
+		}
+		translations := l.Translations[loc]
+		for _, src := range slices.Sorted(maps.Keys(translations)) {
+			p := fmt.Sprintf("%s[%q]", path, src)
+			tr := translations[src]
+			if strings.TrimSpace(tr) == "" {
+				errs.Add(p, validation.CodeRequired, "must not be empty")
+				continue
+			}
+			for _, name := range Placeholders(tr) {
+				if !slices.Contains(Placeholders(src), name) {
+					errs.Add(p, validation.CodeInvalidValue, fmt.Sprintf("references {{%s}} which the source text does not", name))
+				}
+			}
+		}
+	}
+	for _, loc := range slices.Sorted(maps.Keys(l.Fallbacks)) {
+		path := "localization.fallbacks." + loc
+		validateTag(errs, path, loc)
+		for i, f := range l.Fallbacks[loc] {
+			p := fmt.Sprintf("%s[%d]", path, i)
+			if validateTag(errs, p, f) && f == loc {
+				errs.Add(p, validation.CodeInvalidValue, "must not fall back to itself")
+			}
+		}
+	}
+}
+
+// validateTag checks that tag is empty or a canonical language tag.
+func validateTag(errs *validation.Errors, path, tag string) bool {
+	if tag == "" {
+		return true
+	}
+	canonical, err := locale.Canonical(tag)
+	switch {
+	case err != nil:
+		errs.Add(path, validation.CodeInvalidValue, "must be a language tag such as kk-KZ")
+	case canonical != tag:
+		errs.Add(path, validation.CodeInvalidValue, fmt.Sprintf("must be written as %q", canonical))
+	default:
+		return true
+	}
+	return false
+}
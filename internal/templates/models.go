@@ -1,9 +1,12 @@
 package templates
 
 import (
-	"errors"
+	"regexp"
 	"strings"
 	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/ids"
+	"github.com/lumiforge/docfactory-backend/internal/validation"
 )
 
 // DocumentType enumerates supported document types.
@@ -16,15 +19,6 @@ const (
 	DocumentTypeLabel       DocumentType = "label"
 )
 
-// PageSize enumerates supported document sizes.
-type PageSize string
-
-const (
-	PageSizeA4     PageSize = "A4"
-	PageSizeA5     PageSize = "A5"
-	PageSizeLetter PageSize = "Letter"
-)
-
 // Orientation enumerates document orientation values.
 type Orientation string
 
@@ -44,6 +38,11 @@ type Template struct {
 	Orientation    Orientation  `json:"orientation"`
 	JSONSchemaURL  string       `json:"json_schema_url"`
 	ThumbnailURL   string       `json:"thumbnail_url"`
+	Page           PageSetup    `json:"page,omitzero"`
+	Label          LabelSpec    `json:"label,omitzero"`
+	Signing        SigningSpec  `json:"signing,omitzero"`
+	Layout         Layout       `json:"layout"`
+	Localization   Localization `json:"localization,omitzero"`
 	Version        int          `json:"version"`
 	CreatedBy      string       `json:"created_by"`
 	UpdatedBy      string       `json:"updated_by"`
@@ -54,78 +53,196 @@ type Template struct {
 	LastUsedAt     *time.Time   `json:"last_used_at"`
 }
 
-// TemplateVersion represents the template_versions table structure.
+// TemplateVersion represents the template_versions table structure. Besides
+// version metadata it snapshots every content-bearing template field, so that
+// restoring a version brings the template back to the state it had.
 type TemplateVersion struct {
-	VersionID     string    `json:"version_id"`
-	TemplateID    string    `json:"template_id"`
-	VersionNumber int       `json:"version_number"`
-	ChangeSummary string    `json:"change_summary"`
-	JSONSchemaURL string    `json:"json_schema_url"`
-	CreatedBy     string    `json:"created_by"`
-	CreatedAt     time.Time `json:"created_at"`
-	IsCurrent     bool      `json:"is_current"`
+	VersionID     string       `json:"version_id"`
+	TemplateID    string       `json:"template_id"`
+	VersionNumber int          `json:"version_number"`
+	ChangeSummary string       `json:"change_summary"`
+	Name          string       `json:"name"`
+	Description   string       `json:"description"`
+	DocumentType  DocumentType `json:"document_type"`
+	PageSize      PageSize     `json:"page_size"`
+	Orientation   Orientation  `json:"orientation"`
+	JSONSchemaURL string       `json:"json_schema_url"`
+	ThumbnailURL  string       `json:"thumbnail_url"`
+	Page          PageSetup    `json:"page,omitzero"`
+	Label         LabelSpec    `json:"label,omitzero"`
+	Signing       SigningSpec  `json:"signing,omitzero"`
+	Layout        Layout       `json:"layout"`
+	Localization  Localization `json:"localization,omitzero"`
+	CreatedBy     string       `json:"created_by"`
+	CreatedAt     time.Time    `json:"created_at"`
+	IsCurrent     bool         `json:"is_current"`
+	PublishedAt   *time.Time   `json:"published_at"`
+	// Labels name the version for consumers, e.g. "stable" or "2026-q1". A
+	// label belongs to at most one version of a template.
+	Labels []string `json:"labels"`
+	// Pinned versions are relied upon by consumers and cannot be deleted or
+	// pruned.
+	Pinned bool `json:"pinned"`
 }
 
-var (
-	// ErrNotFound is returned when template or version does not exist.
-	ErrNotFound = errors.New("templates: resource not found")
-	// ErrConflict is returned when duplicated operations conflict.
-	ErrConflict = errors.New("templates: conflict detected")
-	// ErrInvalidInput indicates validation error.
-	ErrInvalidInput = errors.New("templates: invalid input")
-)
+// Protected reports whether version must survive deletion and pruning
+// because consumers refer to it by label or pin.
+func (tv TemplateVersion) Protected() bool {
+	return tv.Pinned || len(tv.Labels) > 0
+}
 
-// Validate ensures template structure is valid according to business rules.
-func (t Template) Validate() error {
-	if t.TenantID == "" {
-		return errors.New("tenant_id is required")
+// ValidateLabel checks version label syntax. Labels are lower-case, start
+// with a letter or digit and cannot be mistaken for version numbers or the
+// compare endpoint.
+func ValidateLabel(label string) error {
+	var errs validation.Errors
+	switch {
+	case !labelPattern.MatchString(label):
+		errs.Add("label", validation.CodeInvalidValue, "must be 1-63 lower-case letters, digits, '.', '_' or '-', starting with a letter or digit")
+	case strings.Trim(label, "0123456789") == "":
+		errs.Add("label", validation.CodeInvalidValue, "must not be a version number")
+	case label == "compare":
+		errs.Add("label", validation.CodeInvalidValue, "is reserved")
 	}
-	if len(strings.TrimSpace(t.Name)) < 3 || len(t.Name) > 100 {
-		return errors.New("name must be between 3 and 100 characters")
+	return errs.Err()
+}
+
+var labelPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{0,62}$`)
+
+// newVersion snapshots tpl as its current version.
+func newVersion(tpl Template, changeSummary, createdBy string, createdAt time.Time) TemplateVersion {
+	return TemplateVersion{
+		VersionID:     ids.New(),
+		TemplateID:    tpl.TemplateID,
+		VersionNumber: tpl.Version,
+		ChangeSummary: changeSummary,
+		Name:          tpl.Name,
+		Description:   tpl.Description,
+		DocumentType:  tpl.DocumentType,
+		PageSize:      tpl.PageSize,
+		Orientation:   tpl.Orientation,
+		JSONSchemaURL: tpl.JSONSchemaURL,
+		ThumbnailURL:  tpl.ThumbnailURL,
+		Page:          tpl.Page,
+		Label:         tpl.Label,
+		Signing:       tpl.Signing,
+		Layout:        tpl.Layout,
+		Localization:  tpl.Localization,
+		CreatedBy:     createdBy,
+		CreatedAt:     createdAt,
+		IsCurrent:     true,
+		Labels:        []string{},
+	}
+}
+
+// applyTo copies the snapshot of tv onto tpl.
+func (tv TemplateVersion) applyTo(tpl *Template) {
+	tpl.Name = tv.Name
+	tpl.Description = tv.Description
+	tpl.DocumentType = tv.DocumentType
+	tpl.PageSize = tv.PageSize
+	tpl.Orientation = tv.Orientation
+	tpl.JSONSchemaURL = tv.JSONSchemaURL
+	tpl.ThumbnailURL = tv.ThumbnailURL
+	tpl.Page = tv.Page
+	tpl.Label = tv.Label
+	tpl.Signing = tv.Signing
+	tpl.Layout = tv.Layout
+	tpl.Localization = tv.Localization
+}
+
+// AtVersion returns t with content of version snapshot tv, as documents
+// pinned to that version see it.
+func (t Template) AtVersion(tv TemplateVersion) Template {
+	tv.applyTo(&t)
+	t.Version = tv.VersionNumber
+	return t
+}
+
+// MetadataChange records an edit of a template field that does not affect
+// rendering, such as a rename. Such edits create no TemplateVersion.
+type MetadataChange struct {
+	ChangeID   string    `json:"change_id"`
+	TemplateID string    `json:"template_id"`
+	Field      string    `json:"field"`
+	OldValue   string    `json:"old_value"`
+	NewValue   string    `json:"new_value"`
+	ChangedBy  string    `json:"changed_by"`
+	ChangedAt  time.Time `json:"changed_at"`
+}
+
+// RetentionPolicy selects versions kept when a tenant's version history is
+// pruned. The current version is always kept.
+type RetentionPolicy struct {
+	// KeepLast keeps the newest versions of each template; 0 disables pruning.
+	KeepLast int `json:"keep_last"`
+	// KeepPublished keeps every published version.
+	KeepPublished bool `json:"keep_published"`
+	// KeepReferenced keeps versions that generated documents refer to.
+	KeepReferenced bool `json:"keep_referenced"`
+}
+
+// DefaultRetentionPolicy keeps every version.
+func DefaultRetentionPolicy() RetentionPolicy {
+	return RetentionPolicy{KeepPublished: true, KeepReferenced: true}
+}
+
+// Validate ensures retention policy values are usable.
+func (p RetentionPolicy) Validate() error {
+	var errs validation.Errors
+	if p.KeepLast < 0 {
+		errs.Add("keep_last", validation.CodeInvalidValue, "must not be negative")
 	}
+	return errs.Err()
+}
+
+// PruneResult summarises a pruning run for a tenant. SchemasDeleted is empty
+// unless the service has a schema store.
+type PruneResult struct {
+	TenantID       string   `json:"tenant_id"`
+	VersionsPruned int      `json:"versions_pruned"`
+	SchemasDeleted []string `json:"schemas_deleted"`
+}
+
+// Validate ensures template structure is valid according to business rules.
+// All failing fields are reported as validation.Errors.
+func (t Template) Validate() error {
+	var errs validation.Errors
+	errs.Required("tenant_id", t.TenantID)
+	errs.Length("name", t.Name, 3, 100)
 	if len(t.Description) > 500 {
-		return errors.New("description must be <= 500 characters")
+		errs.Add("description", validation.CodeLength, "must be at most 500 characters")
 	}
 	switch t.DocumentType {
 	case DocumentTypeWarranty, DocumentTypeInstruction, DocumentTypeCertificate, DocumentTypeLabel:
 	default:
-		return errors.New("document_type is invalid")
-	}
-	switch t.PageSize {
-	case PageSizeA4, PageSizeA5, PageSizeLetter:
-	default:
-		return errors.New("page_size is invalid")
+		errs.Add("document_type", validation.CodeInvalidValue, "is invalid")
 	}
+	t.validatePage(&errs)
+	t.validateSigning(&errs)
 	switch t.Orientation {
 	case OrientationPortrait, OrientationLandscape:
 	default:
-		return errors.New("orientation is invalid")
-	}
-	if t.JSONSchemaURL == "" {
-		return errors.New("json_schema_url is required")
+		errs.Add("orientation", validation.CodeInvalidValue, "is invalid")
 	}
-	if t.CreatedBy == "" {
-		return errors.New("created_by is required")
+	errs.Required("json_schema_url", t.JSONSchemaURL)
+	errs.Required("created_by", t.CreatedBy)
+	errs.Required("updated_by", t.UpdatedBy)
+	if err := t.Layout.Validate("layout."); err != nil {
+		errs = append(errs, err.(validation.Errors)...)
 	}
-	if t.UpdatedBy == "" {
-		return errors.New("updated_by is required")
-	}
-	return nil
+	t.Localization.validate(&errs)
+	return errs.Err()
 }
 
 // Validate ensures template version business rules.
 func (tv TemplateVersion) Validate() error {
-	if tv.TemplateID == "" {
-		return errors.New("template_id is required")
-	}
+	var errs validation.Errors
+	errs.Required("template_id", tv.TemplateID)
 	if tv.VersionNumber <= 0 {
-		return errors.New("version_number must be positive")
-	}
-	if tv.JSONSchemaURL == "" {
-		return errors.New("json_schema_url is required")
-	}
-	if tv.CreatedBy == "" {
-		return errors.New("created_by is required")
+		errs.Add("version_number", validation.CodeInvalidValue, "must be positive")
 	}
-	return nil
+	errs.Required("json_schema_url", tv.JSONSchemaURL)
+	errs.Required("created_by", tv.CreatedBy)
+	return errs.Err()
 }
@@ -0,0 +1,268 @@
+package templates
+
+import (
+	"slices"
+
+	"github.com/lumiforge/docfactory-backend/internal/validation"
+)
+
+// PageSize enumerates supported document sizes. PageSizeCustom takes its
+// dimensions from the page setup of the template.
+type PageSize string
+
+const (
+	PageSizeA0     PageSize = "A0"
+	PageSizeA1     PageSize = "A1"
+	PageSizeA2     PageSize = "A2"
+	PageSizeA3     PageSize = "A3"
+	PageSizeA4     PageSize = "A4"
+	PageSizeA5     PageSize = "A5"
+	PageSizeA6     PageSize = "A6"
+	PageSizeA7     PageSize = "A7"
+	PageSizeA8     PageSize = "A8"
+	PageSizeA9     PageSize = "A9"
+	PageSizeA10    PageSize = "A10"
+	PageSizeB0     PageSize = "B0"
+	PageSizeB1     PageSize = "B1"
+	PageSizeB2     PageSize = "B2"
+	PageSizeB3     PageSize = "B3"
+	PageSizeB4     PageSize = "B4"
+	PageSizeB5     PageSize = "B5"
+	PageSizeB6     PageSize = "B6"
+	PageSizeB7     PageSize = "B7"
+	PageSizeB8     PageSize = "B8"
+	PageSizeB9     PageSize = "B9"
+	PageSizeB10    PageSize = "B10"
+	PageSizeLetter PageSize = "Letter"
+	PageSizeLegal  PageSize = "Legal"
+	PageSizeCustom PageSize = "custom"
+)
+
+// pageSizesMM holds portrait width and height of standard page sizes in
+// millimetres, as defined by ISO 216 and ANSI/ASME Y14.1.
+var pageSizesMM = map[PageSize][2]float64{
+	PageSizeA0:     {841, 1189},
+	PageSizeA1:     {594, 841},
+	PageSizeA2:     {420, 594},
+	PageSizeA3:     {297, 420},
+	PageSizeA4:     {210, 297},
+	PageSizeA5:     {148, 210},
+	PageSizeA6:     {105, 148},
+	PageSizeA7:     {74, 105},
+	PageSizeA8:     {52, 74},
+	PageSizeA9:     {37, 52},
+	PageSizeA10:    {26, 37},
+	PageSizeB0:     {1000, 1414},
+	PageSizeB1:     {707, 1000},
+	PageSizeB2:     {500, 707},
+	PageSizeB3:     {353, 500},
+	PageSizeB4:     {250, 353},
+	PageSizeB5:     {176, 250},
+	PageSizeB6:     {125, 176},
+	PageSizeB7:     {88, 125},
+	PageSizeB8:     {62, 88},
+	PageSizeB9:     {44, 62},
+	PageSizeB10:    {31, 44},
+	PageSizeLetter: {215.9, 279.4},
+	PageSizeLegal:  {215.9, 355.6},
+}
+
+// PageSizes lists page sizes, standard sizes first.
+func PageSizes() []PageSize {
+	return []PageSize{
+		PageSizeA0, PageSizeA1, PageSizeA2, PageSizeA3, PageSizeA4, PageSizeA5,
+		PageSizeA6, PageSizeA7, PageSizeA8, PageSizeA9, PageSizeA10,
+		PageSizeB0, PageSizeB1, PageSizeB2, PageSizeB3, PageSizeB4, PageSizeB5,
+		PageSizeB6, PageSizeB7, PageSizeB8, PageSizeB9, PageSizeB10,
+		PageSizeLetter, PageSizeLegal, PageSizeCustom,
+	}
+}
+
+// Unit enumerates length units of page setup.
+type Unit string
+
+const (
+	UnitMM Unit = "mm"
+	UnitIn Unit = "in"
+	UnitPt Unit = "pt"
+)
+
+// Units lists length units.
+func Units() []Unit {
+	return []Unit{UnitMM, UnitIn, UnitPt}
+}
+
+// mm converts v in unit u to millimetres; empty unit is millimetres.
+func (u Unit) mm(v float64) float64 {
+	switch u {
+	case UnitIn:
+		return v * 25.4
+	case UnitPt:
+		return v * 25.4 / 72
+	}
+	return v
+}
+
+// PageSetup refines page geometry. Lengths are in Unit, millimetres by
+// default. Width and Height apply to custom page size only and are not
+// affected by orientation.
+type PageSetup struct {
+	Unit   Unit    `json:"unit,omitempty"`
+	Width  float64 `json:"width,omitempty"`
+	Height float64 `json:"height,omitempty"`
+	// Margins replace DefaultMarginMM on every side when set.
+	Margins *Margins `json:"margins,omitempty"`
+	// Bleed extends the printed area past the trimmed page on every side,
+	// so that backgrounds reach the edge after cutting.
+	Bleed float64 `json:"bleed,omitempty"`
+}
+
+// Margins are distances between page edges and content.
+type Margins struct {
+	Top    float64 `json:"top"`
+	Right  float64 `json:"right"`
+	Bottom float64 `json:"bottom"`
+	Left   float64 `json:"left"`
+}
+
+// Equal reports whether setups describe the same geometry.
+func (p PageSetup) Equal(other PageSetup) bool {
+	if (p.Margins == nil) != (other.Margins == nil) || p.Margins != nil && *p.Margins != *other.Margins {
+		return false
+	}
+	p.Margins, other.Margins = nil, nil
+	return p == other
+}
+
+// DefaultMarginMM is the page margin of templates without explicit margins.
+const DefaultMarginMM = 20
+
+// PageDimensions returns trimmed page width and height in millimetres,
+// accounting for orientation. Label templates use the label size instead.
+func (t Template) PageDimensions() (width, height float64) {
+	switch {
+	case t.Label.Set():
+		return t.Label.WidthMM, t.Label.HeightMM
+	case t.PageSize == PageSizeCustom:
+		return t.Page.Unit.mm(t.Page.Width), t.Page.Unit.mm(t.Page.Height)
+	}
+	size := pageSizesMM[t.PageSize]
+	width, height = size[0], size[1]
+	if t.Orientation == OrientationLandscape {
+		width, height = height, width
+	}
+	return width, height
+}
+
+// MarginsMM returns page margins in millimetres.
+func (t Template) MarginsMM() Margins {
+	switch {
+	case t.Label.Set():
+		m := t.Label.MarginMM
+		return Margins{m, m, m, m}
+	case t.Page.Margins != nil:
+		u, m := t.Page.Unit, t.Page.Margins
+		return Margins{u.mm(m.Top), u.mm(m.Right), u.mm(m.Bottom), u.mm(m.Left)}
+	}
+	return Margins{DefaultMarginMM, DefaultMarginMM, DefaultMarginMM, DefaultMarginMM}
+}
+
+// BleedMM returns bleed in millimetres. Labels have no bleed.
+func (t Template) BleedMM() float64 {
+	if t.Label.Set() {
+		return 0
+	}
+	return t.Page.Unit.mm(t.Page.Bleed)
+}
+
+// Bounds of page geometry in millimetres.
+const (
+	minPageMM  = 10
+	maxPageMM  = 2000
+	maxBleedMM = 25
+)
+
+func (t Template) validatePage(errs *validation.Errors) {
+	if !slices.Contains(PageSizes(), t.PageSize) {
+		errs.Add("page_size", validation.CodeInvalidValue, "is invalid")
+	}
+	p := t.Page
+	if p.Unit != "" && !slices.Contains(Units(), p.Unit) {
+		errs.Add("page.unit", validation.CodeInvalidValue, "must be mm, in or pt")
+	}
+	if t.PageSize == PageSizeCustom {
+		for _, d := range []struct {
+			field string
+			value float64
+		}{{"page.width", p.Width}, {"page.height", p.Height}} {
+			if mm := p.Unit.mm(d.value); mm < minPageMM || mm > maxPageMM {
+				errs.Add(d.field, validation.CodeInvalidValue, "must be between 10 and 2000 mm")
+			}
+		}
+	} else {
+		if p.Width != 0 {
+			errs.Add("page.width", validation.CodeInvalidValue, "is only allowed with custom page size")
+		}
+		if p.Height != 0 {
+			errs.Add("page.height", validation.CodeInvalidValue, "is only allowed with custom page size")
+		}
+	}
+	// Default margins must fit the page too, which small sizes such as A10
+	// do not. Labels check their own margin.
+	m := p.Margins
+	if m != nil && (m.Top < 0 || m.Right < 0 || m.Bottom < 0 || m.Left < 0) {
+		errs.Add("page.margins", validation.CodeInvalidValue, "must not be negative")
+	} else if width, height := t.PageDimensions(); width > 0 && height > 0 && !t.Label.Set() {
+		if mm := t.MarginsMM(); mm.Left+mm.Right >= width || mm.Top+mm.Bottom >= height {
+			message := "must leave room for content"
+			if m == nil {
+				message = "must be set, since the default 20 mm margins leave no room for content"
+			}
+			errs.Add("page.margins", validation.CodeInvalidValue, message)
+		}
+	}
+	if bleed := p.Unit.mm(p.Bleed); bleed < 0 || bleed > maxBleedMM {
+		errs.Add("page.bleed", validation.CodeInvalidValue, "must be between 0 and 25 mm")
+	}
+	if t.Label.Set() {
+		if t.DocumentType != DocumentTypeLabel {
+			errs.Add("label", validation.CodeInvalidValue, "is only supported by label templates")
+		}
+		t.Label.validate(errs)
+	}
+}
+
+// LabelSpec describes the stock of label templates printed on thermal
+// printers. It overrides page size, orientation and page setup.
+type LabelSpec struct {
+	WidthMM  float64 `json:"width_mm"`
+	HeightMM float64 `json:"height_mm"`
+	MarginMM float64 `json:"margin_mm,omitempty"`
+	// DPI is the printer resolution: 203, 300 or 600 dots per inch.
+	DPI int `json:"dpi"`
+}
+
+// Set reports whether label settings are present.
+func (l LabelSpec) Set() bool {
+	return l != LabelSpec{}
+}
+
+// LabelDPIs lists printer resolutions labels can target.
+func LabelDPIs() []int {
+	return []int{203, 300, 600}
+}
+
+func (l LabelSpec) validate(errs *validation.Errors) {
+	if l.WidthMM < 10 || l.WidthMM > 300 {
+		errs.Add("label.width_mm", validation.CodeInvalidValue, "must be between 10 and 300")
+	}
+	if l.HeightMM < 5 || l.HeightMM > 1000 {
+		errs.Add("label.height_mm", validation.CodeInvalidValue, "must be between 5 and 1000")
+	}
+	if l.MarginMM < 0 || 2*l.MarginMM >= min(l.WidthMM, l.HeightMM) {
+		errs.Add("label.margin_mm", validation.CodeInvalidValue, "must be non-negative and less than half of the label")
+	}
+	if !slices.Contains(LabelDPIs(), l.DPI) {
+		errs.Add("label.dpi", validation.CodeInvalidValue, "must be 203, 300 or 600")
+	}
+}
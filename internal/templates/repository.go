@@ -2,6 +2,7 @@ package templates
 
 import (
 	"context"
+	"time"
 )
 
 // ListOptions configure search and pagination behaviour.
@@ -23,21 +24,51 @@ type DuplicateOptions struct {
 	DescriptionOverride string
 }
 
+// LimitCheck is called by repository writes that add a template or version
+// with the number of live templates of the tenant, or versions of the
+// template, inside the lock or transaction of the write. Concurrent writes
+// thus cannot exceed plan limits together. An error aborts the write.
+type LimitCheck func(current int) error
+
 // Repository defines persistence layer for templates and versions.
 type Repository interface {
 	ListTemplates(ctx context.Context, opt ListOptions) ([]Template, error)
 	CountTemplates(ctx context.Context, opt ListOptions) (int, error)
 	GetTemplate(ctx context.Context, tenantID, templateID string) (*Template, error)
-	CreateTemplate(ctx context.Context, tpl Template) (*Template, error)
-	UpdateTemplate(ctx context.Context, tpl Template) (*Template, error)
+	// CreateTemplate stores template once check accepts the template count.
+	CreateTemplate(ctx context.Context, tpl Template, check LimitCheck) (*Template, error)
+	// UpdateTemplate stores template and, when version is not nil, adds it as
+	// the current version once check accepts the version count.
+	UpdateTemplate(ctx context.Context, tpl Template, version *TemplateVersion, check LimitCheck) (*Template, error)
 	SoftDeleteTemplate(ctx context.Context, tenantID, templateID string) error
-	RestoreTemplate(ctx context.Context, tenantID, templateID string) (*Template, error)
-	DuplicateTemplate(ctx context.Context, tenantID, templateID string, opt DuplicateOptions) (*Template, error)
+	// RestoreTemplate undeletes template once check accepts the template
+	// count; templates that are not deleted are returned as they are.
+	RestoreTemplate(ctx context.Context, tenantID, templateID string, check LimitCheck) (*Template, error)
+	// DuplicateTemplate copies template once check accepts the template count.
+	DuplicateTemplate(ctx context.Context, tenantID, templateID string, opt DuplicateOptions, check LimitCheck) (*Template, error)
+	// RecordUsage counts documents generated from template.
+	RecordUsage(ctx context.Context, tenantID, templateID string, documents int, at time.Time) error
 
 	ListVersions(ctx context.Context, tenantID, templateID string) ([]TemplateVersion, error)
 	CreateVersion(ctx context.Context, tenantID string, version TemplateVersion) (*TemplateVersion, error)
-	RestoreVersion(ctx context.Context, tenantID, templateID string, versionNumber int) (*TemplateVersion, error)
 	CompareVersions(ctx context.Context, tenantID, templateID string, left, right int) (*VersionComparison, error)
+	PublishVersion(ctx context.Context, tenantID, templateID string, versionNumber int, at time.Time) (*TemplateVersion, error)
+	// DeleteVersions removes versions and remembers their numbers as pruned.
+	DeleteVersions(ctx context.Context, tenantID, templateID string, versionNumbers []int) error
+	IsVersionPruned(ctx context.Context, tenantID, templateID string, versionNumber int) (bool, error)
+	// SetLabel attaches label to version, moving it from any other version.
+	SetLabel(ctx context.Context, tenantID, templateID, label string, versionNumber int) (*TemplateVersion, error)
+	RemoveLabel(ctx context.Context, tenantID, templateID, label string) error
+	SetPinned(ctx context.Context, tenantID, templateID string, versionNumber int, pinned bool) (*TemplateVersion, error)
+
+	AddMetadataChanges(ctx context.Context, tenantID string, changes []MetadataChange) error
+	// ListMetadataChanges returns metadata history of template, newest first.
+	ListMetadataChanges(ctx context.Context, tenantID, templateID string) ([]MetadataChange, error)
+
+	// GetRetentionPolicy reports false when tenant has no policy of its own.
+	GetRetentionPolicy(ctx context.Context, tenantID string) (RetentionPolicy, bool, error)
+	SetRetentionPolicy(ctx context.Context, tenantID string, policy RetentionPolicy) error
+	ListRetentionPolicies(ctx context.Context) (map[string]RetentionPolicy, error)
 }
 
 // VersionComparison describes the difference between two versions. For now it
@@ -0,0 +1,163 @@
+package templates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// VersionReferences reports template versions used by generated documents.
+type VersionReferences interface {
+	ReferencedVersions(ctx context.Context, tenantID, templateID string) ([]int, error)
+}
+
+// SchemaStore deletes schema blobs that no version refers to any more.
+type SchemaStore interface {
+	DeleteSchema(ctx context.Context, url string) error
+}
+
+// noReferences is used until documents are wired in.
+type noReferences struct{}
+
+func (noReferences) ReferencedVersions(context.Context, string, string) ([]int, error) {
+	return nil, nil
+}
+
+// WithVersionReferences lets pruning keep versions used by documents.
+func (s *TemplateService) WithVersionReferences(references VersionReferences) *TemplateService {
+	s.references = references
+	return s
+}
+
+// WithSchemaStore enables deletion of unreferenced schema blobs on pruning.
+// Without a store schemas live outside of this service and are left alone.
+func (s *TemplateService) WithSchemaStore(schemas SchemaStore) *TemplateService {
+	s.schemas = schemas
+	return s
+}
+
+// RetentionPolicy returns policy of tenant, or DefaultRetentionPolicy when
+// the tenant has none.
+func (s *TemplateService) RetentionPolicy(ctx context.Context, tenantID string) (RetentionPolicy, error) {
+	policy, ok, err := s.repo.GetRetentionPolicy(ctx, tenantID)
+	if err != nil || !ok {
+		return DefaultRetentionPolicy(), err
+	}
+	return policy, nil
+}
+
+// SetRetentionPolicy replaces policy of tenant.
+func (s *TemplateService) SetRetentionPolicy(ctx context.Context, tenantID string, policy RetentionPolicy) (RetentionPolicy, error) {
+	if err := policy.Validate(); err != nil {
+		return RetentionPolicy{}, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	if err := s.repo.SetRetentionPolicy(ctx, tenantID, policy); err != nil {
+		return RetentionPolicy{}, err
+	}
+	return policy, nil
+}
+
+// PruneAll prunes every tenant with a retention policy. Failures of single
+// tenants do not stop the others and are returned joined.
+func (s *TemplateService) PruneAll(ctx context.Context) ([]PruneResult, error) {
+	policies, err := s.repo.ListRetentionPolicies(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tenantIDs := make([]string, 0, len(policies))
+	for tenantID := range policies {
+		tenantIDs = append(tenantIDs, tenantID)
+	}
+	sort.Strings(tenantIDs)
+	var results []PruneResult
+	var errs []error
+	for _, tenantID := range tenantIDs {
+		result, err := s.PruneVersions(ctx, tenantID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("prune tenant %s: %w", tenantID, err))
+			continue
+		}
+		results = append(results, *result)
+	}
+	return results, errors.Join(errs...)
+}
+
+// PruneVersions removes versions of every template of tenant that its
+// retention policy does not keep, never pruning pinned or labelled ones,
+// then deletes schema blobs no remaining template or version refers to when
+// a schema store is configured. Restoring a pruned version fails with
+// ErrVersionPruned.
+func (s *TemplateService) PruneVersions(ctx context.Context, tenantID string) (*PruneResult, error) {
+	result := &PruneResult{TenantID: tenantID, SchemasDeleted: []string{}}
+	policy, err := s.RetentionPolicy(ctx, tenantID)
+	if err != nil || policy.KeepLast == 0 {
+		return result, err
+	}
+	tpls, err := s.repo.ListTemplates(ctx, ListOptions{TenantID: tenantID, IncludeDeleted: true})
+	if err != nil {
+		return nil, err
+	}
+	live := map[string]bool{}
+	candidates := map[string]bool{}
+	for _, tpl := range tpls {
+		live[tpl.JSONSchemaURL] = true
+		versions, err := s.repo.ListVersions(ctx, tenantID, tpl.TemplateID)
+		if err != nil {
+			return nil, err
+		}
+		keep, err := s.keptVersions(ctx, tenantID, tpl.TemplateID, policy, versions)
+		if err != nil {
+			return nil, err
+		}
+		var prune []int
+		for _, v := range versions {
+			if keep[v.VersionNumber] {
+				live[v.JSONSchemaURL] = true
+				continue
+			}
+			prune = append(prune, v.VersionNumber)
+			candidates[v.JSONSchemaURL] = true
+		}
+		if len(prune) == 0 {
+			continue
+		}
+		if err := s.repo.DeleteVersions(ctx, tenantID, tpl.TemplateID, prune); err != nil {
+			return nil, err
+		}
+		result.VersionsPruned += len(prune)
+	}
+	for url := range candidates {
+		if live[url] || s.schemas == nil {
+			continue
+		}
+		if err := s.schemas.DeleteSchema(ctx, url); err != nil {
+			return nil, fmt.Errorf("delete schema %s: %w", url, err)
+		}
+		result.SchemasDeleted = append(result.SchemasDeleted, url)
+	}
+	sort.Strings(result.SchemasDeleted)
+	return result, nil
+}
+
+// keptVersions returns numbers of versions that policy keeps.
+func (s *TemplateService) keptVersions(ctx context.Context, tenantID, templateID string, policy RetentionPolicy, versions []TemplateVersion) (map[int]bool, error) {
+	keep := map[int]bool{}
+	sorted := append([]TemplateVersion(nil), versions...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].VersionNumber > sorted[j].VersionNumber })
+	for i, v := range sorted {
+		if i < policy.KeepLast || v.IsCurrent || v.Protected() || (policy.KeepPublished && v.PublishedAt != nil) {
+			keep[v.VersionNumber] = true
+		}
+	}
+	if policy.KeepReferenced {
+		referenced, err := s.references.ReferencedVersions(ctx, tenantID, templateID)
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range referenced {
+			keep[n] = true
+		}
+	}
+	return keep, nil
+}
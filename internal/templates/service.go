@@ -2,11 +2,15 @@ package templates
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
 	"sort"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/ids"
 )
 
 // NewInMemoryRepository creates thread-safe repository for prototyping.
@@ -14,91 +18,190 @@ func NewInMemoryRepository() Repository {
 	return &inMemoryRepository{
 		templates: make(map[string]Template),
 		versions:  make(map[string][]TemplateVersion),
+		metadata:  make(map[string][]MetadataChange),
+		pruned:    make(map[string]map[int]bool),
+		policies:  make(map[string]RetentionPolicy),
 	}
 }
 
+// QuotaEnforcer checks tenant plan limits of state-changing operations.
+// Template and version counts are checked by the repository as LimitCheck.
+type QuotaEnforcer interface {
+	CheckTemplates(ctx context.Context, tenantID string, current int) error
+	CheckVersions(ctx context.Context, tenantID string, current int) error
+	CheckBatch(ctx context.Context, tenantID string, size int) error
+}
+
+// unlimitedQuota is used until a real enforcer is configured.
+type unlimitedQuota struct{}
+
+func (unlimitedQuota) CheckTemplates(context.Context, string, int) error { return nil }
+func (unlimitedQuota) CheckVersions(context.Context, string, int) error  { return nil }
+func (unlimitedQuota) CheckBatch(context.Context, string, int) error     { return nil }
+
 // TemplateService orchestrates repository operations with validation and
 // business logic.
 type TemplateService struct {
-	repo Repository
+	repo            Repository
+	quota           QuotaEnforcer
+	references      VersionReferences
+	schemas         SchemaStore
+	versionMetadata bool
 }
 
 // NewTemplateService creates service instance.
 func NewTemplateService(repo Repository) *TemplateService {
-	return &TemplateService{repo: repo}
+	return &TemplateService{repo: repo, quota: unlimitedQuota{}, references: noReferences{}}
+}
+
+// WithQuota enables plan limit enforcement.
+func (s *TemplateService) WithQuota(quota QuotaEnforcer) *TemplateService {
+	s.quota = quota
+	return s
+}
+
+// WithMetadataVersioning makes every update record a new TemplateVersion, as
+// before metadata history existed. By default only changes of schema, page
+// size or orientation do.
+func (s *TemplateService) WithMetadataVersioning(enabled bool) *TemplateService {
+	s.versionMetadata = enabled
+	return s
+}
+
+// CountTemplates returns number of live templates of tenant.
+func (s *TemplateService) CountTemplates(ctx context.Context, tenantID string) (int, error) {
+	return s.repo.CountTemplates(ctx, ListOptions{TenantID: tenantID})
+}
+
+// CheckBatch verifies bulk operation size against tenant plan.
+func (s *TemplateService) CheckBatch(ctx context.Context, tenantID string, size int) error {
+	return s.quota.CheckBatch(ctx, tenantID, size)
+}
+
+// templateLimit checks template count of tenant against its plan.
+func (s *TemplateService) templateLimit(ctx context.Context, tenantID string) LimitCheck {
+	return func(current int) error {
+		return s.quota.CheckTemplates(ctx, tenantID, current)
+	}
+}
+
+// versionLimit checks version count of a template against the tenant plan.
+func (s *TemplateService) versionLimit(ctx context.Context, tenantID string) LimitCheck {
+	return func(current int) error {
+		return s.quota.CheckVersions(ctx, tenantID, current)
+	}
 }
 
 // CreateTemplate handles validation and creation.
 func (s *TemplateService) CreateTemplate(ctx context.Context, tpl Template) (*Template, error) {
-	tpl.TemplateID = newID()
+	tpl.TemplateID = ids.New()
 	now := time.Now().UTC()
 	tpl.CreatedAt = now
 	tpl.UpdatedAt = now
 	tpl.Version = 1
 	if err := tpl.Validate(); err != nil {
-		return nil, fmt.Errorf("validate template: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
 	}
-	created, err := s.repo.CreateTemplate(ctx, tpl)
+	created, err := s.repo.CreateTemplate(ctx, tpl, s.templateLimit(ctx, tpl.TenantID))
 	if err != nil {
 		return nil, err
 	}
-	version := TemplateVersion{
-		VersionID:     newID(),
-		TemplateID:    tpl.TemplateID,
-		VersionNumber: tpl.Version,
-		JSONSchemaURL: tpl.JSONSchemaURL,
-		ChangeSummary: "initial version",
-		CreatedBy:     tpl.CreatedBy,
-		CreatedAt:     now,
-		IsCurrent:     true,
-	}
+	version := newVersion(tpl, "initial version", tpl.CreatedBy, now)
 	if _, err := s.repo.CreateVersion(ctx, tpl.TenantID, version); err != nil {
 		return nil, err
 	}
 	return created, nil
 }
 
-// UpdateTemplate updates template metadata while incrementing version history.
+// UpdateTemplate applies mutate to template. Changes of schema, page size or
+// orientation increment version history; metadata edits are recorded as
+// MetadataChange entries instead.
 func (s *TemplateService) UpdateTemplate(ctx context.Context, tenantID, templateID string, mutate func(*Template) error, updatedBy string, changeSummary string) (*Template, error) {
+	updated, _, err := s.update(ctx, tenantID, templateID, mutate, updatedBy, changeSummary, false)
+	return updated, err
+}
+
+// update implements UpdateTemplate. forceVersion records a new version even
+// when only metadata changed; the created version is nil otherwise.
+func (s *TemplateService) update(ctx context.Context, tenantID, templateID string, mutate func(*Template) error, updatedBy, changeSummary string, forceVersion bool) (*Template, *TemplateVersion, error) {
 	tpl, err := s.repo.GetTemplate(ctx, tenantID, templateID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if tpl.DeletedAt != nil {
-		return nil, fmt.Errorf("template is deleted: %w", ErrInvalidInput)
+		return nil, nil, ErrTemplateDeleted
 	}
+	before := *tpl
 	if err := mutate(tpl); err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	tpl.Version++
 	tpl.UpdatedBy = updatedBy
 	tpl.UpdatedAt = time.Now().UTC()
+	versioned := forceVersion || s.versionMetadata || contentChanged(before, *tpl)
+	if versioned {
+		tpl.Version++
+	}
 	if err := tpl.Validate(); err != nil {
-		return nil, err
+		return nil, nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
+	}
+	var version *TemplateVersion
+	if versioned {
+		v := newVersion(*tpl, changeSummary, updatedBy, tpl.UpdatedAt)
+		version = &v
 	}
-	updated, err := s.repo.UpdateTemplate(ctx, *tpl)
+	updated, err := s.repo.UpdateTemplate(ctx, *tpl, version, s.versionLimit(ctx, tenantID))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	version := TemplateVersion{
-		VersionID:     newID(),
-		TemplateID:    tpl.TemplateID,
-		VersionNumber: tpl.Version,
-		JSONSchemaURL: tpl.JSONSchemaURL,
-		ChangeSummary: changeSummary,
-		CreatedBy:     updatedBy,
-		CreatedAt:     tpl.UpdatedAt,
-		IsCurrent:     true,
-	}
-	if _, err := s.repo.CreateVersion(ctx, tenantID, version); err != nil {
-		return nil, err
+	if changes := metadataChanges(before, *tpl); len(changes) > 0 {
+		if err := s.repo.AddMetadataChanges(ctx, tenantID, changes); err != nil {
+			return nil, nil, err
+		}
 	}
-	return updated, nil
+	return updated, version, nil
+}
+
+// contentChanged reports whether schema or layout-affecting fields differ.
+func contentChanged(before, after Template) bool {
+	return before.JSONSchemaURL != after.JSONSchemaURL ||
+		before.PageSize != after.PageSize ||
+		before.Orientation != after.Orientation ||
+		!before.Page.Equal(after.Page) ||
+		before.Label != after.Label ||
+		before.Signing != after.Signing ||
+		!before.Layout.Equal(after.Layout) ||
+		!before.Localization.Equal(after.Localization)
+}
+
+// metadataChanges lists metadata fields that differ between before and after,
+// attributed to the updater of after.
+func metadataChanges(before, after Template) []MetadataChange {
+	var changes []MetadataChange
+	for _, f := range []struct{ field, old, new string }{
+		{"name", before.Name, after.Name},
+		{"description", before.Description, after.Description},
+		{"document_type", string(before.DocumentType), string(after.DocumentType)},
+		{"thumbnail_url", before.ThumbnailURL, after.ThumbnailURL},
+	} {
+		if f.old == f.new {
+			continue
+		}
+		changes = append(changes, MetadataChange{
+			ChangeID:   ids.New(),
+			TemplateID: after.TemplateID,
+			Field:      f.field,
+			OldValue:   f.old,
+			NewValue:   f.new,
+			ChangedBy:  after.UpdatedBy,
+			ChangedAt:  after.UpdatedAt,
+		})
+	}
+	return changes
 }
 
 // DuplicateTemplate duplicates template with optional version copy.
 func (s *TemplateService) DuplicateTemplate(ctx context.Context, tenantID, templateID string, opt DuplicateOptions) (*Template, error) {
-	tpl, err := s.repo.DuplicateTemplate(ctx, tenantID, templateID, opt)
+	tpl, err := s.repo.DuplicateTemplate(ctx, tenantID, templateID, opt, s.templateLimit(ctx, tenantID))
 	if err != nil {
 		return nil, err
 	}
@@ -109,7 +212,9 @@ func (s *TemplateService) DuplicateTemplate(ctx context.Context, tenantID, templ
 		}
 		for _, v := range versions {
 			v.TemplateID = tpl.TemplateID
-			v.VersionID = newID()
+			v.VersionID = ids.New()
+			v.Labels = []string{}
+			v.Pinned = false
 			if v.IsCurrent {
 				v.VersionNumber = tpl.Version
 			}
@@ -123,7 +228,7 @@ func (s *TemplateService) DuplicateTemplate(ctx context.Context, tenantID, templ
 
 // RestoreTemplate performs soft delete restoration.
 func (s *TemplateService) RestoreTemplate(ctx context.Context, tenantID, templateID string) (*Template, error) {
-	return s.repo.RestoreTemplate(ctx, tenantID, templateID)
+	return s.repo.RestoreTemplate(ctx, tenantID, templateID, s.templateLimit(ctx, tenantID))
 }
 
 // DeleteTemplate performs soft delete.
@@ -131,6 +236,12 @@ func (s *TemplateService) DeleteTemplate(ctx context.Context, tenantID, template
 	return s.repo.SoftDeleteTemplate(ctx, tenantID, templateID)
 }
 
+// RecordUsage updates documents count and last use time of template after
+// documents were generated from it.
+func (s *TemplateService) RecordUsage(ctx context.Context, tenantID, templateID string, documents int) error {
+	return s.repo.RecordUsage(ctx, tenantID, templateID, documents, time.Now().UTC())
+}
+
 // ListTemplates proxies listing operation.
 func (s *TemplateService) ListTemplates(ctx context.Context, opt ListOptions) ([]Template, int, error) {
 	items, err := s.repo.ListTemplates(ctx, opt)
@@ -149,23 +260,97 @@ func (s *TemplateService) GetTemplate(ctx context.Context, tenantID, templateID
 	return s.repo.GetTemplate(ctx, tenantID, templateID)
 }
 
-// Version helpers
-func (s *TemplateService) ListVersions(ctx context.Context, tenantID, templateID string) ([]TemplateVersion, error) {
-	return s.repo.ListVersions(ctx, tenantID, templateID)
+// ListVersions returns page of template versions, newest first, and total
+// number of versions.
+func (s *TemplateService) ListVersions(ctx context.Context, tenantID, templateID string, limit, offset int) ([]TemplateVersion, int, error) {
+	versions, err := s.repo.ListVersions(ctx, tenantID, templateID)
+	if err != nil {
+		return nil, 0, err
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return versions[i].VersionNumber > versions[j].VersionNumber
+	})
+	total := len(versions)
+	if offset > total {
+		return []TemplateVersion{}, total, nil
+	}
+	end := offset + limit
+	if limit <= 0 || end > total {
+		end = total
+	}
+	return versions[offset:end], total, nil
+}
+
+// PublishVersion marks version as published; published versions survive
+// pruning when the retention policy keeps them.
+func (s *TemplateService) PublishVersion(ctx context.Context, tenantID, templateID string, versionNumber int) (*TemplateVersion, error) {
+	version, err := s.repo.PublishVersion(ctx, tenantID, templateID, versionNumber, time.Now().UTC())
+	if errors.Is(err, ErrVersionNotFound) {
+		return nil, s.missingVersion(ctx, tenantID, templateID, versionNumber)
+	}
+	return version, err
+}
+
+// missingVersion explains why versions are absent: ErrVersionPruned when
+// any of them was pruned, ErrVersionNotFound otherwise.
+func (s *TemplateService) missingVersion(ctx context.Context, tenantID, templateID string, versionNumbers ...int) error {
+	for _, n := range versionNumbers {
+		pruned, err := s.repo.IsVersionPruned(ctx, tenantID, templateID, n)
+		if err != nil {
+			return err
+		}
+		if pruned {
+			return fmt.Errorf("%w: version %d", ErrVersionPruned, n)
+		}
+	}
+	return ErrVersionNotFound
+}
+
+// RestoreVersion applies snapshot of version to template and records it as a
+// new version created by restoredBy.
+func (s *TemplateService) RestoreVersion(ctx context.Context, tenantID, templateID string, versionNumber int, restoredBy string) (*TemplateVersion, error) {
+	versions, err := s.repo.ListVersions(ctx, tenantID, templateID)
+	if err != nil {
+		return nil, err
+	}
+	var snapshot *TemplateVersion
+	for i := range versions {
+		if versions[i].VersionNumber == versionNumber {
+			snapshot = &versions[i]
+			break
+		}
+	}
+	if snapshot == nil {
+		return nil, s.missingVersion(ctx, tenantID, templateID, versionNumber)
+	}
+	_, restored, err := s.update(ctx, tenantID, templateID, func(t *Template) error {
+		snapshot.applyTo(t)
+		return nil
+	}, restoredBy, fmt.Sprintf("restored version %d", versionNumber), true)
+	return restored, err
 }
 
-func (s *TemplateService) RestoreVersion(ctx context.Context, tenantID, templateID string, versionNumber int) (*TemplateVersion, error) {
-	return s.repo.RestoreVersion(ctx, tenantID, templateID, versionNumber)
+// ListMetadataChanges returns who changed which metadata field and when,
+// newest first.
+func (s *TemplateService) ListMetadataChanges(ctx context.Context, tenantID, templateID string) ([]MetadataChange, error) {
+	return s.repo.ListMetadataChanges(ctx, tenantID, templateID)
 }
 
 func (s *TemplateService) CompareVersions(ctx context.Context, tenantID, templateID string, left, right int) (*VersionComparison, error) {
-	return s.repo.CompareVersions(ctx, tenantID, templateID, left, right)
+	comparison, err := s.repo.CompareVersions(ctx, tenantID, templateID, left, right)
+	if errors.Is(err, ErrVersionNotFound) {
+		return nil, s.missingVersion(ctx, tenantID, templateID, left, right)
+	}
+	return comparison, err
 }
 
 // inMemoryRepository is prototyping repository with maps.
 type inMemoryRepository struct {
 	templates map[string]Template
 	versions  map[string][]TemplateVersion
+	metadata  map[string][]MetadataChange
+	pruned    map[string]map[int]bool
+	policies  map[string]RetentionPolicy
 	mu        sync.RWMutex
 }
 
@@ -241,29 +426,52 @@ func (r *inMemoryRepository) GetTemplate(ctx context.Context, tenantID, template
 	defer r.mu.RUnlock()
 	tpl, ok := r.templates[templateID]
 	if !ok || tpl.TenantID != tenantID {
-		return nil, ErrNotFound
+		return nil, ErrTemplateNotFound
 	}
 	clone := tpl
 	return &clone, nil
 }
 
-func (r *inMemoryRepository) CreateTemplate(ctx context.Context, tpl Template) (*Template, error) {
+// liveTemplates counts templates of tenant that are not deleted; callers
+// hold the lock.
+func (r *inMemoryRepository) liveTemplates(tenantID string) int {
+	count := 0
+	for _, tpl := range r.templates {
+		if tpl.TenantID == tenantID && tpl.DeletedAt == nil {
+			count++
+		}
+	}
+	return count
+}
+
+func (r *inMemoryRepository) CreateTemplate(ctx context.Context, tpl Template, check LimitCheck) (*Template, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	if _, exists := r.templates[tpl.TemplateID]; exists {
-		return nil, ErrConflict
+		return nil, ErrDuplicateID
+	}
+	if err := check(r.liveTemplates(tpl.TenantID)); err != nil {
+		return nil, err
 	}
 	r.templates[tpl.TemplateID] = tpl
 	clone := tpl
 	return &clone, nil
 }
 
-func (r *inMemoryRepository) UpdateTemplate(ctx context.Context, tpl Template) (*Template, error) {
+func (r *inMemoryRepository) UpdateTemplate(ctx context.Context, tpl Template, version *TemplateVersion, check LimitCheck) (*Template, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	existing, ok := r.templates[tpl.TemplateID]
 	if !ok || existing.TenantID != tpl.TenantID {
-		return nil, ErrNotFound
+		return nil, ErrTemplateNotFound
+	}
+	if version != nil {
+		if err := check(len(r.versions[tpl.TemplateID])); err != nil {
+			return nil, err
+		}
+		if err := r.addVersion(*version); err != nil {
+			return nil, err
+		}
 	}
 	r.templates[tpl.TemplateID] = tpl
 	clone := tpl
@@ -275,7 +483,7 @@ func (r *inMemoryRepository) SoftDeleteTemplate(ctx context.Context, tenantID, t
 	defer r.mu.Unlock()
 	tpl, ok := r.templates[templateID]
 	if !ok || tpl.TenantID != tenantID {
-		return ErrNotFound
+		return ErrTemplateNotFound
 	}
 	now := time.Now().UTC()
 	tpl.DeletedAt = &now
@@ -283,12 +491,19 @@ func (r *inMemoryRepository) SoftDeleteTemplate(ctx context.Context, tenantID, t
 	return nil
 }
 
-func (r *inMemoryRepository) RestoreTemplate(ctx context.Context, tenantID, templateID string) (*Template, error) {
+func (r *inMemoryRepository) RestoreTemplate(ctx context.Context, tenantID, templateID string, check LimitCheck) (*Template, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	tpl, ok := r.templates[templateID]
 	if !ok || tpl.TenantID != tenantID {
-		return nil, ErrNotFound
+		return nil, ErrTemplateNotFound
+	}
+	if tpl.DeletedAt == nil {
+		clone := tpl
+		return &clone, nil
+	}
+	if err := check(r.liveTemplates(tenantID)); err != nil {
+		return nil, err
 	}
 	tpl.DeletedAt = nil
 	r.templates[templateID] = tpl
@@ -296,16 +511,32 @@ func (r *inMemoryRepository) RestoreTemplate(ctx context.Context, tenantID, temp
 	return &clone, nil
 }
 
-func (r *inMemoryRepository) DuplicateTemplate(ctx context.Context, tenantID, templateID string, opt DuplicateOptions) (*Template, error) {
+func (r *inMemoryRepository) RecordUsage(ctx context.Context, tenantID, templateID string, documents int, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tpl, ok := r.templates[templateID]
+	if !ok || tpl.TenantID != tenantID {
+		return ErrTemplateNotFound
+	}
+	tpl.DocumentsCount += documents
+	tpl.LastUsedAt = &at
+	r.templates[templateID] = tpl
+	return nil
+}
+
+func (r *inMemoryRepository) DuplicateTemplate(ctx context.Context, tenantID, templateID string, opt DuplicateOptions, check LimitCheck) (*Template, error) {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	tpl, ok := r.templates[templateID]
 	if !ok || tpl.TenantID != tenantID {
-		return nil, ErrNotFound
+		return nil, ErrTemplateNotFound
+	}
+	if err := check(r.liveTemplates(tenantID)); err != nil {
+		return nil, err
 	}
 	now := time.Now().UTC()
 	clone := tpl
-	clone.TemplateID = newID()
+	clone.TemplateID = ids.New()
 	clone.CreatedAt = now
 	clone.UpdatedAt = now
 	clone.CreatedBy = opt.CreatedBy
@@ -323,22 +554,13 @@ func (r *inMemoryRepository) DuplicateTemplate(ctx context.Context, tenantID, te
 		clone.Description = opt.DescriptionOverride
 	}
 	if err := clone.Validate(); err != nil {
-		return nil, err
+		return nil, fmt.Errorf("%w: %w", ErrInvalidInput, err)
 	}
 	if _, exists := r.templates[clone.TemplateID]; exists {
-		return nil, ErrConflict
+		return nil, ErrDuplicateID
 	}
 	r.templates[clone.TemplateID] = clone
-	version := TemplateVersion{
-		VersionID:     newID(),
-		TemplateID:    clone.TemplateID,
-		VersionNumber: clone.Version,
-		JSONSchemaURL: clone.JSONSchemaURL,
-		ChangeSummary: "duplicated from " + tpl.TemplateID,
-		CreatedBy:     opt.UpdatedBy,
-		CreatedAt:     now,
-		IsCurrent:     true,
-	}
+	version := newVersion(clone, "duplicated from "+tpl.TemplateID, opt.UpdatedBy, now)
 	r.versions[clone.TemplateID] = append(r.versions[clone.TemplateID], version)
 	dup := clone
 	return &dup, nil
@@ -349,10 +571,14 @@ func (r *inMemoryRepository) ListVersions(ctx context.Context, tenantID, templat
 	defer r.mu.RUnlock()
 	tpl, ok := r.templates[templateID]
 	if !ok || tpl.TenantID != tenantID {
-		return nil, ErrNotFound
+		return nil, ErrTemplateNotFound
 	}
-	versions := r.versions[templateID]
-	return append([]TemplateVersion(nil), versions...), nil
+	versions := make([]TemplateVersion, len(r.versions[templateID]))
+	for i, version := range r.versions[templateID] {
+		version.Labels = append([]string{}, version.Labels...)
+		versions[i] = version
+	}
+	return versions, nil
 }
 
 func (r *inMemoryRepository) CreateVersion(ctx context.Context, tenantID string, version TemplateVersion) (*TemplateVersion, error) {
@@ -360,48 +586,25 @@ func (r *inMemoryRepository) CreateVersion(ctx context.Context, tenantID string,
 	defer r.mu.Unlock()
 	tpl, ok := r.templates[version.TemplateID]
 	if !ok || tpl.TenantID != tenantID {
-		return nil, ErrNotFound
+		return nil, ErrTemplateNotFound
 	}
-	if err := version.Validate(); err != nil {
+	if err := r.addVersion(version); err != nil {
 		return nil, err
 	}
-	for i := range r.versions[version.TemplateID] {
-		r.versions[version.TemplateID][i].IsCurrent = false
-	}
-	r.versions[version.TemplateID] = append(r.versions[version.TemplateID], version)
 	clone := version
 	return &clone, nil
 }
 
-func (r *inMemoryRepository) RestoreVersion(ctx context.Context, tenantID, templateID string, versionNumber int) (*TemplateVersion, error) {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-	tpl, ok := r.templates[templateID]
-	if !ok || tpl.TenantID != tenantID {
-		return nil, ErrNotFound
-	}
-	versions := r.versions[templateID]
-	var restored *TemplateVersion
-	for i := range versions {
-		if versions[i].VersionNumber == versionNumber {
-			restored = &versions[i]
-			break
-		}
+// addVersion appends version as the current one; callers hold the lock.
+func (r *inMemoryRepository) addVersion(version TemplateVersion) error {
+	if err := version.Validate(); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidInput, err)
 	}
-	if restored == nil {
-		return nil, ErrNotFound
+	for i := range r.versions[version.TemplateID] {
+		r.versions[version.TemplateID][i].IsCurrent = false
 	}
-	tpl.JSONSchemaURL = restored.JSONSchemaURL
-	tpl.Version++
-	tpl.UpdatedAt = time.Now().UTC()
-	tpl.UpdatedBy = restored.CreatedBy
-	r.templates[templateID] = tpl
-	clone := *restored
-	clone.IsCurrent = true
-	clone.VersionNumber = tpl.Version
-	clone.CreatedAt = tpl.UpdatedAt
-	r.versions[templateID] = append(r.versions[templateID], clone)
-	return &clone, nil
+	r.versions[version.TemplateID] = append(r.versions[version.TemplateID], version)
+	return nil
 }
 
 func (r *inMemoryRepository) CompareVersions(ctx context.Context, tenantID, templateID string, left, right int) (*VersionComparison, error) {
@@ -409,7 +612,7 @@ func (r *inMemoryRepository) CompareVersions(ctx context.Context, tenantID, temp
 	defer r.mu.RUnlock()
 	tpl, ok := r.templates[templateID]
 	if !ok || tpl.TenantID != tenantID {
-		return nil, ErrNotFound
+		return nil, ErrTemplateNotFound
 	}
 	var leftVersion, rightVersion *TemplateVersion
 	for i := range r.versions[templateID] {
@@ -424,7 +627,7 @@ func (r *inMemoryRepository) CompareVersions(ctx context.Context, tenantID, temp
 		}
 	}
 	if leftVersion == nil || rightVersion == nil {
-		return nil, ErrNotFound
+		return nil, ErrVersionNotFound
 	}
 	summary := fmt.Sprintf("left schema: %s, right schema: %s", leftVersion.JSONSchemaURL, rightVersion.JSONSchemaURL)
 	return &VersionComparison{
@@ -434,3 +637,174 @@ func (r *inMemoryRepository) CompareVersions(ctx context.Context, tenantID, temp
 		Summary:    summary,
 	}, nil
 }
+
+func (r *inMemoryRepository) AddMetadataChanges(ctx context.Context, tenantID string, changes []MetadataChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, change := range changes {
+		tpl, ok := r.templates[change.TemplateID]
+		if !ok || tpl.TenantID != tenantID {
+			return ErrTemplateNotFound
+		}
+		r.metadata[change.TemplateID] = append(r.metadata[change.TemplateID], change)
+	}
+	return nil
+}
+
+func (r *inMemoryRepository) ListMetadataChanges(ctx context.Context, tenantID, templateID string) ([]MetadataChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tpl, ok := r.templates[templateID]
+	if !ok || tpl.TenantID != tenantID {
+		return nil, ErrTemplateNotFound
+	}
+	changes := append([]MetadataChange(nil), r.metadata[templateID]...)
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].ChangedAt.After(changes[j].ChangedAt)
+	})
+	return changes, nil
+}
+
+func (r *inMemoryRepository) PublishVersion(ctx context.Context, tenantID, templateID string, versionNumber int, at time.Time) (*TemplateVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tpl, ok := r.templates[templateID]
+	if !ok || tpl.TenantID != tenantID {
+		return nil, ErrTemplateNotFound
+	}
+	for i := range r.versions[templateID] {
+		version := &r.versions[templateID][i]
+		if version.VersionNumber != versionNumber {
+			continue
+		}
+		if version.PublishedAt == nil {
+			version.PublishedAt = &at
+		}
+		clone := *version
+		return &clone, nil
+	}
+	return nil, ErrVersionNotFound
+}
+
+func (r *inMemoryRepository) DeleteVersions(ctx context.Context, tenantID, templateID string, versionNumbers []int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tpl, ok := r.templates[templateID]
+	if !ok || tpl.TenantID != tenantID {
+		return ErrTemplateNotFound
+	}
+	if r.pruned[templateID] == nil {
+		r.pruned[templateID] = make(map[int]bool)
+	}
+	remove := make(map[int]bool, len(versionNumbers))
+	for _, n := range versionNumbers {
+		remove[n] = true
+	}
+	kept := r.versions[templateID][:0]
+	for _, version := range r.versions[templateID] {
+		if remove[version.VersionNumber] {
+			r.pruned[templateID][version.VersionNumber] = true
+			continue
+		}
+		kept = append(kept, version)
+	}
+	r.versions[templateID] = kept
+	return nil
+}
+
+func (r *inMemoryRepository) IsVersionPruned(ctx context.Context, tenantID, templateID string, versionNumber int) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tpl, ok := r.templates[templateID]
+	if !ok || tpl.TenantID != tenantID {
+		return false, ErrTemplateNotFound
+	}
+	return r.pruned[templateID][versionNumber], nil
+}
+
+func (r *inMemoryRepository) GetRetentionPolicy(ctx context.Context, tenantID string) (RetentionPolicy, bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	policy, ok := r.policies[tenantID]
+	return policy, ok, nil
+}
+
+func (r *inMemoryRepository) SetRetentionPolicy(ctx context.Context, tenantID string, policy RetentionPolicy) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.policies[tenantID] = policy
+	return nil
+}
+
+func (r *inMemoryRepository) ListRetentionPolicies(ctx context.Context) (map[string]RetentionPolicy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	policies := make(map[string]RetentionPolicy, len(r.policies))
+	for tenantID, policy := range r.policies {
+		policies[tenantID] = policy
+	}
+	return policies, nil
+}
+
+func (r *inMemoryRepository) SetLabel(ctx context.Context, tenantID, templateID, label string, versionNumber int) (*TemplateVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tpl, ok := r.templates[templateID]
+	if !ok || tpl.TenantID != tenantID {
+		return nil, ErrTemplateNotFound
+	}
+	versions := r.versions[templateID]
+	target := -1
+	for i := range versions {
+		if versions[i].VersionNumber == versionNumber {
+			target = i
+		}
+	}
+	if target < 0 {
+		return nil, ErrVersionNotFound
+	}
+	for i := range versions {
+		versions[i].Labels = slices.DeleteFunc(versions[i].Labels, func(l string) bool { return l == label })
+	}
+	versions[target].Labels = append(versions[target].Labels, label)
+	sort.Strings(versions[target].Labels)
+	clone := versions[target]
+	clone.Labels = append([]string{}, clone.Labels...)
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) RemoveLabel(ctx context.Context, tenantID, templateID, label string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tpl, ok := r.templates[templateID]
+	if !ok || tpl.TenantID != tenantID {
+		return ErrTemplateNotFound
+	}
+	for i := range r.versions[templateID] {
+		version := &r.versions[templateID][i]
+		if slices.Contains(version.Labels, label) {
+			version.Labels = slices.DeleteFunc(version.Labels, func(l string) bool { return l == label })
+			return nil
+		}
+	}
+	return ErrLabelNotFound
+}
+
+func (r *inMemoryRepository) SetPinned(ctx context.Context, tenantID, templateID string, versionNumber int, pinned bool) (*TemplateVersion, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tpl, ok := r.templates[templateID]
+	if !ok || tpl.TenantID != tenantID {
+		return nil, ErrTemplateNotFound
+	}
+	for i := range r.versions[templateID] {
+		version := &r.versions[templateID][i]
+		if version.VersionNumber == versionNumber {
+			version.Pinned = pinned
+			clone := *version
+			clone.Labels = append([]string{}, clone.Labels...)
+			return &clone, nil
+		}
+	}
+	return nil, ErrVersionNotFound
+}
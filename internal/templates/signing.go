@@ -0,0 +1,69 @@
+package templates
+
+import (
+	"github.com/lumiforge/docfactory-backend/internal/validation"
+)
+
+// SigningSpec makes PDF files of certificate templates carry a PAdES
+// signature made with a certificate the tenant uploaded. Box, when set,
+// shows the signature on the page.
+type SigningSpec struct {
+	CertificateID string       `json:"certificate_id"`
+	Reason        string       `json:"reason,omitempty"`
+	Location      string       `json:"location,omitempty"`
+	Box           SignatureBox `json:"box,omitzero"`
+}
+
+// SignatureBox places the visible signature. Page is 1-based; negative
+// pages count from the last one, so -1 is the last page. Position is
+// measured from the top-left corner of the page.
+type SignatureBox struct {
+	Page     int     `json:"page"`
+	XMM      float64 `json:"x_mm"`
+	YMM      float64 `json:"y_mm"`
+	WidthMM  float64 `json:"width_mm"`
+	HeightMM float64 `json:"height_mm"`
+}
+
+// Set reports whether signing is enabled.
+func (s SigningSpec) Set() bool {
+	return s != SigningSpec{}
+}
+
+// Set reports whether the signature is visible.
+func (b SignatureBox) Set() bool {
+	return b != SignatureBox{}
+}
+
+func (t Template) validateSigning(errs *validation.Errors) {
+	s := t.Signing
+	if !s.Set() {
+		return
+	}
+	if t.DocumentType != DocumentTypeCertificate {
+		errs.Add("signing", validation.CodeInvalidValue, "is only supported by certificate templates")
+	}
+	errs.Required("signing.certificate_id", s.CertificateID)
+	errs.Length("signing.reason", s.Reason, 0, 200)
+	errs.Length("signing.location", s.Location, 0, 200)
+	b := s.Box
+	if !b.Set() {
+		return
+	}
+	if b.Page == 0 {
+		errs.Add("signing.box.page", validation.CodeInvalidValue, "must be a page number, or negative to count from the last page")
+	}
+	if b.WidthMM < 20 || b.WidthMM > 200 {
+		errs.Add("signing.box.width_mm", validation.CodeInvalidValue, "must be between 20 and 200")
+	}
+	if b.HeightMM < 8 || b.HeightMM > 100 {
+		errs.Add("signing.box.height_mm", validation.CodeInvalidValue, "must be between 8 and 100")
+	}
+	width, height := t.PageDimensions()
+	if b.XMM < 0 || (width > 0 && b.XMM+b.WidthMM > width) {
+		errs.Add("signing.box.x_mm", validation.CodeInvalidValue, "must keep the box on the page")
+	}
+	if b.YMM < 0 || (height > 0 && b.YMM+b.HeightMM > height) {
+		errs.Add("signing.box.y_mm", validation.CodeInvalidValue, "must keep the box on the page")
+	}
+}
@@ -0,0 +1,53 @@
+package tenants
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Subscription enumerates tenant subscription plans.
+type Subscription string
+
+const (
+	SubscriptionFree       Subscription = "free"
+	SubscriptionPro        Subscription = "pro"
+	SubscriptionEnterprise Subscription = "enterprise"
+)
+
+// Tenant represents the tenants table structure.
+type Tenant struct {
+	TenantID     string       `json:"tenant_id"`
+	Name         string       `json:"name"`
+	Subscription Subscription `json:"subscription"`
+	CreatedAt    time.Time    `json:"created_at"`
+	UpdatedAt    time.Time    `json:"updated_at"`
+	IsActive     bool         `json:"is_active"`
+}
+
+var (
+	// ErrNotFound is returned when tenant does not exist.
+	ErrNotFound = errors.New("tenants: tenant not found")
+	// ErrConflict is returned when tenant name is already taken.
+	ErrConflict = errors.New("tenants: conflict detected")
+	// ErrInvalidInput indicates validation error.
+	ErrInvalidInput = errors.New("tenants: invalid input")
+	// ErrInactive is returned when tenant is suspended.
+	ErrInactive = errors.New("tenants: tenant is suspended")
+)
+
+// Validate ensures tenant structure is valid according to business rules.
+func (t Tenant) Validate() error {
+	if t.TenantID == "" {
+		return errors.New("tenant_id is required")
+	}
+	if len(strings.TrimSpace(t.Name)) < 2 || len(t.Name) > 200 {
+		return errors.New("name must be between 2 and 200 characters")
+	}
+	switch t.Subscription {
+	case SubscriptionFree, SubscriptionPro, SubscriptionEnterprise:
+	default:
+		return errors.New("subscription is invalid")
+	}
+	return nil
+}
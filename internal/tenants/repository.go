@@ -0,0 +1,22 @@
+package tenants
+
+import (
+	"context"
+)
+
+// ListOptions configure search and pagination behaviour.
+type ListOptions struct {
+	Search          string
+	IncludeInactive bool
+	Limit           int
+	Offset          int
+}
+
+// Repository defines persistence layer for tenants.
+type Repository interface {
+	ListTenants(ctx context.Context, opt ListOptions) ([]Tenant, error)
+	CountTenants(ctx context.Context, opt ListOptions) (int, error)
+	GetTenant(ctx context.Context, tenantID string) (*Tenant, error)
+	CreateTenant(ctx context.Context, tenant Tenant) (*Tenant, error)
+	UpdateTenant(ctx context.Context, tenant Tenant) (*Tenant, error)
+}
@@ -0,0 +1,200 @@
+package tenants
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/ids"
+)
+
+// NewInMemoryRepository creates thread-safe repository for prototyping.
+func NewInMemoryRepository() Repository {
+	return &inMemoryRepository{tenants: make(map[string]Tenant)}
+}
+
+// TenantService orchestrates tenant lifecycle operations.
+type TenantService struct {
+	repo Repository
+}
+
+// NewTenantService creates service instance.
+func NewTenantService(repo Repository) *TenantService {
+	return &TenantService{repo: repo}
+}
+
+// CreateTenant registers a new active tenant.
+func (s *TenantService) CreateTenant(ctx context.Context, tenant Tenant) (*Tenant, error) {
+	tenant.TenantID = ids.New()
+	tenant.Name = strings.TrimSpace(tenant.Name)
+	if tenant.Subscription == "" {
+		tenant.Subscription = SubscriptionFree
+	}
+	now := time.Now().UTC()
+	tenant.CreatedAt = now
+	tenant.UpdatedAt = now
+	tenant.IsActive = true
+	if err := tenant.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	return s.repo.CreateTenant(ctx, tenant)
+}
+
+// UpdateTenant applies mutate to the stored tenant and persists the result.
+func (s *TenantService) UpdateTenant(ctx context.Context, tenantID string, mutate func(*Tenant) error) (*Tenant, error) {
+	tenant, err := s.repo.GetTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := mutate(tenant); err != nil {
+		return nil, err
+	}
+	tenant.Name = strings.TrimSpace(tenant.Name)
+	tenant.UpdatedAt = time.Now().UTC()
+	if err := tenant.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	return s.repo.UpdateTenant(ctx, *tenant)
+}
+
+// SuspendTenant deactivates tenant so its requests are rejected.
+func (s *TenantService) SuspendTenant(ctx context.Context, tenantID string) (*Tenant, error) {
+	return s.UpdateTenant(ctx, tenantID, func(t *Tenant) error {
+		t.IsActive = false
+		return nil
+	})
+}
+
+// ReactivateTenant re-enables suspended tenant.
+func (s *TenantService) ReactivateTenant(ctx context.Context, tenantID string) (*Tenant, error) {
+	return s.UpdateTenant(ctx, tenantID, func(t *Tenant) error {
+		t.IsActive = true
+		return nil
+	})
+}
+
+// GetTenant fetches tenant regardless of its status.
+func (s *TenantService) GetTenant(ctx context.Context, tenantID string) (*Tenant, error) {
+	return s.repo.GetTenant(ctx, tenantID)
+}
+
+// ActiveTenant fetches tenant and fails with ErrInactive when it is suspended.
+func (s *TenantService) ActiveTenant(ctx context.Context, tenantID string) (*Tenant, error) {
+	tenant, err := s.repo.GetTenant(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !tenant.IsActive {
+		return nil, ErrInactive
+	}
+	return tenant, nil
+}
+
+// ListTenants proxies listing operation.
+func (s *TenantService) ListTenants(ctx context.Context, opt ListOptions) ([]Tenant, int, error) {
+	items, err := s.repo.ListTenants(ctx, opt)
+	if err != nil {
+		return nil, 0, err
+	}
+	count, err := s.repo.CountTenants(ctx, opt)
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, count, nil
+}
+
+// inMemoryRepository is prototyping repository with maps.
+type inMemoryRepository struct {
+	tenants map[string]Tenant
+	mu      sync.RWMutex
+}
+
+func (r *inMemoryRepository) matching(opt ListOptions) []Tenant {
+	var result []Tenant
+	search := strings.ToLower(strings.TrimSpace(opt.Search))
+	for _, tenant := range r.tenants {
+		if !opt.IncludeInactive && !tenant.IsActive {
+			continue
+		}
+		if search != "" && !strings.Contains(strings.ToLower(tenant.Name), search) {
+			continue
+		}
+		result = append(result, tenant)
+	}
+	return result
+}
+
+func (r *inMemoryRepository) ListTenants(ctx context.Context, opt ListOptions) ([]Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := r.matching(opt)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	start := opt.Offset
+	if start > len(result) {
+		return []Tenant{}, nil
+	}
+	end := start + opt.Limit
+	if opt.Limit <= 0 || end > len(result) {
+		end = len(result)
+	}
+	return append([]Tenant(nil), result[start:end]...), nil
+}
+
+func (r *inMemoryRepository) CountTenants(ctx context.Context, opt ListOptions) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.matching(opt)), nil
+}
+
+func (r *inMemoryRepository) GetTenant(ctx context.Context, tenantID string) (*Tenant, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tenant, ok := r.tenants[tenantID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	clone := tenant
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) CreateTenant(ctx context.Context, tenant Tenant) (*Tenant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.tenants[tenant.TenantID]; exists {
+		return nil, ErrConflict
+	}
+	if r.nameTaken(tenant.TenantID, tenant.Name) {
+		return nil, ErrConflict
+	}
+	r.tenants[tenant.TenantID] = tenant
+	clone := tenant
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) UpdateTenant(ctx context.Context, tenant Tenant) (*Tenant, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tenants[tenant.TenantID]; !ok {
+		return nil, ErrNotFound
+	}
+	if r.nameTaken(tenant.TenantID, tenant.Name) {
+		return nil, ErrConflict
+	}
+	r.tenants[tenant.TenantID] = tenant
+	clone := tenant
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) nameTaken(tenantID, name string) bool {
+	for id, existing := range r.tenants {
+		if id != tenantID && strings.EqualFold(existing.Name, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,128 @@
+package users
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+)
+
+// Role enumerates user roles within a tenant.
+type Role string
+
+const (
+	RoleOwner  Role = "owner"
+	RoleAdmin  Role = "admin"
+	RoleEditor Role = "editor"
+	RoleViewer Role = "viewer"
+)
+
+var roleRank = map[Role]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleAdmin:  3,
+	RoleOwner:  4,
+}
+
+// Valid reports whether role is one of the known roles.
+func (r Role) Valid() bool {
+	_, ok := roleRank[r]
+	return ok
+}
+
+// AtLeast reports whether role grants at least the privileges of other.
+func (r Role) AtLeast(other Role) bool {
+	return roleRank[r] >= roleRank[other]
+}
+
+// User represents the users table structure.
+type User struct {
+	UserID       string     `json:"user_id"`
+	TenantID     string     `json:"tenant_id"`
+	Email        string     `json:"email"`
+	PasswordHash string     `json:"-"`
+	FullName     string     `json:"full_name"`
+	Role         Role       `json:"role"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	LastLogin    *time.Time `json:"last_login"`
+	IsActive     bool       `json:"is_active"`
+}
+
+// Invitation is a pending request for someone to join a tenant.
+type Invitation struct {
+	InvitationID string     `json:"invitation_id"`
+	TenantID     string     `json:"tenant_id"`
+	Email        string     `json:"email"`
+	Role         Role       `json:"role"`
+	InvitedBy    string     `json:"invited_by"`
+	TokenHash    string     `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	AcceptedAt   *time.Time `json:"accepted_at"`
+}
+
+var (
+	// ErrNotFound is returned when user or invitation does not exist.
+	ErrNotFound = errors.New("users: resource not found")
+	// ErrConflict is returned when email is already registered or invited.
+	ErrConflict = errors.New("users: conflict detected")
+	// ErrInvalidInput indicates validation error.
+	ErrInvalidInput = errors.New("users: invalid input")
+	// ErrForbidden is returned when actor lacks privileges for the operation.
+	ErrForbidden = errors.New("users: operation not permitted")
+	// ErrInvalidToken is returned for forged, expired or already used tokens.
+	ErrInvalidToken = errors.New("users: invalid or expired token")
+	// ErrInactive is returned when user is deactivated.
+	ErrInactive = errors.New("users: user is deactivated")
+)
+
+// NormalizeEmail lowercases and trims email for lookups and uniqueness.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// Validate ensures user structure is valid according to business rules.
+func (u User) Validate() error {
+	if u.TenantID == "" {
+		return errors.New("tenant_id is required")
+	}
+	if err := validateEmail(u.Email); err != nil {
+		return err
+	}
+	if len(u.FullName) > 200 {
+		return errors.New("full_name must be <= 200 characters")
+	}
+	if !u.Role.Valid() {
+		return errors.New("role is invalid")
+	}
+	if u.PasswordHash == "" {
+		return errors.New("password_hash is required")
+	}
+	return nil
+}
+
+// Validate ensures invitation business rules.
+func (i Invitation) Validate() error {
+	if i.TenantID == "" {
+		return errors.New("tenant_id is required")
+	}
+	if err := validateEmail(i.Email); err != nil {
+		return err
+	}
+	if !i.Role.Valid() {
+		return errors.New("role is invalid")
+	}
+	if i.InvitedBy == "" {
+		return errors.New("invited_by is required")
+	}
+	return nil
+}
+
+func validateEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return errors.New("email is invalid")
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package users
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// Passwords are hashed with argon2id using the second recommended parameter
+// set of RFC 9106. The encoded form carries algorithm and cost, allowing the
+// parameters to be raised later without invalidating existing hashes.
+const (
+	passwordAlgorithm = "argon2id"
+	passwordTime      = 3
+	passwordMemoryKiB = 64 * 1024
+	passwordThreads   = 4
+	passwordSaltLen   = 16
+	passwordKeyLen    = 32
+
+	minPasswordLength = 10
+	maxPasswordLength = 128
+)
+
+// ValidatePassword enforces password policy.
+func ValidatePassword(password string) error {
+	n := utf8.RuneCountInString(password)
+	if n < minPasswordLength || n > maxPasswordLength {
+		return fmt.Errorf("password must be between %d and %d characters", minPasswordLength, maxPasswordLength)
+	}
+	return nil
+}
+
+// HashPassword derives encoded password hash in the form
+// argon2id$v=19,m=<memory KiB>,t=<time>,p=<threads>$<salt>$<key>.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, passwordSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("generate salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(password), salt, passwordTime, passwordMemoryKiB, passwordThreads, passwordKeyLen)
+	enc := base64.RawStdEncoding
+	return strings.Join([]string{
+		passwordAlgorithm,
+		fmt.Sprintf("v=%d,m=%d,t=%d,p=%d", argon2.Version, passwordMemoryKiB, passwordTime, passwordThreads),
+		enc.EncodeToString(salt),
+		enc.EncodeToString(key),
+	}, "$"), nil
+}
+
+// VerifyPassword reports whether password matches encoded hash.
+func VerifyPassword(encoded, password string) (bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != passwordAlgorithm {
+		return false, errors.New("unsupported password hash format")
+	}
+	var version int
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[1], "v=%d,m=%d,t=%d,p=%d", &version, &memory, &time, &threads); err != nil {
+		return false, fmt.Errorf("invalid password hash parameters: %w", err)
+	}
+	if version != argon2.Version || memory == 0 || time == 0 || threads == 0 {
+		return false, errors.New("invalid password hash parameters")
+	}
+	enc := base64.RawStdEncoding
+	salt, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return false, fmt.Errorf("decode salt: %w", err)
+	}
+	want, err := enc.DecodeString(parts[3])
+	if err != nil {
+		return false, fmt.Errorf("decode key: %w", err)
+	}
+	got := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(want)))
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
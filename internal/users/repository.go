@@ -0,0 +1,32 @@
+package users
+
+import (
+	"context"
+)
+
+// ListOptions configure search and pagination behaviour.
+type ListOptions struct {
+	TenantID        string
+	Search          string
+	Role            Role
+	IncludeInactive bool
+	Limit           int
+	Offset          int
+}
+
+// Repository defines persistence layer for users and invitations.
+type Repository interface {
+	ListUsers(ctx context.Context, opt ListOptions) ([]User, error)
+	CountUsers(ctx context.Context, opt ListOptions) (int, error)
+	GetUser(ctx context.Context, tenantID, userID string) (*User, error)
+	GetUserByEmail(ctx context.Context, email string) (*User, error)
+	CreateUser(ctx context.Context, user User) (*User, error)
+	UpdateUser(ctx context.Context, user User) (*User, error)
+
+	// CreateInvitation stores invitation, superseding pending invitations of
+	// the same email in the tenant. It fails with ErrConflict when another
+	// tenant has a pending invitation for the email.
+	CreateInvitation(ctx context.Context, invitation Invitation) (*Invitation, error)
+	GetInvitation(ctx context.Context, invitationID string) (*Invitation, error)
+	UpdateInvitation(ctx context.Context, invitation Invitation) (*Invitation, error)
+}
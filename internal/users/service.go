@@ -0,0 +1,427 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lumiforge/docfactory-backend/internal/ids"
+	"github.com/lumiforge/docfactory-backend/internal/tenants"
+)
+
+// DefaultInvitationTTL is how long invitation tokens remain valid.
+const DefaultInvitationTTL = 7 * 24 * time.Hour
+
+// NewInMemoryRepository creates thread-safe repository for prototyping.
+func NewInMemoryRepository() Repository {
+	return &inMemoryRepository{
+		users:       make(map[string]User),
+		invitations: make(map[string]Invitation),
+	}
+}
+
+// UserService orchestrates user lifecycle, invitations and role changes.
+type UserService struct {
+	repo          Repository
+	tenants       *tenants.TenantService
+	signer        tokenSigner
+	invitationTTL time.Duration
+}
+
+// NewUserService creates service instance. Secret signs invitation tokens and
+// must be shared by all instances serving the same tenants.
+func NewUserService(repo Repository, tenantService *tenants.TenantService, secret []byte) *UserService {
+	return &UserService{
+		repo:          repo,
+		tenants:       tenantService,
+		signer:        tokenSigner{secret: secret},
+		invitationTTL: DefaultInvitationTTL,
+	}
+}
+
+// InviteUser creates invitation on behalf of actor and returns the one-time
+// token that must be delivered to the invitee. Only owners may invite owners.
+// Inviting an email again supersedes its pending invitation, so that only the
+// latest token can be redeemed.
+func (s *UserService) InviteUser(ctx context.Context, actor User, email string, role Role) (*Invitation, string, error) {
+	if !actor.Role.AtLeast(RoleAdmin) {
+		return nil, "", fmt.Errorf("only admins can invite users: %w", ErrForbidden)
+	}
+	if role == RoleOwner && actor.Role != RoleOwner {
+		return nil, "", fmt.Errorf("only owners can invite owners: %w", ErrForbidden)
+	}
+	return s.createInvitation(ctx, actor.TenantID, email, role, actor.UserID)
+}
+
+// InviteOwner bootstraps the first owner of a tenant on behalf of platform
+// administration. The tenant must exist and be active.
+func (s *UserService) InviteOwner(ctx context.Context, tenantID, email string) (*Invitation, string, error) {
+	if _, err := s.tenants.ActiveTenant(ctx, tenantID); err != nil {
+		return nil, "", err
+	}
+	return s.createInvitation(ctx, tenantID, email, RoleOwner, "system")
+}
+
+func (s *UserService) createInvitation(ctx context.Context, tenantID, email string, role Role, invitedBy string) (*Invitation, string, error) {
+	email = NormalizeEmail(email)
+	if _, err := s.repo.GetUserByEmail(ctx, email); err == nil {
+		return nil, "", fmt.Errorf("email already registered: %w", ErrConflict)
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, "", err
+	}
+	now := time.Now().UTC()
+	invitation := Invitation{
+		InvitationID: ids.New(),
+		TenantID:     tenantID,
+		Email:        email,
+		Role:         role,
+		InvitedBy:    invitedBy,
+		CreatedAt:    now,
+		ExpiresAt:    now.Add(s.invitationTTL),
+	}
+	if err := invitation.Validate(); err != nil {
+		return nil, "", fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	token, err := s.signer.issue(invitation.InvitationID)
+	if err != nil {
+		return nil, "", err
+	}
+	invitation.TokenHash = hashToken(token)
+	created, err := s.repo.CreateInvitation(ctx, invitation)
+	if err != nil {
+		return nil, "", err
+	}
+	return created, token, nil
+}
+
+// AcceptInvitation redeems invitation token, creating an active user with the
+// given password. Each token can be redeemed once.
+func (s *UserService) AcceptInvitation(ctx context.Context, token, fullName, password string) (*User, error) {
+	invitationID, err := s.signer.verify(token)
+	if err != nil {
+		return nil, err
+	}
+	invitation, err := s.repo.GetInvitation(ctx, invitationID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	now := time.Now().UTC()
+	if invitation.TokenHash != hashToken(token) || invitation.AcceptedAt != nil || now.After(invitation.ExpiresAt) {
+		return nil, ErrInvalidToken
+	}
+	if err := ValidatePassword(password); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	hash, err := HashPassword(password)
+	if err != nil {
+		return nil, err
+	}
+	user := User{
+		UserID:       ids.New(),
+		TenantID:     invitation.TenantID,
+		Email:        invitation.Email,
+		PasswordHash: hash,
+		FullName:     strings.TrimSpace(fullName),
+		Role:         invitation.Role,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+		IsActive:     true,
+	}
+	if err := user.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	created, err := s.repo.CreateUser(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	invitation.AcceptedAt = &now
+	if _, err := s.repo.UpdateInvitation(ctx, *invitation); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// ListUsers proxies listing operation.
+func (s *UserService) ListUsers(ctx context.Context, opt ListOptions) ([]User, int, error) {
+	items, err := s.repo.ListUsers(ctx, opt)
+	if err != nil {
+		return nil, 0, err
+	}
+	count, err := s.repo.CountUsers(ctx, opt)
+	if err != nil {
+		return nil, 0, err
+	}
+	return items, count, nil
+}
+
+// GetUser fetches user within tenant.
+func (s *UserService) GetUser(ctx context.Context, tenantID, userID string) (*User, error) {
+	return s.repo.GetUser(ctx, tenantID, userID)
+}
+
+// ActiveUser fetches user and fails with ErrInactive when it is deactivated.
+func (s *UserService) ActiveUser(ctx context.Context, tenantID, userID string) (*User, error) {
+	user, err := s.repo.GetUser(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrInactive
+	}
+	return user, nil
+}
+
+// UserByEmail fetches user by email across tenants, used for login.
+func (s *UserService) UserByEmail(ctx context.Context, email string) (*User, error) {
+	return s.repo.GetUserByEmail(ctx, NormalizeEmail(email))
+}
+
+// RecordLogin stores time of successful login.
+func (s *UserService) RecordLogin(ctx context.Context, user User, at time.Time) (*User, error) {
+	user.LastLogin = &at
+	return s.repo.UpdateUser(ctx, user)
+}
+
+// UpdateProfile changes profile fields. Users may edit themselves, admins may
+// edit anyone in their tenant.
+func (s *UserService) UpdateProfile(ctx context.Context, actor User, userID string, mutate func(*User) error) (*User, error) {
+	if actor.UserID != userID && !actor.Role.AtLeast(RoleAdmin) {
+		return nil, fmt.Errorf("only admins can edit other users: %w", ErrForbidden)
+	}
+	user, err := s.repo.GetUser(ctx, actor.TenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	role, active := user.Role, user.IsActive
+	if err := mutate(user); err != nil {
+		return nil, err
+	}
+	user.Role, user.IsActive = role, active
+	user.FullName = strings.TrimSpace(user.FullName)
+	user.UpdatedAt = time.Now().UTC()
+	if err := user.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidInput, err)
+	}
+	return s.repo.UpdateUser(ctx, *user)
+}
+
+// ChangeRole assigns new role to user. Granting or revoking the owner role is
+// reserved to owners and the last active owner cannot be demoted.
+func (s *UserService) ChangeRole(ctx context.Context, actor User, userID string, role Role) (*User, error) {
+	if !role.Valid() {
+		return nil, fmt.Errorf("%w: role is invalid", ErrInvalidInput)
+	}
+	if !actor.Role.AtLeast(RoleAdmin) {
+		return nil, fmt.Errorf("only admins can change roles: %w", ErrForbidden)
+	}
+	user, err := s.repo.GetUser(ctx, actor.TenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if (role == RoleOwner || user.Role == RoleOwner) && actor.Role != RoleOwner {
+		return nil, fmt.Errorf("only owners can grant or revoke owner role: %w", ErrForbidden)
+	}
+	if user.Role == RoleOwner && role != RoleOwner {
+		if err := s.ensureAnotherOwner(ctx, *user); err != nil {
+			return nil, err
+		}
+	}
+	user.Role = role
+	user.UpdatedAt = time.Now().UTC()
+	return s.repo.UpdateUser(ctx, *user)
+}
+
+// DeactivateUser disables user access. Owners can only be deactivated by
+// owners and the last active owner cannot be deactivated.
+func (s *UserService) DeactivateUser(ctx context.Context, actor User, userID string) (*User, error) {
+	return s.setActive(ctx, actor, userID, false)
+}
+
+// ReactivateUser restores access for previously deactivated user.
+func (s *UserService) ReactivateUser(ctx context.Context, actor User, userID string) (*User, error) {
+	return s.setActive(ctx, actor, userID, true)
+}
+
+func (s *UserService) setActive(ctx context.Context, actor User, userID string, active bool) (*User, error) {
+	if !actor.Role.AtLeast(RoleAdmin) {
+		return nil, fmt.Errorf("only admins can change user status: %w", ErrForbidden)
+	}
+	user, err := s.repo.GetUser(ctx, actor.TenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Role == RoleOwner && actor.Role != RoleOwner {
+		return nil, fmt.Errorf("only owners can change owner status: %w", ErrForbidden)
+	}
+	if user.Role == RoleOwner && !active {
+		if err := s.ensureAnotherOwner(ctx, *user); err != nil {
+			return nil, err
+		}
+	}
+	user.IsActive = active
+	user.UpdatedAt = time.Now().UTC()
+	return s.repo.UpdateUser(ctx, *user)
+}
+
+func (s *UserService) ensureAnotherOwner(ctx context.Context, user User) error {
+	owners, err := s.repo.CountUsers(ctx, ListOptions{TenantID: user.TenantID, Role: RoleOwner})
+	if err != nil {
+		return err
+	}
+	if owners <= 1 && user.IsActive {
+		return fmt.Errorf("tenant must keep at least one active owner: %w", ErrConflict)
+	}
+	return nil
+}
+
+// inMemoryRepository is prototyping repository with maps.
+type inMemoryRepository struct {
+	users       map[string]User
+	invitations map[string]Invitation
+	mu          sync.RWMutex
+}
+
+func (r *inMemoryRepository) matching(opt ListOptions) []User {
+	var result []User
+	search := strings.ToLower(strings.TrimSpace(opt.Search))
+	for _, user := range r.users {
+		if user.TenantID != opt.TenantID {
+			continue
+		}
+		if !opt.IncludeInactive && !user.IsActive {
+			continue
+		}
+		if opt.Role != "" && user.Role != opt.Role {
+			continue
+		}
+		if search != "" && !strings.Contains(user.Email, search) && !strings.Contains(strings.ToLower(user.FullName), search) {
+			continue
+		}
+		result = append(result, user)
+	}
+	return result
+}
+
+func (r *inMemoryRepository) ListUsers(ctx context.Context, opt ListOptions) ([]User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	result := r.matching(opt)
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].CreatedAt.Before(result[j].CreatedAt)
+	})
+	start := opt.Offset
+	if start > len(result) {
+		return []User{}, nil
+	}
+	end := start + opt.Limit
+	if opt.Limit <= 0 || end > len(result) {
+		end = len(result)
+	}
+	return append([]User(nil), result[start:end]...), nil
+}
+
+func (r *inMemoryRepository) CountUsers(ctx context.Context, opt ListOptions) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.matching(opt)), nil
+}
+
+func (r *inMemoryRepository) GetUser(ctx context.Context, tenantID, userID string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, ok := r.users[userID]
+	if !ok || user.TenantID != tenantID {
+		return nil, ErrNotFound
+	}
+	clone := user
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) GetUserByEmail(ctx context.Context, email string) (*User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, user := range r.users {
+		if user.Email == email {
+			clone := user
+			return &clone, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+func (r *inMemoryRepository) CreateUser(ctx context.Context, user User) (*User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.users[user.UserID]; exists {
+		return nil, ErrConflict
+	}
+	for _, existing := range r.users {
+		if existing.Email == user.Email {
+			return nil, ErrConflict
+		}
+	}
+	r.users[user.UserID] = user
+	clone := user
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) UpdateUser(ctx context.Context, user User) (*User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	existing, ok := r.users[user.UserID]
+	if !ok || existing.TenantID != user.TenantID {
+		return nil, ErrNotFound
+	}
+	r.users[user.UserID] = user
+	clone := user
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) CreateInvitation(ctx context.Context, invitation Invitation) (*Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.invitations[invitation.InvitationID]; exists {
+		return nil, ErrConflict
+	}
+	for id, existing := range r.invitations {
+		if existing.Email != invitation.Email || existing.AcceptedAt != nil || !invitation.CreatedAt.Before(existing.ExpiresAt) {
+			continue
+		}
+		if existing.TenantID != invitation.TenantID {
+			return nil, ErrConflict
+		}
+		delete(r.invitations, id)
+	}
+	r.invitations[invitation.InvitationID] = invitation
+	clone := invitation
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) GetInvitation(ctx context.Context, invitationID string) (*Invitation, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	invitation, ok := r.invitations[invitationID]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	clone := invitation
+	return &clone, nil
+}
+
+func (r *inMemoryRepository) UpdateInvitation(ctx context.Context, invitation Invitation) (*Invitation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.invitations[invitation.InvitationID]; !ok {
+		return nil, ErrNotFound
+	}
+	r.invitations[invitation.InvitationID] = invitation
+	clone := invitation
+	return &clone, nil
+}
@@ -0,0 +1,56 @@
+package users
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// tokenSigner issues and verifies HMAC-signed invitation tokens of the form
+// <invitation_id>.<nonce>.<signature>. The signature rejects forged tokens
+// before storage lookup while the stored hash makes each token single-use.
+type tokenSigner struct {
+	secret []byte
+}
+
+func (s tokenSigner) issue(invitationID string) (string, error) {
+	nonce := make([]byte, 24)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	payload := invitationID + "." + base64.RawURLEncoding.EncodeToString(nonce)
+	return payload + "." + s.sign(payload), nil
+}
+
+// verify checks token signature and returns the embedded invitation ID.
+func (s tokenSigner) verify(token string) (string, error) {
+	idx := strings.LastIndex(token, ".")
+	if idx <= 0 {
+		return "", ErrInvalidToken
+	}
+	payload, signature := token[:idx], token[idx+1:]
+	if !hmac.Equal([]byte(signature), []byte(s.sign(payload))) {
+		return "", ErrInvalidToken
+	}
+	invitationID, _, ok := strings.Cut(payload, ".")
+	if !ok || invitationID == "" {
+		return "", ErrInvalidToken
+	}
+	return invitationID, nil
+}
+
+func (s tokenSigner) sign(payload string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// hashToken returns hex encoded SHA-256 digest used to store tokens at rest.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,71 @@
+// Package validation collects field level input errors so that clients see
+// every problem of a request at once.
+package validation
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Stable codes of field errors.
+const (
+	CodeRequired     = "required"
+	CodeLength       = "length"
+	CodeInvalidValue = "invalid_value"
+	CodeInvalidType  = "invalid_type"
+	CodeUnknownField = "unknown_field"
+	CodeNotFound     = "not_found"
+)
+
+// FieldError describes a single invalid field.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors is a list of field errors. The zero value is empty and ready to use.
+type Errors []FieldError
+
+// Add records invalid field.
+func (e *Errors) Add(field, code, message string) {
+	*e = append(*e, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Err returns e as error or nil when no field failed.
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e Errors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, f := range e {
+		parts = append(parts, f.Field+" "+f.Message)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Length checks that trimmed value has between minLen and maxLen runes.
+func (e *Errors) Length(field, value string, minLen, maxLen int) {
+	n := len([]rune(strings.TrimSpace(value)))
+	if n < minLen || n > maxLen {
+		e.Add(field, CodeLength, lengthMessage(minLen, maxLen))
+	}
+}
+
+// Required checks that value is not blank.
+func (e *Errors) Required(field, value string) {
+	if strings.TrimSpace(value) == "" {
+		e.Add(field, CodeRequired, "is required")
+	}
+}
+
+func lengthMessage(minLen, maxLen int) string {
+	if minLen == 0 {
+		return "must be at most " + strconv.Itoa(maxLen) + " characters"
+	}
+	return "must be between " + strconv.Itoa(minLen) + " and " + strconv.Itoa(maxLen) + " characters"
+}